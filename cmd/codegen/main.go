@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/letsmakecakes/jsonparser/internal/codegen"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+type Config struct {
+	lang    string
+	name    string
+	pkg     string
+	naming  string
+	dialect string
+	files   []string // Sample files; "-" for stdin
+}
+
+func main() {
+	config := parseFlags()
+
+	if err := run(config); err != nil {
+		_, err2 := fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err2 != nil {
+			log.Fatalf("error printing message to console: %v", err2)
+		}
+		os.Exit(1)
+	}
+}
+
+func parseFlags() *Config {
+	config := &Config{}
+
+	flag.StringVar(&config.lang, "lang", "go", "Output language: go (structs with json tags) or typescript")
+	flag.StringVar(&config.name, "name", "Root", "Name of the root type")
+	flag.StringVar(&config.pkg, "package", "main", "Package clause of Go output")
+	flag.StringVar(&config.naming, "naming", "path", "Names of nested types: path (parent type and member, as in OrderCustomer) or field (member alone, as in Customer)")
+	flag.StringVar(&config.dialect, "dialect", "strict", "Input dialect: strict (RFC 8259), json5 or jsonc")
+
+	flag.Usage = func() {
+		_, err := fmt.Fprintf(os.Stderr, "Usage: %s [options] sample.json...\n\n", filepath.Base(os.Args[0]))
+		if err != nil {
+			log.Fatalf("error printing message to console: %v", err)
+		}
+		_, err = fmt.Fprintf(os.Stderr, "Options:")
+		if err != nil {
+			log.Fatalf("error printing message to console: %v", err)
+		}
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	config.files = flag.Args()
+	if len(config.files) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	return config
+}
+
+// run merges every sample into one shape and prints its declarations.
+func run(config *Config) error {
+	lang, err := codegen.ParseLanguage(config.lang)
+	if err != nil {
+		return err
+	}
+	naming, err := codegen.ParseNaming(config.naming)
+	if err != nil {
+		return err
+	}
+	dialect, err := lexer.ParseDialect(config.dialect)
+	if err != nil {
+		return err
+	}
+
+	shape := &codegen.Shape{}
+	for _, file := range config.files {
+		input, err := readInput(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		v, err := parser.New(lexer.NewWithDialect(string(input), dialect)).Parse()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		shape.Add(v)
+	}
+
+	code, err := codegen.Generate(shape, codegen.Options{
+		Language: lang,
+		Name:     config.name,
+		Package:  config.pkg,
+		Naming:   naming,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	return nil
+}
+
+func readInput(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/letsmakecakes/jsonparser/internal/lsp"
+)
+
+const maxDepth = 32 // Maximum nesting depth for JSON
+
+func main() {
+	// stdout carries the protocol, so diagnostics go to stderr.
+	log.SetOutput(os.Stderr)
+
+	server := lsp.New(os.Stdin, os.Stdout, maxDepth)
+	if err := server.Run(); err != nil {
+		if errors.Is(err, lsp.ErrExitWithoutShutdown) {
+			os.Exit(1)
+		}
+		log.Fatalf("language server error: %v", err)
+	}
+}
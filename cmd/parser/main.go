@@ -1,17 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
+	"github.com/letsmakecakes/jsonparser/internal/batch"
+	"github.com/letsmakecakes/jsonparser/internal/bson"
+	"github.com/letsmakecakes/jsonparser/internal/cbor"
+	"github.com/letsmakecakes/jsonparser/internal/conformance"
+	"github.com/letsmakecakes/jsonparser/internal/cst"
 	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/msgpack"
 	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+	"github.com/letsmakecakes/jsonparser/internal/schema"
+	"github.com/letsmakecakes/jsonparser/internal/signature"
+	"github.com/letsmakecakes/jsonparser/internal/stats"
+	"github.com/letsmakecakes/jsonparser/internal/tabular"
+	"github.com/letsmakecakes/jsonparser/internal/toml"
 	"github.com/letsmakecakes/jsonparser/internal/validator"
+	"github.com/letsmakecakes/jsonparser/internal/xml"
+	"github.com/letsmakecakes/jsonparser/internal/yaml"
 	e "github.com/letsmakecakes/jsonparser/pkg/errors"
 	"io"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 )
@@ -19,10 +36,37 @@ import (
 const maxDepth = 32 // Maximum nesting depth for JSON
 
 type Config struct {
-	inputFile  string
-	verbose    bool
-	benchmark  bool
-	strictMode bool
+	inputFile   string
+	inputs      []string // Every file, directory and glob pattern given
+	include     string   // Comma-separated patterns of files searched for in directories
+	exclude     string   // Comma-separated patterns of files and directories to skip
+	jobs        int      // Files validated at once
+	verbose     bool
+	benchmark   bool
+	strictMode  bool
+	dialect     string
+	format      bool
+	conformance string
+	canonical   bool
+	from        string // Input format
+	to          string // Output format; empty to only validate
+	sqlTable    string // Table name for -to sql
+	xmlRoot     string // Document element name for -to xml
+	xmlUntyped  bool   // Omit the type annotations of -to xml
+	xmlArrays   string // Comma-separated elements -from xml reads as arrays
+	sortKeys    bool   // Deterministic map key order for -to cbor
+	extJSON     string // Extended JSON mode of -from bson
+	inferSchema bool   // Replace the documents with their inferred schema
+	enumMax     int    // Most distinct strings an inferred enum lists
+	stats       string // Report format of document statistics; empty for none
+	statsTop    int    // Entries in each statistics ranking
+	statsDepth  int    // Levels of the statistics size breakdown
+
+	sign           string // Algorithm to sign with
+	verify         string // Algorithm to verify with
+	keyFile        string
+	signatureFile  string // Detached signature; empty for an embedded one
+	signatureField string
 }
 
 func main() {
@@ -36,7 +80,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	fmt.Println("✓ JSON is valid")
+	if !config.writesOutput() {
+		fmt.Println("✓ JSON is valid")
+	}
 }
 
 func parseFlags() *Config {
@@ -46,9 +92,34 @@ func parseFlags() *Config {
 	flag.BoolVar(&config.verbose, "verbose", false, "Enable verbose output")
 	flag.BoolVar(&config.benchmark, "benchmark", false, "Show paring time")
 	flag.BoolVar(&config.strictMode, "strict", false, "Enable strict mode validation")
+	flag.StringVar(&config.dialect, "dialect", "strict", "Input dialect: strict (RFC 8259), json5 or jsonc")
+	flag.BoolVar(&config.format, "format", false, "Print the formatted document (comments are kept with -dialect jsonc)")
+	flag.BoolVar(&config.canonical, "canonical", false, "Print the RFC 8785 canonical form of the document (no trailing newline)")
+	flag.StringVar(&config.sign, "sign", "", "Sign the document with an algorithm: hs256 or ed25519 (needs -key)")
+	flag.StringVar(&config.verify, "verify", "", "Verify the document's signature with an algorithm: hs256 or ed25519 (needs -key)")
+	flag.StringVar(&config.keyFile, "key", "", "Key file: the HMAC secret, or a PEM Ed25519 private key (sign) or public key (verify)")
+	flag.StringVar(&config.signatureFile, "signature", "", "Detached signature file, written by -sign and read by -verify; without it the signature is embedded")
+	flag.StringVar(&config.signatureField, "signature-field", signature.DefaultField, "Member holding an embedded signature")
+	flag.StringVar(&config.from, "from", "json", "Input format: json (see -dialect), ndjson (one JSON document per line), yaml (all documents of a stream), toml, xml, cbor (a CBOR sequence), msgpack (concatenated MessagePack objects), bson (concatenated BSON documents, such as a mongodump file), or csv and tsv (a header row, then one record per row)")
+	flag.StringVar(&config.to, "to", "", "Print the document converted to a format: json, ndjson, yaml, toml, xml, cbor, diag (CBOR diagnostic notation), msgpack, bson, or for an array of objects csv, tsv, markdown, table or sql (defaults to json when -from is not json)")
+	flag.BoolVar(&config.sortKeys, "cbor-deterministic", false, "Sort map keys for -to cbor and -to diag, as the CBOR deterministic encoding requires")
+	flag.StringVar(&config.extJSON, "extjson", "relaxed", "MongoDB Extended JSON form that -from bson produces: relaxed (plain numbers and dates where exact) or canonical (every value typed)")
+	flag.BoolVar(&config.inferSchema, "infer-schema", false, "Print a JSON Schema (draft 2020-12) inferred from all input documents instead of the documents (see -to for its format)")
+	flag.IntVar(&config.enumMax, "enum-max", schema.DefaultOptions().MaxEnum, "Most distinct values of a string that -infer-schema lists as an enum (0 for none)")
+	flag.StringVar(&config.stats, "stats", "", "Print statistics of the document instead of it: text (a readable report) or json. Counts values per type, nesting depth, frequent keys, the longest strings and arrays, and the bytes taken by each subtree")
+	flag.IntVar(&config.statsTop, "stats-top", stats.DefaultOptions().Top, "Entries in each -stats ranking, and children listed per subtree")
+	flag.IntVar(&config.statsDepth, "stats-depth", stats.DefaultOptions().Depth, "Levels below the root in the -stats size breakdown")
+	flag.StringVar(&config.sqlTable, "sql-table", "data", "Table name for -to sql")
+	flag.StringVar(&config.xmlRoot, "xml-root", "root", "Document element name for -to xml")
+	flag.BoolVar(&config.xmlUntyped, "xml-untyped", false, "Omit the json:type and json:array annotations of -to xml (the XML then reads back as strings)")
+	flag.StringVar(&config.xmlArrays, "xml-arrays", "", "Comma-separated element names that -from xml always reads as arrays")
+	flag.StringVar(&config.include, "include", "*.json", "Comma-separated name patterns of the files validated in directories")
+	flag.StringVar(&config.exclude, "exclude", "", "Comma-separated name patterns of the files and directories skipped in directories and glob matches (such as node_modules)")
+	flag.IntVar(&config.jobs, "jobs", runtime.NumCPU(), "Files validated at once when given several")
+	flag.StringVar(&config.conformance, "conformance", "", "Run the y_/n_/i_ conformance suite files in a directory (JSONTestSuite layout)")
 
 	flag.Usage = func() {
-		_, err := fmt.Fprintf(os.Stderr, "Usage: %s [options] [file]\n\n", filepath.Base(os.Args[0]))
+		_, err := fmt.Fprintf(os.Stderr, "Usage: %s [options] [file|directory|pattern...]\n\n", filepath.Base(os.Args[0]))
 		if err != nil {
 			log.Fatalf("error printing message to console: %v", err)
 		}
@@ -61,13 +132,17 @@ func parseFlags() *Config {
 
 	flag.Parse()
 
-	// Check for positional args if a file is not provided as a flag.
-	if config.inputFile == "" && flag.NArg() > 0 {
-		config.inputFile = flag.Arg(0)
+	// Positional args follow a file provided as a flag
+	if config.inputFile != "" {
+		config.inputs = append(config.inputs, config.inputFile)
+	}
+	config.inputs = append(config.inputs, flag.Args()...)
+	if len(config.inputs) > 0 {
+		config.inputFile = config.inputs[0]
 	}
 
 	// Show usage and exit if no file is provided
-	if config.inputFile == "" {
+	if len(config.inputs) == 0 && config.conformance == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -75,9 +150,72 @@ func parseFlags() *Config {
 	return config
 }
 
+// batch reports whether the inputs name several files: more than one
+// input, a directory or a glob pattern. Each file is then validated on its
+// own.
+func (c *Config) batch() bool {
+	if len(c.inputs) != 1 {
+		return len(c.inputs) > 1
+	}
+	if c.inputFile == "-" {
+		return false
+	}
+	info, err := os.Stat(c.inputFile)
+	if err != nil {
+		return strings.ContainsAny(c.inputFile, "*?[")
+	}
+	return info.IsDir()
+}
+
+// writesOutput reports whether the selected mode prints its own output
+// instead of the validity message.
+func (c *Config) writesOutput() bool {
+	return c.batch() || c.format || c.canonical || c.conformance != "" || c.sign != "" || c.verify != "" || c.to != "" || c.inferSchema || c.stats != ""
+}
+
 func run(config *Config) error {
+	if config.conformance != "" {
+		return runConformance(config.conformance)
+	}
+
+	if config.batch() {
+		return validateFiles(config)
+	}
+
+	if config.format && config.canonical {
+		return errors.New("-format and -canonical cannot be combined")
+	}
+	if config.sign != "" && config.verify != "" {
+		return errors.New("-sign and -verify cannot be combined")
+	}
+	if config.stats != "" {
+		if config.stats != "text" && config.stats != "json" {
+			return fmt.Errorf("unknown statistics format %q (want text or json)", config.stats)
+		}
+		if config.statsTop < 0 || config.statsDepth < 0 {
+			return errors.New("-stats-top and -stats-depth cannot be negative")
+		}
+		if config.from != "json" {
+			return errors.New("-stats needs JSON input (-from json), since it measures the source text")
+		}
+		if config.to != "" || config.format || config.canonical || config.sign != "" || config.verify != "" || config.inferSchema {
+			return errors.New("-stats cannot be combined with -to, -format, -canonical, -sign, -verify or -infer-schema")
+		}
+	}
+	if (config.from != "json" || config.inferSchema) && config.to == "" && !config.format && !config.canonical && config.sign == "" && config.verify == "" {
+		config.to = "json"
+	}
+	if config.to != "" && (config.format || config.canonical || config.sign != "" || config.verify != "") {
+		return errors.New("-to cannot be combined with -format, -canonical, -sign or -verify")
+	}
+
 	start := time.Now()
 
+	dialect, err := lexer.ParseDialect(config.dialect)
+	if err != nil {
+		return err
+	}
+
 	input, err := readInput(config.inputFile)
 	if err != nil {
 		return fmt.Errorf("failed to read input: %w", err)
@@ -90,18 +228,38 @@ func run(config *Config) error {
 		}
 	}
 
-	l := lexer.New(string(input))
-	p := parser.New(l)
-	v := validator.New(maxDepth)
+	// Diagnostic notation of CBOR input shows the encoding itself: tags,
+	// byte strings and indefinite lengths
+	if config.from == "cbor" && config.to == "diag" {
+		text, err := cbor.Diagnose(input)
+		if err != nil {
+			return err
+		}
+		fmt.Print(text)
+		return nil
+	}
 
-	root, err := p.Parse()
+	docs, err := parseInput(config, input, dialect)
 	if err != nil {
-		return handleError(input, err)
+		return err
+	}
+	if config.inferSchema {
+		in := schema.NewInferrer(schema.Options{MaxEnum: config.enumMax})
+		for _, doc := range docs {
+			in.Add(doc.Root)
+		}
+		docs = []*parser.Document{{Root: in.Schema()}}
+	}
+	if len(docs) != 1 && (config.format || config.canonical || config.sign != "" || config.verify != "") {
+		return fmt.Errorf("input has %d documents, but -format, -canonical, -sign and -verify need exactly one", len(docs))
 	}
 
 	if config.strictMode {
-		if err := v.Validate(root); err != nil {
-			return fmt.Errorf("validation error: %w", err)
+		v := validator.New(maxDepth)
+		for _, doc := range docs {
+			if err := v.Validate(doc.Root); err != nil {
+				return fmt.Errorf("validation error: %w", err)
+			}
 		}
 	}
 
@@ -109,6 +267,466 @@ func run(config *Config) error {
 		displayBenchmark(start, len(input))
 	}
 
+	if config.stats != "" {
+		return printStats(config, input, dialect)
+	}
+
+	if config.format {
+		fmt.Println(printer.New("  ").PrintDocument(docs[0]))
+	}
+
+	if config.canonical {
+		canonical, err := printer.Canonical(docs[0].Root)
+		if err != nil {
+			return err
+		}
+		fmt.Print(canonical)
+	}
+
+	if config.to != "" {
+		return convertDocuments(config, docs)
+	}
+
+	doc := docs[0]
+	if config.sign != "" {
+		return signDocument(config, doc)
+	}
+	if config.verify != "" {
+		return verifyDocument(config, doc)
+	}
+
+	return nil
+}
+
+// validateFiles validates every file the inputs name on a pool of -jobs
+// workers, and prints a line per file in input order followed by a
+// summary. It fails if any file is invalid.
+func validateFiles(config *Config) error {
+	if config.format || config.canonical || config.sign != "" || config.verify != "" || config.to != "" || config.inferSchema || config.stats != "" {
+		return errors.New("several input files can only be validated; -format, -canonical, -sign, -verify, -to, -infer-schema and -stats need one")
+	}
+	for _, input := range config.inputs {
+		if input == "-" {
+			return errors.New("standard input cannot be combined with other inputs")
+		}
+	}
+	dialect, err := lexer.ParseDialect(config.dialect)
+	if err != nil {
+		return err
+	}
+
+	files, err := batch.Expand(config.inputs, batch.Filter{Include: splitList(config.include), Exclude: splitList(config.exclude)})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no files to validate")
+	}
+
+	start := time.Now()
+	failed := batch.Run(files, config.jobs, func(path string) error {
+		return validateFile(config, path, dialect)
+	}, func(r batch.Result) {
+		if r.Err == nil {
+			fmt.Printf("✓ %s\n", r.Path)
+			return
+		}
+		message := strings.TrimPrefix(r.Err.Error(), "\n")
+		if !strings.Contains(message, "\n") {
+			fmt.Printf("✗ %s: %s\n", r.Path, message)
+			return
+		}
+		// Indent multi-line parse errors below the file name
+		fmt.Printf("✗ %s\n    %s\n", r.Path, strings.ReplaceAll(message, "\n", "\n    "))
+	})
+
+	fmt.Printf("\n%d files: %d valid, %d invalid\n", len(files), len(files)-failed, failed)
+	if config.benchmark {
+		_, err := fmt.Fprintf(os.Stderr, "Validated %d files in %v\n", len(files), time.Since(start))
+		if err != nil {
+			log.Fatalf("error printing message to console: %v", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files are invalid", failed, len(files))
+	}
+	return nil
+}
+
+// validateFile parses one file in the -from format and, in strict mode,
+// validates its documents.
+func validateFile(config *Config, path string, dialect lexer.Dialect) error {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	docs, err := parseInput(config, input, dialect)
+	if err != nil {
+		return err
+	}
+	if config.strictMode {
+		v := validator.New(maxDepth)
+		for _, doc := range docs {
+			if err := v.Validate(doc.Root); err != nil {
+				return fmt.Errorf("validation error: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseInput parses the input in the -from format. JSON, TOML, XML and CSV
+// input hold one document; NDJSON, a YAML stream, a CBOR sequence and
+// streams of MessagePack objects or BSON documents may hold any number.
+func parseInput(config *Config, input []byte, dialect lexer.Dialect) ([]*parser.Document, error) {
+	switch config.from {
+	case "json":
+		doc, err := parser.New(lexer.NewWithDialect(string(input), dialect)).ParseDocument()
+		if err != nil {
+			if dialect == lexer.Strict {
+				return nil, reportExtensions(input, handleError(input, err))
+			}
+			return nil, handleError(input, err)
+		}
+		return []*parser.Document{doc}, nil
+	case "ndjson":
+		var docs []*parser.Document
+		for i, line := range strings.Split(string(input), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			v, err := parser.New(lexer.NewWithDialect(line, dialect)).Parse()
+			if err != nil {
+				// Report the line within the whole input
+				var parseErr *e.ParseError
+				if errors.As(err, &parseErr) {
+					parseErr.Line += i
+				}
+				return nil, handleError(input, err)
+			}
+			docs = append(docs, &parser.Document{Root: v})
+		}
+		return docs, nil
+	case "yaml":
+		values, err := yaml.Parse(string(input))
+		if err != nil {
+			return nil, handleError(input, err)
+		}
+		docs := make([]*parser.Document, len(values))
+		for i, v := range values {
+			docs[i] = &parser.Document{Root: v}
+		}
+		return docs, nil
+	case "toml":
+		v, err := toml.Parse(string(input))
+		if err != nil {
+			return nil, handleError(input, err)
+		}
+		return []*parser.Document{{Root: v}}, nil
+	case "xml":
+		v, err := xml.Parse(string(input), xmlMapping(config))
+		if err != nil {
+			return nil, handleError(input, err)
+		}
+		return []*parser.Document{{Root: v}}, nil
+	case "cbor":
+		values, err := cbor.ParseSequence(input)
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]*parser.Document, len(values))
+		for i, v := range values {
+			docs[i] = &parser.Document{Root: v}
+		}
+		return docs, nil
+	case "msgpack":
+		values, err := msgpack.ParseAll(input)
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]*parser.Document, len(values))
+		for i, v := range values {
+			docs[i] = &parser.Document{Root: v}
+		}
+		return docs, nil
+	case "bson":
+		mode, err := bson.ParseMode(config.extJSON)
+		if err != nil {
+			return nil, err
+		}
+		values, err := bson.ParseAll(input, mode)
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]*parser.Document, len(values))
+		for i, v := range values {
+			docs[i] = &parser.Document{Root: v}
+		}
+		return docs, nil
+	case "csv", "tsv":
+		comma := ','
+		if config.from == "tsv" {
+			comma = '\t'
+		}
+		v, err := tabular.ReadCSV(bytes.NewReader(input), comma)
+		if err != nil {
+			return nil, err
+		}
+		return []*parser.Document{{Root: v}}, nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q (want json, ndjson, yaml, toml, xml, cbor, msgpack, bson, csv or tsv)", config.from)
+	}
+}
+
+// convertDocuments prints documents in the -to format. Several JSON
+// documents are printed one after another, NDJSON one per line, several
+// YAML documents as a stream, several CBOR documents as a CBOR sequence,
+// and MessagePack objects and BSON documents concatenated; TOML, XML and
+// the tabular formats hold a single document.
+func convertDocuments(config *Config, docs []*parser.Document) error {
+	switch config.to {
+	case "json", "ndjson":
+		for _, doc := range docs {
+			if err := checkFinite(doc.Root); err != nil {
+				return err
+			}
+		}
+		for _, doc := range docs {
+			if config.to == "json" {
+				fmt.Println(printer.New("  ").PrintDocument(doc))
+			} else {
+				fmt.Println(printer.New("").Print(doc.Root))
+			}
+		}
+	case "yaml":
+		if len(docs) == 1 {
+			fmt.Print(yaml.Marshal(docs[0].Root))
+			return nil
+		}
+		values := make([]parser.Value, len(docs))
+		for i, doc := range docs {
+			values[i] = doc.Root
+		}
+		fmt.Print(yaml.MarshalAll(values))
+	case "toml":
+		if len(docs) != 1 {
+			return fmt.Errorf("input has %d documents, but a TOML file holds exactly one", len(docs))
+		}
+		text, err := toml.Marshal(docs[0].Root)
+		if err != nil {
+			return err
+		}
+		fmt.Print(text)
+	case "xml":
+		if len(docs) != 1 {
+			return fmt.Errorf("input has %d documents, but an XML document holds exactly one", len(docs))
+		}
+		text, err := xml.Marshal(docs[0].Root, xmlMapping(config))
+		if err != nil {
+			return err
+		}
+		fmt.Print(text)
+	case "cbor", "diag":
+		var data []byte
+		for _, doc := range docs {
+			marshal := cbor.Marshal
+			if config.sortKeys {
+				marshal = cbor.MarshalDeterministic
+			}
+			item, err := marshal(doc.Root)
+			if err != nil {
+				return err
+			}
+			data = append(data, item...)
+		}
+		if config.to == "diag" {
+			text, err := cbor.Diagnose(data)
+			if err != nil {
+				return err
+			}
+			fmt.Print(text)
+			return nil
+		}
+		_, err := os.Stdout.Write(data)
+		return err
+	case "msgpack":
+		var data []byte
+		for _, doc := range docs {
+			object, err := msgpack.Marshal(doc.Root)
+			if err != nil {
+				return err
+			}
+			data = append(data, object...)
+		}
+		_, err := os.Stdout.Write(data)
+		return err
+	case "bson":
+		var data []byte
+		for _, doc := range docs {
+			document, err := bson.Marshal(doc.Root)
+			if err != nil {
+				return err
+			}
+			data = append(data, document...)
+		}
+		_, err := os.Stdout.Write(data)
+		return err
+	case "csv", "tsv", "markdown", "table", "sql":
+		if len(docs) != 1 {
+			return fmt.Errorf("input has %d documents, but -to %s needs exactly one", len(docs), config.to)
+		}
+		t, err := tabular.FromValue(docs[0].Root)
+		if err != nil {
+			return err
+		}
+		switch config.to {
+		case "csv":
+			return t.WriteCSV(os.Stdout, ',')
+		case "tsv":
+			return t.WriteCSV(os.Stdout, '\t')
+		case "markdown":
+			return t.WriteMarkdown(os.Stdout)
+		case "table":
+			return t.WriteAligned(os.Stdout)
+		default:
+			return t.WriteSQL(os.Stdout, config.sqlTable)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q (want json, ndjson, yaml, toml, xml, cbor, diag, msgpack, bson, csv, tsv, markdown, table or sql)", config.to)
+	}
+	return nil
+}
+
+// printStats profiles the JSON input and prints the report in the -stats
+// format.
+func printStats(config *Config, input []byte, dialect lexer.Dialect) error {
+	root, err := cst.Parse(string(input), dialect)
+	if err != nil {
+		return handleError(input, err)
+	}
+	report := stats.Collect(root, stats.Options{Top: config.statsTop, Depth: config.statsDepth})
+	if config.stats == "json" {
+		fmt.Println(printer.New("  ").Print(report.Value()))
+		return nil
+	}
+	return report.WriteText(os.Stdout)
+}
+
+// checkFinite rejects the infinite and NaN numbers that YAML, CBOR and
+// other formats can hold, since JSON cannot express them.
+func checkFinite(v parser.Value) error {
+	var err error
+	parser.Walk(v, parser.Funcs{OnEnter: func(path parser.Path, node parser.Value) parser.Action {
+		if n, ok := node.(*parser.NumberValue); ok && (math.IsInf(n.Value, 0) || math.IsNaN(n.Value)) {
+			err = fmt.Errorf("JSON cannot express the number %s at %s", printer.New("").Print(n), path.Describe())
+			return parser.Stop
+		}
+		return parser.Continue
+	}})
+	return err
+}
+
+// xmlMapping returns the XML mapping selected by the -xml flags.
+func xmlMapping(config *Config) xml.Mapping {
+	m := xml.DefaultMapping()
+	m.Root = config.xmlRoot
+	m.Typed = !config.xmlUntyped
+	if config.xmlArrays != "" {
+		m.Arrays = strings.Split(config.xmlArrays, ",")
+	}
+	return m
+}
+
+// runConformance checks the parser against a conformance suite directory and
+// prints the per-file matrix and compliance score.
+func runConformance(dir string) error {
+	report, err := conformance.Run(dir)
+	if err != nil {
+		return fmt.Errorf("failed to run conformance suite: %w", err)
+	}
+	if err := report.WriteMatrix(os.Stdout); err != nil {
+		return err
+	}
+	if failures := report.Failures(); len(failures) > 0 {
+		return fmt.Errorf("%d of %d conformance files failed", len(failures), len(report.Results))
+	}
+	return nil
+}
+
+// loadKey reads the -key file for an algorithm name.
+func loadKey(config *Config, name string) (signature.Key, error) {
+	alg, err := signature.ParseAlgorithm(name)
+	if err != nil {
+		return nil, err
+	}
+	if config.keyFile == "" {
+		return nil, errors.New("signing and verifying need a -key file")
+	}
+	data, err := os.ReadFile(config.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	return signature.LoadKey(alg, data)
+}
+
+// signDocument writes a detached signature to the -signature file, or
+// prints the document with the signature embedded.
+func signDocument(config *Config, doc *parser.Document) error {
+	key, err := loadKey(config, config.sign)
+	if err != nil {
+		return err
+	}
+
+	if config.signatureFile != "" {
+		sig, err := signature.Sign(doc.Root, key)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(config.signatureFile, []byte(sig+"\n"), 0o644)
+	}
+
+	signed, err := signature.Embed(doc.Root, config.signatureField, key)
+	if err != nil {
+		return err
+	}
+	doc.Root = signed
+	fmt.Println(printer.New("  ").PrintDocument(doc))
+	return nil
+}
+
+// verifyDocument checks the detached signature in the -signature file, or
+// the signature embedded in the document.
+func verifyDocument(config *Config, doc *parser.Document) error {
+	key, err := loadKey(config, config.verify)
+	if err != nil {
+		return err
+	}
+
+	if config.signatureFile != "" {
+		sig, err := os.ReadFile(config.signatureFile)
+		if err != nil {
+			return fmt.Errorf("failed to read signature: %w", err)
+		}
+		err = signature.Verify(doc.Root, strings.TrimSpace(string(sig)), key)
+	} else {
+		err = signature.VerifyEmbedded(doc.Root, config.signatureField, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Signature is valid")
 	return nil
 }
 
@@ -134,6 +752,22 @@ func handleError(input []byte, err error) error {
 	return err
 }
 
+// reportExtensions appends the JSON5 extensions found in the input to a strict
+// mode parse error, so users can see why a JSON5 file was rejected.
+func reportExtensions(input []byte, err error) error {
+	extensions := lexer.ScanExtensions(string(input))
+	if len(extensions) == 0 {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\ninput uses JSON5 extensions (retry with -dialect json5):")
+	for _, ext := range extensions {
+		sb.WriteString("\n  " + ext.String())
+	}
+	return fmt.Errorf("%w%s", err, sb.String())
+}
+
 func formatParseError(input []byte, err *e.ParseError) error {
 	lines := strings.Split(string(input), "\n")
 	if err.Line-1 >= len(lines) {
@@ -0,0 +1,198 @@
+// Package batch finds the files named by paths, glob patterns and
+// directories, and checks them concurrently while reporting the results
+// in a deterministic order.
+package batch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Filter selects the files Expand finds. Patterns use the syntax of
+// filepath.Match and are matched against the end of a path: "*.json"
+// against the base name, and "fixtures/*.json" against the last two
+// elements.
+type Filter struct {
+	Include []string // Files searched for in directories; every file when empty
+	Exclude []string // Files and directories skipped in directories and glob matches
+}
+
+// Validate reports the first malformed pattern.
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// included reports whether a file found in a directory is searched for.
+func (f Filter) included(path string) bool {
+	if len(f.Include) == 0 {
+		return true
+	}
+	return matchAny(f.Include, path)
+}
+
+// excluded reports whether a file or directory is skipped.
+func (f Filter) excluded(path string) bool {
+	return matchAny(f.Exclude, path)
+}
+
+func matchAny(patterns []string, path string) bool {
+	elems := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for _, pattern := range patterns {
+		n := min(len(elems), strings.Count(pattern, "/")+1)
+		if ok, _ := filepath.Match(pattern, strings.Join(elems[len(elems)-n:], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns the files named by args, in order and without
+// duplicates. An existing file is taken as it is, whatever the filter. A
+// directory stands for the included files below it, in lexical order, and
+// a glob pattern for its matches in sorted order, without the excluded
+// ones; directories among the matches are searched too. A glob that
+// matches nothing is an error.
+func Expand(args []string, f Filter) ([]string, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if key := filepath.Clean(path); !seen[key] {
+			seen[key] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && !info.IsDir():
+			add(arg)
+		case err == nil:
+			if err := walk(arg, f, add); err != nil {
+				return nil, err
+			}
+		case isGlob(arg):
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+			for _, match := range matches {
+				if f.excluded(match) {
+					continue
+				}
+				info, err := os.Stat(match)
+				if err != nil {
+					return nil, err
+				}
+				if !info.IsDir() {
+					add(match)
+				} else if err := walk(match, f, add); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// walk adds the included files below a directory, skipping excluded
+// files and directories.
+func walk(root string, f Filter, add func(string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && f.excluded(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && f.included(path) {
+			add(path)
+		}
+		return nil
+	})
+}
+
+// isGlob reports whether a path has glob metacharacters.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Result is the outcome of checking one file.
+type Result struct {
+	Path string
+	Err  error // nil if the file passed
+}
+
+// Run checks files on at most jobs goroutines. report is called with the
+// result of every file in the order of files, as soon as that file and
+// all before it are done, and never concurrently. Run returns the number
+// of files that failed.
+func Run(files []string, jobs int, check func(path string) error, report func(Result)) int {
+	jobs = max(1, min(jobs, len(files)))
+
+	type outcome struct {
+		index int
+		err   error
+	}
+	work := make(chan int)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				outcomes <- outcome{i, check(files[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			work <- i
+		}
+		close(work)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// Hold results back until every earlier file is reported
+	pending := make(map[int]error)
+	next, failed := 0, 0
+	for o := range outcomes {
+		pending[o.index] = o.err
+		for {
+			err, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if err != nil {
+				failed++
+			}
+			report(Result{Path: files[next], Err: err})
+			next++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tree creates files under a temporary directory and returns it.
+func tree(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpand(t *testing.T) {
+	dir := tree(t, "a.json", "b.txt", "sub/c.json", "sub/d.json", "node_modules/e.json", "z.json")
+	rel := func(paths []string) string {
+		for i, path := range paths {
+			paths[i], _ = filepath.Rel(dir, path)
+			paths[i] = filepath.ToSlash(paths[i])
+		}
+		return strings.Join(paths, " ")
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		filter   Filter
+		expected string
+	}{
+		{"directory", []string{"."}, Filter{Include: []string{"*.json"}}, "a.json node_modules/e.json sub/c.json sub/d.json z.json"},
+		{"no include filter", []string{"sub", "b.txt"}, Filter{}, "sub/c.json sub/d.json b.txt"},
+		{"excluded directory", []string{"."}, Filter{Include: []string{"*.json"}, Exclude: []string{"node_modules"}}, "a.json sub/c.json sub/d.json z.json"},
+		{"excluded path", []string{"."}, Filter{Exclude: []string{"sub/c.json", "*.txt"}}, "a.json node_modules/e.json sub/d.json z.json"},
+		{"glob", []string{"sub/*.json", "*.txt"}, Filter{Exclude: []string{"d.json"}}, "sub/c.json b.txt"},
+		{"glob matching directories", []string{"s*"}, Filter{Include: []string{"c.*"}}, "sub/c.json"},
+		{"named files ignore the filter", []string{"b.txt"}, Filter{Include: []string{"*.json"}, Exclude: []string{"b.txt"}}, "b.txt"},
+		{"duplicates", []string{"z.json", "*.json", "./a.json"}, Filter{}, "z.json a.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]string, len(tt.args))
+			for i, arg := range tt.args {
+				args[i] = filepath.Join(dir, filepath.FromSlash(arg))
+			}
+			files, err := Expand(args, tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rel(files); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	dir := tree(t, "a.json")
+	tests := []struct {
+		name     string
+		args     []string
+		filter   Filter
+		expected string
+	}{
+		{"missing file", []string{filepath.Join(dir, "b.json")}, Filter{}, "no such file"},
+		{"glob without matches", []string{filepath.Join(dir, "*.yaml")}, Filter{}, "no files match"},
+		{"bad glob", []string{filepath.Join(dir, "[a")}, Filter{}, "invalid pattern"},
+		{"bad filter", []string{dir}, Filter{Exclude: []string{"[x"}}, `invalid pattern "[x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Expand(tt.args, tt.filter); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	files := make([]string, 50)
+	for i := range files {
+		files[i] = string(rune('a'+i%26)) + strings.Repeat("x", i/26)
+	}
+
+	var running, peak atomic.Int32
+	check := func(path string) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		// Later files finish first, so results arrive out of order
+		time.Sleep(time.Duration(len(files)-len(path)) * 10 * time.Microsecond)
+		if strings.HasPrefix(path, "b") {
+			return errors.New("bad")
+		}
+		return nil
+	}
+
+	var reported []string
+	failed := Run(files, 4, check, func(r Result) {
+		reported = append(reported, r.Path)
+		if (r.Err != nil) != strings.HasPrefix(r.Path, "b") {
+			t.Errorf("unexpected result for %s: %v", r.Path, r.Err)
+		}
+	})
+	if failed != 2 {
+		t.Errorf("expected 2 failures, got %d", failed)
+	}
+	if strings.Join(reported, " ") != strings.Join(files, " ") {
+		t.Errorf("expected results in input order, got %v", reported)
+	}
+	if peak.Load() > 4 {
+		t.Errorf("expected at most 4 concurrent checks, got %d", peak.Load())
+	}
+
+	if failed := Run(nil, 0, check, func(Result) { t.Error("unexpected result") }); failed != 0 {
+		t.Errorf("expected no failures without files, got %d", failed)
+	}
+}
@@ -0,0 +1,75 @@
+// Package bson converts between the parser's AST and BSON, the binary
+// format of MongoDB documents and dumps.
+//
+// BSON types that JSON lacks are represented in MongoDB Extended JSON
+// (version 2): an ObjectId is {"$oid": "..."}, a date {"$date": ...}, a
+// 64-bit integer {"$numberLong": "..."}, a decimal {"$numberDecimal":
+// "..."}, and so on. Marshal turns such wrappers back into the typed BSON
+// values they describe. Decoding produces either the canonical form, which
+// keeps the type of every value, or the relaxed form, which writes numbers
+// and dates as plain JSON where that loses nothing a reader would notice.
+package bson
+
+import "fmt"
+
+// Mode selects the form of Extended JSON that decoding produces.
+type Mode int
+
+const (
+	// Relaxed writes int32, int64 and finite double values as JSON numbers,
+	// and dates from 1970 to 9999 as RFC 3339 strings. An int64 beyond 2^53,
+	// which a JSON number cannot hold exactly, stays a $numberLong.
+	Relaxed Mode = iota
+	// Canonical wraps every number and date, so that encoding the result
+	// gives back the same BSON.
+	Canonical
+)
+
+// String returns the mode name used on the command line.
+func (m Mode) String() string {
+	switch m {
+	case Relaxed:
+		return "relaxed"
+	case Canonical:
+		return "canonical"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// ParseMode maps a mode name, as accepted on the command line, to a Mode.
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "", "relaxed":
+		return Relaxed, nil
+	case "canonical":
+		return Canonical, nil
+	default:
+		return Relaxed, fmt.Errorf("unknown Extended JSON mode %q (want canonical or relaxed)", name)
+	}
+}
+
+// Element types
+const (
+	typeDouble        = 0x01
+	typeString        = 0x02
+	typeDocument      = 0x03
+	typeArray         = 0x04
+	typeBinary        = 0x05
+	typeUndefined     = 0x06 // Deprecated
+	typeObjectID      = 0x07
+	typeBool          = 0x08
+	typeDateTime      = 0x09
+	typeNull          = 0x0a
+	typeRegex         = 0x0b
+	typeDBPointer     = 0x0c // Deprecated
+	typeCode          = 0x0d
+	typeSymbol        = 0x0e // Deprecated
+	typeCodeWithScope = 0x0f // Deprecated
+	typeInt32         = 0x10
+	typeTimestamp     = 0x11
+	typeInt64         = 0x12
+	typeDecimal128    = 0x13
+	typeMinKey        = 0xff
+	typeMaxKey        = 0x7f
+)
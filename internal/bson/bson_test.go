@@ -0,0 +1,310 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+// marshalTests are also decoded in canonical mode and encoded again.
+var marshalTests = []struct {
+	input    string // JSON5
+	expected string // Hex
+}{
+	{`{}`, "05000000 00"},
+	{`{"a": 1}`, "0c000000 10 6100 01000000 00"},
+	{`{"a": -2147483648}`, "0c000000 10 6100 00000080 00"},
+	{`{"a": 2147483648}`, "10000000 12 6100 0000008000000000 00"},
+	{`{"a": 1.5}`, "10000000 01 6100 000000000000f83f 00"},
+	{`{"a": "b"}`, "0e000000 02 6100 02000000 6200 00"},
+	{`{"a": true, "b": null}`, "0c000000 08 6100 01 0a 6200 00"},
+	{`{"a": [1]}`, "14000000 04 6100 0c000000 10 3000 01000000 00 00"},
+	{`{"a": {"b": false}}`, "11000000 03 6100 09000000 08 6200 00 00 00"},
+	{`{"_id": {"$oid": "57e193d7a9cc81b4027498b5"}}`, "16000000 07 5f696400 57e193d7a9cc81b4027498b5 00"},
+	{`{"a": {"$numberInt": "-1"}}`, "0c000000 10 6100 ffffffff 00"},
+	{`{"a": {"$numberLong": "1"}}`, "10000000 12 6100 0100000000000000 00"},
+	{`{"a": {"$numberDouble": "1.0"}}`, "10000000 01 6100 000000000000f03f 00"},
+	{`{"a": {"$numberDouble": "-Infinity"}}`, "10000000 01 6100 000000000000f0ff 00"},
+	{`{"a": {"$numberDecimal": "1"}}`, "18000000 13 6100 0100000000000000 0000000000004030 00"},
+	{`{"a": {"$numberDecimal": "-Infinity"}}`, "18000000 13 6100 0000000000000000 00000000000000f8 00"},
+	{`{"a": {"$date": {"$numberLong": "1000"}}}`, "10000000 09 6100 e803000000000000 00"},
+	{`{"a": {"$date": {"$numberLong": "-1"}}}`, "10000000 09 6100 ffffffffffffffff 00"},
+	{`{"a": {"$binary": {"base64": "AQI=", "subType": "80"}}}`, "0f000000 05 6100 02000000 80 0102 00"},
+	{`{"a": {"$timestamp": {"t": 1, "i": 2}}}`, "10000000 11 6100 0200000001000000 00"},
+	{`{"a": {"$regularExpression": {"pattern": "x", "options": "im"}}}`, "0d000000 0b 6100 7800 696d00 00"},
+	{`{"a": {"$dbPointer": {"$ref": "c", "$id": {"$oid": "57e193d7a9cc81b4027498b5"}}}}`, "1a000000 0c 6100 02000000 6300 57e193d7a9cc81b4027498b5 00"},
+	{`{"a": {"$code": "x"}, "b": {"$symbol": "y"}}`, "17000000 0d 6100 02000000 7800 0e 6200 02000000 7900 00"},
+	{`{"a": {"$code": "x", "$scope": {}}}`, "17000000 0f 6100 0f000000 02000000 7800 05000000 00 00"},
+	{`{"a": {"$minKey": 1}, "b": {"$maxKey": 1}, "c": {"$undefined": true}}`, "0e000000 ff 6100 7f 6200 06 6300 00"},
+	{`{"$ref": "c", "$id": 1}`, "1a000000 02 2472656600 02000000 6300 10 24696400 01000000 00"},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, tt := range marshalTests {
+		got, err := Marshal(testutil.JSON(t, tt.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if expected := strings.ReplaceAll(tt.expected, " ", ""); hex.EncodeToString(got) != expected {
+			t.Errorf("%s: expected %s, got %x", tt.input, expected, got)
+		}
+	}
+}
+
+func TestMarshal_RelaxedForms(t *testing.T) {
+	tests := []struct {
+		input    string // Relaxed Extended JSON
+		expected string // Canonical Extended JSON
+	}{
+		{`{"a": {"$date": "1970-01-01T00:00:01Z"}}`, `{"a": {"$date": {"$numberLong": "1000"}}}`},
+		{`{"a": {"$date": "2024-02-29T12:00:00.123+01:00"}}`, `{"a": {"$date": {"$numberLong": "1709204400123"}}}`},
+		{`{"a": {"$date": 1000}}`, `{"a": {"$date": {"$numberLong": "1000"}}}`},
+		{`{"a": {"$regularExpression": {"pattern": "x", "options": "mi"}}}`, `{"a": {"$regularExpression": {"pattern": "x", "options": "im"}}}`},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(testutil.JSON(t, tt.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		expected, _ := Marshal(testutil.JSON(t, tt.expected))
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: expected %x, got %x", tt.input, expected, got)
+		}
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`[1]`, "a BSON document must be an object, found array"},
+		{`{"$oid": "57e193d7a9cc81b4027498b5"}`, "found an Extended JSON value"},
+		{`{"a": {"$oid": "xyz"}}`, `invalid Extended JSON at a: "$oid" must be a string of 24 hex digits`},
+		{`{"a": {"$oid": "57e193d7a9cc81b4027498b5", "x": 1}}`, "unexpected keys $oid, x"},
+		{`{"a": {"$numberLong": 1}}`, `"$numberLong" must be a string holding a 64-bit integer`},
+		{`{"a": {"$numberInt": "2147483648"}}`, `"$numberInt" must be a string holding a 32-bit integer`},
+		{`{"a": {"$numberDouble": "inf"}}`, `"$numberDouble" must be a string`},
+		{`{"a": {"$numberDecimal": "1.2.3"}}`, `invalid decimal "1.2.3"`},
+		{`{"a": {"$date": "yesterday"}}`, `"$date" must be an RFC 3339 time`},
+		{`{"a": {"$binary": {"base64": "AQ==", "subType": "100"}}}`, `"subType" must be one or two hex digits`},
+		{`{"a": {"$binary": "AQ=="}}`, `"$binary" must hold "base64" and "subType"`},
+		{`{"a": {"$timestamp": {"t": -1, "i": 0}}}`, "unsigned 32-bit integers"},
+		{`{"a": {"$regularExpression": {"pattern": "\u0000", "options": ""}}}`, "without NUL bytes"},
+		{`{"a": {"$minKey": 0}}`, `"$minKey" must be 1`},
+		{`{"a": [{"b\u0000": 1}]}`, "cannot encode the key at a[0]"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(testutil.JSON(t, tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.message, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // Hex
+		mode     Mode
+		expected string // JSON5
+	}{
+		{"relaxed numbers", "2d000000 10 6100 01000000 12 6200 0000008000000000 01 6300 000000000000f83f 12 6400 0200000000002000 00",
+			Relaxed, `{"a": 1, "b": 2147483648, "c": 1.5, "d": {"$numberLong": "9007199254740994"}}`},
+		{"canonical numbers", "22000000 10 6100 01000000 12 6200 0000008000000000 01 6300 000000000000f03f 00",
+			Canonical, `{"a": {"$numberInt": "1"}, "b": {"$numberLong": "2147483648"}, "c": {"$numberDouble": "1.0"}}`},
+		{"special doubles", "26000000 01 6100 000000000000f07f 01 6200 0000000000000080 01 6300 50efe2d6e41a4b44 00",
+			Canonical, `{"a": {"$numberDouble": "Infinity"}, "b": {"$numberDouble": "-0.0"}, "c": {"$numberDouble": "1.0E+21"}}`},
+		{"relaxed infinity", "10000000 01 6100 000000000000f07f 00", Relaxed, `{"a": {"$numberDouble": "Infinity"}}`},
+		{"relaxed dates", "1b000000 09 6100 7b00000000000000 09 6200 ffffffffffffffff 00",
+			Relaxed, `{"a": {"$date": "1970-01-01T00:00:00.123Z"}, "b": {"$date": {"$numberLong": "-1"}}}`},
+		{"canonical date", "10000000 09 6100 e803000000000000 00", Canonical, `{"a": {"$date": {"$numberLong": "1000"}}}`},
+		{"decimal", "18000000 13 6100 9600000000000000 0000000000003c30 00",
+			Relaxed, `{"a": {"$numberDecimal": "1.50"}}`},
+		{"array keys are ignored", "14000000 04 6100 0c000000 10 7800 07000000 00 00", Relaxed, `{"a": [7]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(testutil.Hex(t, tt.input), tt.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+// TestParse_Canonical decodes the marshal vectors in canonical mode and
+// checks that encoding the result gives the same bytes.
+func TestParse_Canonical(t *testing.T) {
+	for _, tt := range marshalTests {
+		data := testutil.Hex(t, tt.expected)
+		v, err := Parse(data, Canonical)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		got, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", printer.New("").Print(v), err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: decoded as %s, which encodes as %x", tt.input, printer.New("").Print(v), got)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	deep := parser.Object().Set("x", parser.Null())
+	for range maxDepth + 1 {
+		deep = parser.Object().Set("x", deep)
+	}
+	deepData, _ := Marshal(deep)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		message string
+	}{
+		{"empty", nil, "no document in the input"},
+		{"short length", testutil.Hex(t, "04000000 00"), "document length 4 is less than 5 at offset 0"},
+		{"truncated", testutil.Hex(t, "06000000 00"), "unexpected end of data at offset 5"},
+		{"missing terminator", testutil.Hex(t, "05000000 01"), "document does not end with a NUL byte at offset 0"},
+		{"trailing data", testutil.Hex(t, "05000000 00 05"), "unexpected data after the document at offset 5"},
+		{"unknown type", testutil.Hex(t, "08000000 20 6100 00"), "unknown element type 0x20 at offset 4"},
+		{"short element", testutil.Hex(t, "0a000000 10 6100 0100 00"), "element exceeds its document at offset 7"},
+		{"unterminated name", testutil.Hex(t, "08000000 10 6161 00"), "unterminated name at offset 5"},
+		{"bad boolean", testutil.Hex(t, "09000000 08 6100 02 00"), "boolean byte is 0x02, not 0 or 1 at offset 4"},
+		{"invalid UTF-8", testutil.Hex(t, "0e000000 02 6100 02000000 ff00 00"), "string is not valid UTF-8 at offset 7"},
+		{"unterminated string", testutil.Hex(t, "0e000000 02 6100 02000000 6161 00"), "string does not end with a NUL byte at offset 7"},
+		{"duplicate key", testutil.Hex(t, "0b000000 0a 6100 0a 6100 00"), `duplicate key "a" at offset 7`},
+		{"oversized document", testutil.Hex(t, "0d000000 03 6100 ff000000 00 00"), "document length 255 does not fit its container at offset 7"},
+		{"deep nesting", deepData, "nesting exceeds 1000 levels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, Relaxed)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	// Documents arrive one at a time, as from a mongodump file
+	r, w := io.Pipe()
+	go func() {
+		w.Write(testutil.Hex(t, "0c000000 10 6100 01000000 00"))
+		w.Write(testutil.Hex(t, "0e000000 02 6200"))
+		w.Write(testutil.Hex(t, "02000000 7800 00"))
+		w.Close()
+	}()
+	d := NewDecoder(r, Relaxed)
+	var got []string
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, printer.New("").Print(v))
+	}
+	if expected := `{"a":1} {"b":"x"}`; strings.Join(got, " ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(got, " "))
+	}
+
+	// A stream cut inside a document
+	d = NewDecoder(bytes.NewReader(testutil.Hex(t, "05000000 00 0c000000 10")), Relaxed)
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	values, err := ParseAll(testutil.Hex(t, "05000000 00 05000000 00"), Canonical)
+	if err != nil || len(values) != 2 {
+		t.Errorf("expected two documents, got %d (%v)", len(values), err)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // Empty for an error
+	}{
+		{"1", "1"},
+		{"-1", "-1"},
+		{"0", "0"},
+		{"-0", "-0"},
+		{"0.00", "0.00"},
+		{"1.50", "1.50"},
+		{"+12.345", "12.345"},
+		{"0.001234", "0.001234"},
+		{"0.0000001234", "1.234E-7"},
+		{"1000", "1000"},
+		{"1E+3", "1E+3"},
+		{"1.0e3", "1.0E+3"},
+		{"123E-9", "1.23E-7"},
+		{"Infinity", "Infinity"},
+		{"-inf", "-Infinity"},
+		{"NaN", "NaN"},
+		{"9.999999999999999999999999999999999E+6144", "9.999999999999999999999999999999999E+6144"},
+		{"1E-6176", "1E-6176"},
+		{"0E-6177", "0E-6176"},
+		{"1E+6112", "1.0E+6112"},
+		{"10E-6177", "1E-6176"},
+		{"1000000000000000000000000000000000000", "1.000000000000000000000000000000000E+36"},
+		{"12345678901234567890123456789012345", ""},
+		{"1E-6177", ""},
+		{"1E+6145", ""},
+		{"", ""},
+		{".", ""},
+		{"1e", ""},
+		{"1.2.3", ""},
+		{"0x10", ""},
+	}
+	for _, tt := range tests {
+		hi, lo, err := parseDecimal(tt.input)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s", tt.input, formatDecimal(hi, lo))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got := formatDecimal(hi, lo); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+
+	// Coefficients above 10^34 - 1 are not canonical and read as zero
+	if got := formatDecimal(0x3041ed09bead87c0, 0x378d8e6400000000); got != "0" {
+		t.Errorf("expected an oversized coefficient to give 0, got %s", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{"name": "Ada", "n": [0, -1, 2147483648, 1.5, -0.25, 1e300], "nested": {"x": null, "t": true, "e": {}}}`,
+		`{"_id": {"$oid": "57e193d7a9cc81b4027498b5"}, "when": {"$date": "2024-02-29T12:00:00.123Z"}}`,
+		`{"big": {"$numberLong": "9007199254740993"}, "price": {"$numberDecimal": "19.90"}, "nan": {"$numberDouble": "NaN"}}`,
+		`{"": "", "ü水😀": ["\u0000"], "bin": {"$binary": {"base64": "AAECAw==", "subType": "04"}}}`,
+	}
+	parse := func(data []byte) (parser.Value, error) { return Parse(data, Relaxed) }
+	for _, input := range inputs {
+		testutil.RoundTrip(t, input, Marshal, parse)
+	}
+}
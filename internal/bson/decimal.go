@@ -0,0 +1,130 @@
+package bson
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal128 limits, from IEEE 754-2008
+const (
+	exponentBias = 6176
+	minExponent  = -6176
+	maxExponent  = 6111
+	maxDigits    = 34
+)
+
+// maxCoefficient is the largest coefficient, 10^34 - 1.
+var maxCoefficient = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(maxDigits), nil), big.NewInt(1))
+
+// formatDecimal formats a decimal128 given as its high and low 64 bits, as
+// the BSON specification describes: plain notation for exponents from -6
+// up to 0 after adjusting for the digits, scientific notation otherwise,
+// and every digit of the coefficient kept, so 1.50 stays "1.50".
+func formatDecimal(hi, lo uint64) string {
+	sign := ""
+	if hi>>63 == 1 {
+		sign = "-"
+	}
+	var exp int
+	coef := new(big.Int)
+	switch {
+	case hi>>58&0x1f == 0x1f:
+		return "NaN"
+	case hi>>58&0x1f == 0x1e:
+		return sign + "Infinity"
+	case hi>>61&3 == 3:
+		// The coefficient would exceed 34 digits, which makes it zero
+		exp = int(hi>>47&0x3fff) - exponentBias
+	default:
+		exp = int(hi>>49&0x3fff) - exponentBias
+		coef.SetUint64(hi & (1<<49 - 1))
+		coef.Lsh(coef, 64)
+		coef.Or(coef, new(big.Int).SetUint64(lo))
+		if coef.Cmp(maxCoefficient) > 0 {
+			coef.SetInt64(0)
+		}
+	}
+
+	digits := coef.String()
+	adjusted := exp + len(digits) - 1
+	switch {
+	case exp > 0 || adjusted < -6:
+		s := digits[:1]
+		if len(digits) > 1 {
+			s += "." + digits[1:]
+		}
+		return fmt.Sprintf("%s%sE%+d", sign, s, adjusted)
+	case exp == 0:
+		return sign + digits
+	}
+	point := len(digits) + exp
+	if point <= 0 {
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	}
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// parseDecimal parses a decimal string into the high and low 64 bits of a
+// decimal128. It accepts the notation formatDecimal writes, Infinity and
+// NaN, and rejects values that need rounding: more than 34 significant
+// digits, or an exponent outside the range even after moving zeros between
+// the coefficient and the exponent.
+func parseDecimal(s string) (hi, lo uint64, err error) {
+	invalid := fmt.Errorf("invalid decimal %q", s)
+
+	rest := s
+	var signBit uint64
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		if rest[0] == '-' {
+			signBit = 1 << 63
+		}
+		rest = rest[1:]
+	}
+	switch strings.ToLower(rest) {
+	case "infinity", "inf":
+		return signBit | 0x1e<<58, 0, nil
+	case "nan":
+		return 0x1f << 58, 0, nil
+	}
+
+	mantissa, exp := rest, 0
+	if i := strings.IndexAny(rest, "eE"); i >= 0 {
+		mantissa = rest[:i]
+		if exp, err = strconv.Atoi(rest[i+1:]); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, 0, invalid
+	}
+	exp -= len(fraction)
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		// Zero keeps its exponent, clamped to the range
+		return signBit | uint64(min(max(exp, minExponent), maxExponent)+exponentBias)<<49, 0, nil
+	}
+	for (len(digits) > maxDigits || exp < minExponent) && strings.HasSuffix(digits, "0") {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	for exp > maxExponent && len(digits) < maxDigits {
+		digits += "0"
+		exp--
+	}
+	switch {
+	case len(digits) > maxDigits:
+		return 0, 0, fmt.Errorf("decimal %q has more than %d significant digits", s, maxDigits)
+	case exp < minExponent || exp > maxExponent:
+		return 0, 0, fmt.Errorf("decimal %q is out of range", s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	lo = new(big.Int).And(coef, new(big.Int).SetUint64(1<<64-1)).Uint64()
+	hi = signBit | uint64(exp+exponentBias)<<49 | new(big.Int).Rsh(coef, 64).Uint64()
+	return hi, lo, nil
+}
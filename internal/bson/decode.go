@@ -0,0 +1,419 @@
+package bson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// maxDepth bounds the nesting of documents and arrays.
+const maxDepth = 1000
+
+// A Decoder reads a stream of concatenated BSON documents, such as a
+// mongodump .bson file, one per call to Decode.
+type Decoder struct {
+	r      *bufio.Reader
+	mode   Mode
+	offset int64 // Bytes consumed so far
+}
+
+// NewDecoder returns a decoder reading from r that produces Extended JSON
+// in the given mode.
+func NewDecoder(r io.Reader, mode Mode) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), mode: mode}
+}
+
+// Decode reads the next document and converts it to an object. At the end
+// of the stream Decode returns io.EOF; a stream that ends inside a
+// document gives an error wrapping io.ErrUnexpectedEOF.
+func (d *Decoder) Decode() (parser.Value, error) {
+	if _, err := d.r.Peek(1); err == io.EOF {
+		return nil, io.EOF
+	}
+	start := d.offset
+	header, err := d.read(4)
+	if err != nil {
+		return nil, err
+	}
+	n := int32(binary.LittleEndian.Uint32(header))
+	if n < 5 {
+		return nil, fmt.Errorf("bson: document length %d is less than 5 at offset %d", n, start)
+	}
+	body, err := d.read(int64(n) - 4)
+	if err != nil {
+		return nil, err
+	}
+	r := &reader{data: append(header, body...), base: start, mode: d.mode}
+	r.end = len(r.data)
+	return r.document(0, false)
+}
+
+// Parse decodes data holding exactly one document.
+func Parse(data []byte, mode Mode) (parser.Value, error) {
+	d := NewDecoder(bytes.NewReader(data), mode)
+	v, err := d.Decode()
+	if err == io.EOF {
+		return nil, fmt.Errorf("bson: no document in the input")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.offset != int64(len(data)) {
+		return nil, fmt.Errorf("bson: unexpected data after the document at offset %d", d.offset)
+	}
+	return v, nil
+}
+
+// ParseAll decodes every document of a stream held in memory.
+func ParseAll(data []byte, mode Mode) ([]parser.Value, error) {
+	d := NewDecoder(bytes.NewReader(data), mode)
+	var values []parser.Value
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
+
+// read reads exactly n bytes, growing the buffer as data arrives so that a
+// corrupt length cannot allocate more than the stream holds.
+func (d *Decoder) read(n int64) ([]byte, error) {
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d.r, n)
+	d.offset += copied
+	if err == io.EOF {
+		return nil, fmt.Errorf("bson: unexpected end of data at offset %d: %w", d.offset, io.ErrUnexpectedEOF)
+	}
+	return buf.Bytes(), err
+}
+
+// A reader converts one document held in memory.
+type reader struct {
+	data []byte
+	base int64 // Stream offset of data[0]
+	pos  int
+	end  int // End of the innermost document's elements
+	mode Mode
+}
+
+func (r *reader) errorf(pos int, format string, args ...any) error {
+	return fmt.Errorf("bson: "+format+" at offset %d", append(args, r.base+int64(pos))...)
+}
+
+// take returns the next n bytes of the current document.
+func (r *reader) take(n int) ([]byte, error) {
+	if n < 0 || n > r.end-r.pos {
+		return nil, r.errorf(r.pos, "element exceeds its document")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) int32() (int32, error) {
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (r *reader) uint64() (uint64, error) {
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// cstring reads a NUL-terminated string.
+func (r *reader) cstring() (string, error) {
+	start := r.pos
+	i := bytes.IndexByte(r.data[r.pos:r.end], 0)
+	if i < 0 {
+		return "", r.errorf(start, "unterminated name")
+	}
+	s := string(r.data[r.pos : r.pos+i])
+	r.pos += i + 1
+	if !utf8.ValidString(s) {
+		return "", r.errorf(start, "name is not valid UTF-8")
+	}
+	return s, nil
+}
+
+// string reads a length-prefixed, NUL-terminated string.
+func (r *reader) string() (string, error) {
+	start := r.pos
+	n, err := r.int32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", r.errorf(start, "string length %d is less than 1", n)
+	}
+	b, err := r.take(int(n))
+	if err != nil {
+		return "", err
+	}
+	if b[n-1] != 0 {
+		return "", r.errorf(start, "string does not end with a NUL byte")
+	}
+	if !utf8.Valid(b[:n-1]) {
+		return "", r.errorf(start, "string is not valid UTF-8")
+	}
+	return string(b[:n-1]), nil
+}
+
+// document reads an embedded document or array.
+func (r *reader) document(depth int, array bool) (parser.Value, error) {
+	start := r.pos
+	if depth > maxDepth {
+		return nil, r.errorf(start, "nesting exceeds %d levels", maxDepth)
+	}
+	n, err := r.int32()
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 || int(n) > r.end-start {
+		return nil, r.errorf(start, "document length %d does not fit its container", n)
+	}
+	end := start + int(n)
+	if r.data[end-1] != 0 {
+		return nil, r.errorf(start, "document does not end with a NUL byte")
+	}
+
+	outer := r.end
+	r.end = end - 1
+	defer func() { r.end = outer }()
+
+	o, a := parser.Object(), parser.Array()
+	for r.pos < r.end {
+		elemStart := r.pos
+		typ := r.data[r.pos]
+		r.pos++
+		key, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.value(typ, elemStart, depth)
+		if err != nil {
+			return nil, err
+		}
+		if array {
+			// The keys of an array are its indexes, which readers ignore
+			a.Append(v)
+			continue
+		}
+		if _, ok := o.Pairs[key]; ok {
+			return nil, r.errorf(elemStart, "duplicate key %q", key)
+		}
+		o.Set(key, v)
+	}
+	r.pos = end
+	if array {
+		return a, nil
+	}
+	return o, nil
+}
+
+// value reads the value of an element of the given type.
+func (r *reader) value(typ byte, start, depth int) (parser.Value, error) {
+	wrap := func(key string, v parser.Value) parser.Value {
+		return parser.Object().Set(key, v)
+	}
+	switch typ {
+	case typeDouble:
+		bits, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		f := math.Float64frombits(bits)
+		if r.mode == Relaxed && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return parser.Number(f), nil
+		}
+		return wrap("$numberDouble", parser.String(formatDouble(f))), nil
+	case typeString, typeCode, typeSymbol:
+		s, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case typeCode:
+			return wrap("$code", parser.String(s)), nil
+		case typeSymbol:
+			return wrap("$symbol", parser.String(s)), nil
+		}
+		return parser.String(s), nil
+	case typeDocument, typeArray:
+		return r.document(depth+1, typ == typeArray)
+	case typeBinary:
+		n, err := r.int32()
+		if err != nil {
+			return nil, err
+		}
+		sub, err := r.take(1)
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.take(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$binary", parser.Object().
+			Set("base64", parser.String(base64.StdEncoding.EncodeToString(data))).
+			Set("subType", parser.String(fmt.Sprintf("%02x", sub[0])))), nil
+	case typeUndefined:
+		return wrap("$undefined", parser.Bool(true)), nil
+	case typeObjectID:
+		id, err := r.take(12)
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$oid", parser.String(hex.EncodeToString(id))), nil
+	case typeBool:
+		b, err := r.take(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] > 1 {
+			return nil, r.errorf(start, "boolean byte is 0x%02x, not 0 or 1", b[0])
+		}
+		return parser.Bool(b[0] == 1), nil
+	case typeDateTime:
+		bits, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		ms := int64(bits)
+		if r.mode == Relaxed && ms >= 0 && ms < 253402300800000 {
+			return wrap("$date", parser.String(time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.999Z07:00"))), nil
+		}
+		return wrap("$date", wrap("$numberLong", parser.String(strconv.FormatInt(ms, 10)))), nil
+	case typeNull:
+		return parser.Null(), nil
+	case typeRegex:
+		pattern, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$regularExpression", parser.Object().
+			Set("pattern", parser.String(pattern)).
+			Set("options", parser.String(options))), nil
+	case typeDBPointer:
+		ref, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		id, err := r.take(12)
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$dbPointer", parser.Object().
+			Set("$ref", parser.String(ref)).
+			Set("$id", wrap("$oid", parser.String(hex.EncodeToString(id))))), nil
+	case typeCodeWithScope:
+		n, err := r.int32()
+		if err != nil {
+			return nil, err
+		}
+		end := r.pos - 4 + int(n)
+		code, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		scope, err := r.document(depth+1, false)
+		if err != nil {
+			return nil, err
+		}
+		if r.pos != end {
+			return nil, r.errorf(start, "code with scope length %d does not match its content", n)
+		}
+		return parser.Object().Set("$code", parser.String(code)).Set("$scope", scope), nil
+	case typeInt32:
+		i, err := r.int32()
+		if err != nil {
+			return nil, err
+		}
+		if r.mode == Relaxed {
+			return parser.Number(float64(i)), nil
+		}
+		return wrap("$numberInt", parser.String(strconv.Itoa(int(i)))), nil
+	case typeTimestamp:
+		bits, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$timestamp", parser.Object().
+			Set("t", parser.Number(float64(bits>>32))).
+			Set("i", parser.Number(float64(uint32(bits))))), nil
+	case typeInt64:
+		bits, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		i := int64(bits)
+		if r.mode == Relaxed && i >= -(1<<53) && i <= 1<<53 {
+			return parser.Number(float64(i)), nil
+		}
+		return wrap("$numberLong", parser.String(strconv.FormatInt(i, 10))), nil
+	case typeDecimal128:
+		lo, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		hi, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		return wrap("$numberDecimal", parser.String(formatDecimal(hi, lo))), nil
+	case typeMinKey:
+		return wrap("$minKey", parser.Number(1)), nil
+	case typeMaxKey:
+		return wrap("$maxKey", parser.Number(1)), nil
+	}
+	return nil, r.errorf(start, "unknown element type 0x%02x", typ)
+}
+
+// formatDouble formats the string of a $numberDouble: the shortest
+// representation that reads back exactly, always with a decimal point, as
+// in "1.0", "-0.0" and "1.0E+21".
+func formatDouble(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	mantissa, exp, scientific := strings.Cut(s, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if !scientific {
+		return mantissa
+	}
+	n, _ := strconv.Atoi(exp)
+	return fmt.Sprintf("%sE%+d", mantissa, n)
+}
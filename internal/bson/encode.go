@@ -0,0 +1,403 @@
+package bson
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// wrapperKeys are the keys that make an object an Extended JSON value.
+var wrapperKeys = map[string]bool{
+	"$oid": true, "$symbol": true, "$numberInt": true, "$numberLong": true,
+	"$numberDouble": true, "$numberDecimal": true, "$binary": true, "$code": true,
+	"$scope": true, "$timestamp": true, "$regularExpression": true,
+	"$dbPointer": true, "$date": true, "$minKey": true, "$maxKey": true,
+	"$undefined": true,
+}
+
+// Marshal encodes a document, which must be an object, as BSON. Objects in
+// canonical or relaxed Extended JSON become the typed values they
+// describe; other objects become embedded documents. Integral numbers
+// become int32 when they fit and int64 when they fit, and all other
+// numbers become doubles.
+func Marshal(v parser.Value) ([]byte, error) {
+	o, ok := v.(*parser.ObjectValue)
+	if !ok {
+		return nil, fmt.Errorf("a BSON document must be an object, found %s", parser.TypeName(v))
+	}
+	if isWrapper(o) {
+		return nil, fmt.Errorf("a BSON document must be an object, found an Extended JSON value")
+	}
+	e := &encoder{}
+	if err := e.document(nil, o); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+// document writes an object as a document: its length, its elements and a
+// terminating NUL.
+func (e *encoder) document(path parser.Path, o *parser.ObjectValue) error {
+	start := len(e.buf)
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	for _, key := range o.OrderedKeys() {
+		if err := e.element(append(path.Clone(), parser.KeySegment(key)), key, o.Pairs[key]); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, 0)
+	binary.LittleEndian.PutUint32(e.buf[start:], uint32(len(e.buf)-start))
+	return nil
+}
+
+// array writes an array as a document with the keys "0", "1" and so on.
+func (e *encoder) array(path parser.Path, a *parser.ArrayValue) error {
+	start := len(e.buf)
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	for i, elem := range a.Elements {
+		if err := e.element(append(path, parser.IndexSegment(i)), strconv.Itoa(i), elem); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, 0)
+	binary.LittleEndian.PutUint32(e.buf[start:], uint32(len(e.buf)-start))
+	return nil
+}
+
+// element writes the type, name and value of a document member.
+func (e *encoder) element(path parser.Path, key string, v parser.Value) error {
+	if err := checkCString(key); err != nil {
+		return fmt.Errorf("cannot encode the key at %s: %v", path.Describe(), err)
+	}
+	typeAt := len(e.buf)
+	e.buf = append(append(e.buf, 0), key...)
+	e.buf = append(e.buf, 0)
+	typ, err := e.value(path, v)
+	if err != nil {
+		return err
+	}
+	e.buf[typeAt] = typ
+	return nil
+}
+
+// value writes the payload of a value and returns its element type.
+func (e *encoder) value(path parser.Path, v parser.Value) (byte, error) {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		if isWrapper(n) {
+			return e.wrapper(path, n)
+		}
+		return typeDocument, e.document(path, n)
+	case *parser.ArrayValue:
+		return typeArray, e.array(path, n)
+	case *parser.StringValue:
+		if !utf8.ValidString(n.Value) {
+			return 0, fmt.Errorf("cannot encode the string at %s: it is not valid UTF-8", path.Describe())
+		}
+		e.writeString(n.Value)
+		return typeString, nil
+	case *parser.NumberValue:
+		f := n.Value
+		switch {
+		case f != math.Trunc(f) || f < -(1<<63) || f >= 1<<63:
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+			return typeDouble, nil
+		case f >= math.MinInt32 && f <= math.MaxInt32:
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(int32(f)))
+			return typeInt32, nil
+		default:
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(int64(f)))
+			return typeInt64, nil
+		}
+	case *parser.BooleanValue:
+		if n.Value {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+		return typeBool, nil
+	case *parser.NullValue:
+		return typeNull, nil
+	default:
+		return 0, fmt.Errorf("cannot encode %T at %s", v, path.Describe())
+	}
+}
+
+// writeString writes a length-prefixed, NUL-terminated string.
+func (e *encoder) writeString(s string) {
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(s)+1))
+	e.buf = append(append(e.buf, s...), 0)
+}
+
+// isWrapper reports whether an object uses an Extended JSON key, which
+// makes it a typed value rather than a document.
+func isWrapper(o *parser.ObjectValue) bool {
+	for key := range o.Pairs {
+		if wrapperKeys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapper writes an Extended JSON value as the BSON type it describes.
+func (e *encoder) wrapper(path parser.Path, o *parser.ObjectValue) (byte, error) {
+	keys := o.OrderedKeys()
+	slices.Sort(keys)
+	invalid := func(format string, args ...any) (byte, error) {
+		return 0, fmt.Errorf("invalid Extended JSON at %s: "+format, append([]any{path.Describe()}, args...)...)
+	}
+	// str returns the string held by a member, or ok false.
+	str := func(parent *parser.ObjectValue, key string) (string, bool) {
+		s, ok := parent.Pairs[key].(*parser.StringValue)
+		if !ok {
+			return "", false
+		}
+		return s.Value, true
+	}
+	// fields returns the members of a nested object with exactly the given
+	// keys, or nil.
+	fields := func(key string, want ...string) *parser.ObjectValue {
+		inner, ok := o.Pairs[key].(*parser.ObjectValue)
+		if !ok || len(inner.Pairs) != len(want) {
+			return nil
+		}
+		for _, k := range want {
+			if _, ok := inner.Pairs[k]; !ok {
+				return nil
+			}
+		}
+		return inner
+	}
+
+	switch strings.Join(keys, ",") {
+	case "$oid":
+		s, ok := str(o, "$oid")
+		id, err := hex.DecodeString(s)
+		if !ok || err != nil || len(id) != 12 {
+			return invalid(`"$oid" must be a string of 24 hex digits`)
+		}
+		e.buf = append(e.buf, id...)
+		return typeObjectID, nil
+	case "$symbol", "$code":
+		s, ok := str(o, keys[0])
+		if !ok {
+			return invalid("%q must be a string", keys[0])
+		}
+		e.writeString(s)
+		if keys[0] == "$code" {
+			return typeCode, nil
+		}
+		return typeSymbol, nil
+	case "$code,$scope":
+		code, ok := str(o, "$code")
+		scope, isObject := o.Pairs["$scope"].(*parser.ObjectValue)
+		if !ok || !isObject || isWrapper(scope) {
+			return invalid(`"$code" must be a string and "$scope" a document`)
+		}
+		start := len(e.buf)
+		e.buf = append(e.buf, 0, 0, 0, 0)
+		e.writeString(code)
+		if err := e.document(append(path.Clone(), parser.KeySegment("$scope")), scope); err != nil {
+			return 0, err
+		}
+		binary.LittleEndian.PutUint32(e.buf[start:], uint32(len(e.buf)-start))
+		return typeCodeWithScope, nil
+	case "$numberInt":
+		s, _ := str(o, "$numberInt")
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return invalid(`"$numberInt" must be a string holding a 32-bit integer`)
+		}
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(i))
+		return typeInt32, nil
+	case "$numberLong":
+		s, _ := str(o, "$numberLong")
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return invalid(`"$numberLong" must be a string holding a 64-bit integer`)
+		}
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(i))
+		return typeInt64, nil
+	case "$numberDouble":
+		s, _ := str(o, "$numberDouble")
+		f, err := parseDouble(s)
+		if err != nil {
+			return invalid(`"$numberDouble" must be a string holding a number, Infinity, -Infinity or NaN`)
+		}
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+		return typeDouble, nil
+	case "$numberDecimal":
+		s, ok := str(o, "$numberDecimal")
+		if !ok {
+			return invalid(`"$numberDecimal" must be a string`)
+		}
+		hi, lo, err := parseDecimal(s)
+		if err != nil {
+			return invalid("%v", err)
+		}
+		e.buf = binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(e.buf, lo), hi)
+		return typeDecimal128, nil
+	case "$binary":
+		inner := fields("$binary", "base64", "subType")
+		if inner == nil {
+			return invalid(`"$binary" must hold "base64" and "subType"`)
+		}
+		b64, ok := str(inner, "base64")
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if !ok || err != nil {
+			return invalid(`"base64" must be a base64 string`)
+		}
+		sub, ok := str(inner, "subType")
+		subType, err := strconv.ParseUint(sub, 16, 8)
+		if !ok || err != nil || len(sub) > 2 {
+			return invalid(`"subType" must be one or two hex digits`)
+		}
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(data)))
+		e.buf = append(append(e.buf, byte(subType)), data...)
+		return typeBinary, nil
+	case "$timestamp":
+		inner := fields("$timestamp", "t", "i")
+		t, tok := uint32Member(inner, "t")
+		i, iok := uint32Member(inner, "i")
+		if !tok || !iok {
+			return invalid(`"$timestamp" must hold "t" and "i", both unsigned 32-bit integers`)
+		}
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(t)<<32|uint64(i))
+		return typeTimestamp, nil
+	case "$regularExpression":
+		inner := fields("$regularExpression", "pattern", "options")
+		if inner == nil {
+			return invalid(`"$regularExpression" must hold "pattern" and "options"`)
+		}
+		pattern, pok := str(inner, "pattern")
+		options, ook := str(inner, "options")
+		if !pok || !ook || checkCString(pattern) != nil || checkCString(options) != nil {
+			return invalid(`"pattern" and "options" must be strings without NUL bytes`)
+		}
+		// BSON stores the options in alphabetical order
+		sorted := []byte(options)
+		slices.Sort(sorted)
+		e.buf = append(append(e.buf, pattern...), 0)
+		e.buf = append(append(e.buf, sorted...), 0)
+		return typeRegex, nil
+	case "$dbPointer":
+		inner := fields("$dbPointer", "$ref", "$id")
+		if inner == nil {
+			return invalid(`"$dbPointer" must hold "$ref" and "$id"`)
+		}
+		ref, ok := str(inner, "$ref")
+		id, isObject := inner.Pairs["$id"].(*parser.ObjectValue)
+		if !ok || !isObject || len(id.Pairs) != 1 || id.Pairs["$oid"] == nil {
+			return invalid(`"$ref" must be a string and "$id" an {"$oid": ...} value`)
+		}
+		e.writeString(ref)
+		if _, err := e.wrapper(append(path.Clone(), parser.KeySegment("$dbPointer"), parser.KeySegment("$id")), id); err != nil {
+			return 0, err
+		}
+		return typeDBPointer, nil
+	case "$date":
+		ms, err := dateMillis(o.Pairs["$date"])
+		if err != nil {
+			return invalid("%v", err)
+		}
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(ms))
+		return typeDateTime, nil
+	case "$minKey", "$maxKey":
+		if n, ok := o.Pairs[keys[0]].(*parser.NumberValue); !ok || n.Value != 1 {
+			return invalid("%q must be 1", keys[0])
+		}
+		if keys[0] == "$minKey" {
+			return typeMinKey, nil
+		}
+		return typeMaxKey, nil
+	case "$undefined":
+		if b, ok := o.Pairs["$undefined"].(*parser.BooleanValue); !ok || !b.Value {
+			return invalid(`"$undefined" must be true`)
+		}
+		return typeUndefined, nil
+	}
+	return invalid("unexpected keys %s", strings.Join(keys, ", "))
+}
+
+// uint32Member returns a member of an object holding an unsigned 32-bit
+// integer.
+func uint32Member(o *parser.ObjectValue, key string) (uint32, bool) {
+	if o == nil {
+		return 0, false
+	}
+	n, ok := o.Pairs[key].(*parser.NumberValue)
+	if !ok || n.Value != math.Trunc(n.Value) || n.Value < 0 || n.Value > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(n.Value), true
+}
+
+// parseDouble parses the string of a $numberDouble.
+func parseDouble(s string) (float64, error) {
+	switch s {
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	if strings.ContainsAny(s, "xXnN") {
+		// Hexadecimal floats and the spellings of Infinity and NaN that
+		// strconv also accepts
+		return 0, fmt.Errorf("invalid double %q", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// dateMillis returns the milliseconds since the Unix epoch of a $date
+// value: {"$numberLong": "..."} in canonical form, an RFC 3339 string in
+// relaxed form, or a plain number of milliseconds as older tools write.
+func dateMillis(v parser.Value) (int64, error) {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		s, ok := n.Pairs["$numberLong"].(*parser.StringValue)
+		if ok && len(n.Pairs) == 1 {
+			if ms, err := strconv.ParseInt(s.Value, 10, 64); err == nil {
+				return ms, nil
+			}
+		}
+	case *parser.StringValue:
+		t, err := time.Parse(time.RFC3339Nano, n.Value)
+		if err != nil {
+			return 0, fmt.Errorf(`"$date" must be an RFC 3339 time: %v`, err)
+		}
+		return t.UnixMilli(), nil
+	case *parser.NumberValue:
+		if n.Value == math.Trunc(n.Value) && n.Value >= -(1<<63) && n.Value < 1<<63 {
+			return int64(n.Value), nil
+		}
+	}
+	return 0, fmt.Errorf(`"$date" must be an RFC 3339 string or {"$numberLong": "..."} milliseconds`)
+}
+
+// checkCString reports an error for strings BSON stores NUL-terminated,
+// which must not contain NUL and must be valid UTF-8.
+func checkCString(s string) error {
+	if strings.IndexByte(s, 0) >= 0 {
+		return fmt.Errorf("it contains a NUL byte")
+	}
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("it is not valid UTF-8")
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+// Examples from RFC 8949, appendix A
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		input    string // JSON5
+		expected string // Hex
+	}{
+		{`0`, "00"},
+		{`23`, "17"},
+		{`24`, "1818"},
+		{`1000`, "1903e8"},
+		{`1000000`, "1a000f4240"},
+		{`1000000000000`, "1b000000e8d4a51000"},
+		{`18446744073709549568`, "1bfffffffffffff800"},
+		{`-1`, "20"},
+		{`-1000`, "3903e7"},
+		{`-18446744073709549568`, "3bfffffffffffff7ff"},
+		{`1.0`, "01"},
+		{`1.5`, "f93e00"},
+		{`65504.5`, "fa477fe080"},
+		{`100000.5`, "fa47c35040"},
+		{`3.4028234663852886e+38`, "fa7f7fffff"},
+		{`1.0e+300`, "fb7e37e43c8800759c"},
+		{`5.960464477539063e-8`, "f90001"},
+		{`0.00006103515625`, "f90400"},
+		{`-4.1`, "fbc010666666666666"},
+		{`1e100`, "fb54b249ad2594c37d"},
+		{`Infinity`, "f97c00"},
+		{`NaN`, "f97e00"},
+		{`-Infinity`, "f9fc00"},
+		{`false`, "f4"},
+		{`true`, "f5"},
+		{`null`, "f6"},
+		{`""`, "60"},
+		{`"IETF"`, "6449455446"},
+		{`"\"\\"`, "62225c"},
+		{`"ü"`, "62c3bc"},
+		{`"水"`, "63e6b0b4"},
+		{`[]`, "80"},
+		{`[1, [2, 3], [4, 5]]`, "8301820203820405"},
+		{`{}`, "a0"},
+		{`{"a": 1, "b": [2, 3]}`, "a26161016162820203"},
+		{`["a", {"b": "c"}]`, "826161a161626163"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(testutil.JSON(t, tt.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if hex.EncodeToString(got) != tt.expected {
+			t.Errorf("%s: expected %s, got %x", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	input := testutil.JSON(t, `{"bb": 1, "a": {"z": 1, "y": 2}, "aa": 3, "b": 4}`)
+	got, err := MarshalDeterministic(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Shorter keys first, then bytewise: "a", "b", "aa", "bb"
+	expected := "a4" + "6161a2" + "617902" + "617a01" + "616204" + "62616103" + "62626201"
+	if hex.EncodeToString(got) != expected {
+		t.Errorf("expected %s, got %x", expected, got)
+	}
+
+	plain, _ := Marshal(input)
+	if hex.EncodeToString(plain) == expected {
+		t.Errorf("expected Marshal to keep member order")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // Hex
+		expected string // JSON5
+	}{
+		{"integers", "83 00 3903e7 1bffffffffffffffff", `[0, -1000, 18446744073709551615]`},
+		{"floats", "84 f93c00 f9c400 fa47c35000 fb3ff199999999999a", `[1, -4, 100000, 1.1]`},
+		{"subnormal half", "f90001", `5.960464477539063e-8`},
+		{"NaN of every width", "83 f97e00 fa7fc00000 fb7ff8000000000000", `[NaN, NaN, NaN]`},
+		{"bytes", "43 010203", `"AQID"`},
+		{"indefinite bytes", "5f 4201ff 43ffffff ff", `"Af____8"`},
+		{"indefinite text", "7f 657374726561 646d696e67 ff", `"streaming"`},
+		{"indefinite array", "9f 01 820203 9f0405ff ff", `[1, [2, 3], [4, 5]]`},
+		{"indefinite map", "bf 6346756e f5 63416d74 21 ff", `{"Fun": true, "Amt": -2}`},
+		{"date string", "c0 74323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"epoch integer", "c1 1a514b67b0", `"2013-03-21T20:04:00Z"`},
+		{"epoch float", "c1 fb41d452d9ec200000", `"2013-03-21T20:04:00.5Z"`},
+		{"positive bignum", "c2 49 010000000000000000", `18446744073709551616`},
+		{"negative bignum", "c3 49 010000000000000000", `-18446744073709551617`},
+		{"base64 hint", "d6 82 43010203 a1 6161 42ffff", `["AQID", {"a": "//8="}]`},
+		{"base16 hint", "d7 44 01020304", `"01020304"`},
+		{"unknown tag", "d8 20 76687474703a2f2f7777772e6578616d706c652e636f6d", `"http://www.example.com"`},
+		{"simple values", "84 f7 f0 f8ff f6", `[null, null, null, null]`},
+		{"non-text keys", "a3 01 02 4101 03 f5 04", `{"1": 2, "h'01'": 3, "true": 4}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(testutil.Hex(t, tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	got, err := ParseSequence(testutil.Hex(t, "01 6161 80"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || !parser.Equal(got[2], parser.Array()) {
+		t.Errorf("expected three items, got %d", len(got))
+	}
+	if _, err := Parse(testutil.Hex(t, "01 02")); err == nil || !strings.Contains(err.Error(), "expected one data item, found 2") {
+		t.Errorf("expected Parse to reject a sequence, got %v", err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"empty", "", "expected one data item, found 0"},
+		{"truncated argument", "19 01", "unexpected end of data at offset 0"},
+		{"truncated string", "63 6161", "string of 3 bytes exceeds the data at offset 0"},
+		{"truncated array", "83 01 02", "3 items exceed the data at offset 0"},
+		{"truncated element", "82 19 01", "unexpected end of data at offset 1"},
+		{"huge array", "9b ffffffffffffffff", "items exceed the data"},
+		{"reserved info", "1c", "reserved additional information 28"},
+		{"invalid UTF-8", "62 c328", "not valid UTF-8 at offset 0"},
+		{"stray break", "ff", "unexpected break at offset 0"},
+		{"break in array", "82 01 ff", "unexpected break at offset 2"},
+		{"indefinite integer", "1f", "major type 0 cannot have an indefinite length"},
+		{"bad chunk", "5f 6161 ff", "must be a definite string of the same type at offset 1"},
+		{"odd indefinite map", "bf 01 ff", "map ends after a key without a value"},
+		{"two-byte simple", "f8 10", "simple value 16 must use the one-byte form"},
+		{"duplicate key", "a2 6161 01 6161 02", `duplicate map key "a"`},
+		{"colliding key", "a2 01 01 6131 02", `duplicate map key "1"`},
+		{"bad bignum", "c2 01", "tag 2 needs a byte string"},
+		{"bad epoch", "c1 6161", "tag 1 needs a number of seconds"},
+		{"epoch out of range", "c1 1b7fffffffffffffff", "outside the years 0000 to 9999"},
+		{"deep nesting", strings.Repeat("81", 1002) + "00", "nesting exceeds 1000 levels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(testutil.Hex(t, tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		input    string // Hex
+		expected string
+	}{
+		{"8301820203820405", "[1, [2, 3], [4, 5]]"},
+		{"3bffffffffffffffff", "-18446744073709551616"},
+		{"f93c00", "1.0"},
+		{"fa47c35000", "100000.0"},
+		{"fb7e37e43c8800759c", "1e+300"},
+		{"f97c00", "Infinity"},
+		{"f4 f5 f6 f7 f0 f8ff", "false\ntrue\nnull\nundefined\nsimple(16)\nsimple(255)"},
+		{"4401020304", "h'01020304'"},
+		{"62225c", `"\"\\"`},
+		{"5f 4201 02 43030405 ff", "(_ h'0102', h'030405')"},
+		{"5fff", "''_"},
+		{"7f 657374726561 646d696e67 ff", `(_ "strea", "ming")`},
+		{"9f 01 820203 ff", "[_ 1, [2, 3]]"},
+		{"bf 6161 01 ff", `{_ "a": 1}`},
+		{"a2 01 02 03 04", "{1: 2, 3: 4}"},
+		{"c1 1a514b67b0", "1(1363896240)"},
+		{"d8 20 6161", `32("a")`},
+	}
+	for _, tt := range tests {
+		got, err := Diagnose(testutil.Hex(t, tt.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if got != tt.expected+"\n" {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected+"\n", got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{"name": "Ada", "age": 36, "ratio": 0.5, "tags": ["a", "b"], "nested": {"x": null, "y": [true, false]}}`,
+		`[0, -0.5, 1.1, 65504, 65505, -65536, 4294967296, -4294967297, 9007199254740993, 1e300, 5e-324, Infinity]`,
+		`["", "ü水😀", "\u0000", {"": {}}, [[[]]]]`,
+	}
+	for _, marshal := range []func(parser.Value) ([]byte, error){Marshal, MarshalDeterministic} {
+		for _, input := range inputs {
+			testutil.RoundTrip(t, input, marshal, Parse)
+		}
+	}
+}
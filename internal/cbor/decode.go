@@ -0,0 +1,420 @@
+package cbor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// maxDepth bounds the nesting of arrays, maps and tags.
+const maxDepth = 1000
+
+// item is a decoded CBOR data item, kept with the details diagnostic
+// notation shows and JSON cannot.
+type item struct {
+	major      byte
+	arg        uint64   // Integer argument, tag number or simple value
+	float      float64  // For floats
+	floatSize  int      // 2, 4 or 8 bytes for floats; 0 otherwise
+	chunks     [][]byte // Byte or text string content, one chunk if definite
+	items      []*item  // Array elements, alternating map keys and values, or a tag's content
+	indefinite bool
+}
+
+// content returns a string's chunks joined.
+func (it *item) content() []byte {
+	if len(it.chunks) == 1 {
+		return it.chunks[0]
+	}
+	var b []byte
+	for _, chunk := range it.chunks {
+		b = append(b, chunk...)
+	}
+	return b
+}
+
+// Parse decodes a single CBOR data item into a value, converting as
+// RFC 8949 section 6.1 suggests:
+//
+//   - integers and floats become numbers; integers beyond 2^53, and
+//     bignums (tags 2 and 3), lose precision, as the AST holds float64
+//   - byte strings become base64url strings without padding, or base64 or
+//     hex under the expected-encoding tags 22 and 23
+//   - epoch times (tag 1) become RFC 3339 strings in UTC, and other tags
+//     are replaced by their content
+//   - undefined and unassigned simple values become null
+//   - map keys that are not text become their diagnostic notation, so the
+//     integer key 1 becomes "1"
+func Parse(data []byte) (parser.Value, error) {
+	values, err := ParseSequence(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("cbor: expected one data item, found %d", len(values))
+	}
+	return values[0], nil
+}
+
+// ParseSequence decodes a CBOR sequence (RFC 8742): zero or more data
+// items, one after another.
+func ParseSequence(data []byte) ([]parser.Value, error) {
+	items, err := decodeAll(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]parser.Value, len(items))
+	for i, it := range items {
+		if values[i], err = convert(it, base64URL); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// decodeAll reads every data item of a sequence.
+func decodeAll(data []byte) ([]*item, error) {
+	d := &decoder{data: data}
+	var items []*item
+	for d.pos < len(d.data) {
+		it, err := d.item(0)
+		if err != nil {
+			return nil, err
+		}
+		if it == nil {
+			return nil, d.errorf(d.pos-1, "unexpected break")
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) errorf(offset int, format string, args ...any) error {
+	return fmt.Errorf("cbor: "+format+" at offset %d", append(args, offset)...)
+}
+
+// item reads one data item. It returns nil for the break byte (0xff) that
+// ends an indefinite-length item.
+func (d *decoder) item(depth int) (*item, error) {
+	start := d.pos
+	if depth > maxDepth {
+		return nil, d.errorf(start, "nesting exceeds %d levels", maxDepth)
+	}
+	if d.pos >= len(d.data) {
+		return nil, d.errorf(start, "unexpected end of data")
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+	if initial == 0xff {
+		return nil, nil
+	}
+
+	it := &item{major: major}
+	if info == 31 {
+		return d.indefinite(it, start, depth)
+	}
+	arg, err := d.argument(info, start)
+	if err != nil {
+		return nil, err
+	}
+	it.arg = arg
+
+	switch major {
+	case majorBytes, majorText:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, d.errorf(start, "string of %d bytes exceeds the data", arg)
+		}
+		chunk := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == majorText && !utf8.Valid(chunk) {
+			return nil, d.errorf(start, "text string is not valid UTF-8")
+		}
+		it.chunks = [][]byte{chunk}
+	case majorArray, majorMap:
+		count := arg
+		if major == majorMap {
+			count *= 2
+			if arg > math.MaxUint64/2 {
+				count = math.MaxUint64
+			}
+		}
+		// Every item takes at least a byte, which bounds the allocation
+		if count > uint64(len(d.data)-d.pos) {
+			return nil, d.errorf(start, "%d items exceed the data", count)
+		}
+		it.items = make([]*item, 0, count)
+		for range count {
+			child, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				return nil, d.errorf(d.pos-1, "unexpected break")
+			}
+			it.items = append(it.items, child)
+		}
+	case majorTag:
+		child, err := d.item(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, d.errorf(d.pos-1, "unexpected break")
+		}
+		it.items = []*item{child}
+	case majorSimple:
+		switch info {
+		case 24:
+			if arg < 32 {
+				return nil, d.errorf(start, "simple value %d must use the one-byte form", arg)
+			}
+		case 25:
+			it.float, it.floatSize = fromHalf(uint16(arg)), 2
+		case 26:
+			it.float, it.floatSize = float64(math.Float32frombits(uint32(arg))), 4
+		case 27:
+			it.float, it.floatSize = math.Float64frombits(arg), 8
+		}
+	}
+	return it, nil
+}
+
+// argument reads the argument that follows an initial byte.
+func (d *decoder) argument(info byte, start int) (uint64, error) {
+	size := 0
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	default:
+		return 0, d.errorf(start, "reserved additional information %d", info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, d.errorf(start, "unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+size]
+	d.pos += size
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// indefinite reads the rest of an indefinite-length item, up to its break.
+func (d *decoder) indefinite(it *item, start, depth int) (*item, error) {
+	it.indefinite = true
+	switch it.major {
+	case majorBytes, majorText:
+		it.chunks = [][]byte{}
+		for {
+			chunkStart := d.pos
+			chunk, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if chunk == nil {
+				return it, nil
+			}
+			if chunk.major != it.major || chunk.indefinite {
+				return nil, d.errorf(chunkStart, "a chunk of an indefinite-length string must be a definite string of the same type")
+			}
+			it.chunks = append(it.chunks, chunk.chunks[0])
+		}
+	case majorArray, majorMap:
+		for {
+			child, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				break
+			}
+			it.items = append(it.items, child)
+		}
+		if it.major == majorMap && len(it.items)%2 != 0 {
+			return nil, d.errorf(d.pos-1, "map ends after a key without a value")
+		}
+		return it, nil
+	default:
+		return nil, d.errorf(start, "major type %d cannot have an indefinite length", it.major)
+	}
+}
+
+// fromHalf converts an IEEE 754 half-precision float.
+func fromHalf(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h >> 10 & 0x1f)
+	mant := float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 31:
+		if mant != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	}
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
+
+// byteEncoding is how byte strings are turned into JSON strings.
+type byteEncoding int
+
+const (
+	base64URL byteEncoding = iota // The default, and tag 21
+	base64Std                     // Tag 22
+	base16                        // Tag 23
+)
+
+// convert turns an item into a value; see Parse.
+func convert(it *item, enc byteEncoding) (parser.Value, error) {
+	switch it.major {
+	case majorUnsigned:
+		return parser.Number(float64(it.arg)), nil
+	case majorNegative:
+		return parser.Number(-1 - float64(it.arg)), nil
+	case majorBytes:
+		return parser.String(encodeBytes(it.content(), enc)), nil
+	case majorText:
+		return parser.String(string(it.content())), nil
+	case majorArray:
+		arr := parser.Array()
+		for _, child := range it.items {
+			v, err := convert(child, enc)
+			if err != nil {
+				return nil, err
+			}
+			arr.Append(v)
+		}
+		return arr, nil
+	case majorMap:
+		o := parser.Object()
+		for i := 0; i < len(it.items); i += 2 {
+			key := it.items[i]
+			name := diagnose(key)
+			if key.major == majorText {
+				name = string(key.content())
+			}
+			if _, ok := o.Pairs[name]; ok {
+				return nil, fmt.Errorf("cbor: duplicate map key %s", diagnose(key))
+			}
+			v, err := convert(it.items[i+1], enc)
+			if err != nil {
+				return nil, err
+			}
+			o.Set(name, v)
+		}
+		return o, nil
+	case majorTag:
+		return convertTag(it, enc)
+	default:
+		switch {
+		case it.floatSize > 0:
+			return parser.Number(it.float), nil
+		case it.arg == 20:
+			return parser.Bool(false), nil
+		case it.arg == 21:
+			return parser.Bool(true), nil
+		}
+		return parser.Null(), nil
+	}
+}
+
+// convertTag converts the tags with a JSON meaning; others are replaced by
+// their content.
+func convertTag(it *item, enc byteEncoding) (parser.Value, error) {
+	content := it.items[0]
+	switch it.arg {
+	case 1: // Epoch-based date/time
+		var seconds float64
+		switch {
+		case content.major == majorUnsigned:
+			seconds = float64(content.arg)
+		case content.major == majorNegative:
+			seconds = -1 - float64(content.arg)
+		case content.floatSize > 0 && !math.IsNaN(content.float) && !math.IsInf(content.float, 0):
+			seconds = content.float
+		default:
+			return nil, fmt.Errorf("cbor: tag 1 needs a number of seconds, found %s", diagnose(content))
+		}
+		// RFC 3339 covers the years 0000 to 9999
+		if seconds < -62167219200 || seconds >= 253402300800 {
+			return nil, fmt.Errorf("cbor: tag 1 time %s is outside the years 0000 to 9999", diagnose(content))
+		}
+		whole, frac := math.Modf(seconds)
+		t := time.Unix(int64(whole), int64(math.Round(frac*1e9))).UTC()
+		return parser.String(t.Format(time.RFC3339Nano)), nil
+	case 2, 3: // Bignums
+		if content.major != majorBytes {
+			return nil, fmt.Errorf("cbor: tag %d needs a byte string, found %s", it.arg, diagnose(content))
+		}
+		n := new(big.Int).SetBytes(content.content())
+		if it.arg == 3 {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return parser.Number(f), nil
+	case 21:
+		return convert(content, base64URL)
+	case 22:
+		return convert(content, base64Std)
+	case 23:
+		return convert(content, base16)
+	}
+	return convert(content, enc)
+}
+
+func encodeBytes(b []byte, enc byteEncoding) string {
+	switch enc {
+	case base64Std:
+		return base64.StdEncoding.EncodeToString(b)
+	case base16:
+		return hex.EncodeToString(b)
+	default:
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+}
+
+// formatFloat writes a float in diagnostic notation: always with a
+// decimal point or an exponent, and NaN and the infinities by name.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	s := printer.FormatNumber(f)
+	for _, c := range s {
+		if c == '.' || c == 'e' {
+			return s
+		}
+	}
+	return s + ".0"
+}
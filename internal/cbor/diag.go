@@ -0,0 +1,96 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// Diagnose prints each data item of a CBOR sequence in the diagnostic
+// notation of RFC 8949 section 8, one per line. Unlike Parse it shows
+// everything the encoding holds: byte strings as h'...', tags as
+// number(content), floats with a decimal point, undefined and other simple
+// values, and indefinite-length items with a leading underscore, as in
+// [_ 1, 2] and (_ "chunk", "ed").
+func Diagnose(data []byte) (string, error) {
+	items, err := decodeAll(data)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, it := range items {
+		sb.WriteString(diagnose(it) + "\n")
+	}
+	return sb.String(), nil
+}
+
+// diagnose formats one item in diagnostic notation.
+func diagnose(it *item) string {
+	switch it.major {
+	case majorUnsigned:
+		return strconv.FormatUint(it.arg, 10)
+	case majorNegative:
+		if it.arg == 1<<64-1 {
+			return "-18446744073709551616"
+		}
+		return "-" + strconv.FormatUint(it.arg+1, 10)
+	case majorBytes, majorText:
+		format := func(chunk []byte) string {
+			if it.major == majorBytes {
+				return "h'" + hex.EncodeToString(chunk) + "'"
+			}
+			return printer.New("").Print(parser.String(string(chunk)))
+		}
+		if !it.indefinite {
+			return format(it.chunks[0])
+		}
+		if len(it.chunks) == 0 {
+			if it.major == majorBytes {
+				return "''_"
+			}
+			return `""_`
+		}
+		parts := make([]string, len(it.chunks))
+		for i, chunk := range it.chunks {
+			parts[i] = format(chunk)
+		}
+		return "(_ " + strings.Join(parts, ", ") + ")"
+	case majorArray, majorMap:
+		open, close := "[", "]"
+		if it.major == majorMap {
+			open, close = "{", "}"
+		}
+		if it.indefinite {
+			open += "_ "
+		}
+		var parts []string
+		for i := 0; i < len(it.items); i++ {
+			if it.major == majorMap {
+				parts = append(parts, diagnose(it.items[i])+": "+diagnose(it.items[i+1]))
+				i++
+			} else {
+				parts = append(parts, diagnose(it.items[i]))
+			}
+		}
+		return open + strings.Join(parts, ", ") + close
+	case majorTag:
+		return strconv.FormatUint(it.arg, 10) + "(" + diagnose(it.items[0]) + ")"
+	default:
+		switch {
+		case it.floatSize > 0:
+			return formatFloat(it.float)
+		case it.arg == 20:
+			return "false"
+		case it.arg == 21:
+			return "true"
+		case it.arg == 22:
+			return "null"
+		case it.arg == 23:
+			return "undefined"
+		}
+		return "simple(" + strconv.FormatUint(it.arg, 10) + ")"
+	}
+}
@@ -0,0 +1,191 @@
+// Package cbor converts between the parser's AST and CBOR (RFC 8949).
+// Marshal writes preferred serialization; MarshalDeterministic also sorts
+// map keys, following the core deterministic encoding requirements. Parse
+// reads CBOR, including indefinite-length items and the tags that have a
+// JSON equivalent, and Diagnose prints CBOR in diagnostic notation.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Major types
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+)
+
+// Marshal encodes a value as a CBOR data item. Integral numbers within the
+// range of CBOR integers are written as integers and other numbers as the
+// shortest float that holds them exactly, so 1 and 1.0 both become the
+// integer 1 while 1.5 becomes a half-precision float. Object members are
+// written in member order. Strings must be valid UTF-8.
+func Marshal(v parser.Value) ([]byte, error) {
+	e := &encoder{}
+	if err := e.write(nil, v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// MarshalDeterministic is like Marshal, but sorts each map's keys by their
+// encoded bytes, so equal documents always produce the same bytes
+// (RFC 8949, section 4.2.1).
+func MarshalDeterministic(v parser.Value) ([]byte, error) {
+	e := &encoder{sorted: true}
+	if err := e.write(nil, v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf    bytes.Buffer
+	sorted bool
+}
+
+func (e *encoder) write(path parser.Path, v parser.Value) error {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		e.writeHead(majorMap, uint64(len(n.Pairs)))
+		keys := n.OrderedKeys()
+		if e.sorted {
+			slices.SortFunc(keys, func(a, b string) int {
+				return bytes.Compare(encodeText(a), encodeText(b))
+			})
+		}
+		for _, key := range keys {
+			child := append(path.Clone(), parser.KeySegment(key))
+			if !utf8.ValidString(key) {
+				return fmt.Errorf("cannot encode the key at %s: it is not valid UTF-8", child.Describe())
+			}
+			e.buf.Write(encodeText(key))
+			if err := e.write(child, n.Pairs[key]); err != nil {
+				return err
+			}
+		}
+	case *parser.ArrayValue:
+		e.writeHead(majorArray, uint64(len(n.Elements)))
+		for i, elem := range n.Elements {
+			if err := e.write(append(path, parser.IndexSegment(i)), elem); err != nil {
+				return err
+			}
+		}
+	case *parser.StringValue:
+		if !utf8.ValidString(n.Value) {
+			return fmt.Errorf("cannot encode the string at %s: it is not valid UTF-8", path.Describe())
+		}
+		e.buf.Write(encodeText(n.Value))
+	case *parser.NumberValue:
+		e.writeNumber(n.Value)
+	case *parser.BooleanValue:
+		if n.Value {
+			e.buf.WriteByte(0xf5)
+		} else {
+			e.buf.WriteByte(0xf4)
+		}
+	case *parser.NullValue:
+		e.buf.WriteByte(0xf6)
+	default:
+		return fmt.Errorf("cannot encode %T at %s", v, path.Describe())
+	}
+	return nil
+}
+
+// writeHead writes the initial byte of an item with its argument in the
+// fewest bytes.
+func (e *encoder) writeHead(major byte, arg uint64) {
+	e.buf.Write(appendHead(nil, major, arg))
+}
+
+func appendHead(b []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(b, major<<5|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, major<<5|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major<<5|27), arg)
+	}
+}
+
+// encodeText encodes a text string item.
+func encodeText(s string) []byte {
+	return append(appendHead(nil, majorText, uint64(len(s))), s...)
+}
+
+// writeNumber writes integral values from -2^64 to 2^64 as integers and
+// everything else as a float of 16, 32 or 64 bits, whichever is the
+// shortest to hold the value exactly.
+func (e *encoder) writeNumber(f float64) {
+	const two64 = 1 << 64
+	if f == math.Trunc(f) && f > -two64 && f < two64 {
+		if f >= 0 {
+			e.writeHead(majorUnsigned, uint64(f))
+		} else {
+			e.writeHead(majorNegative, uint64(-f)-1)
+		}
+		return
+	}
+
+	if h, ok := toHalf(f); ok {
+		e.buf.Write(binary.BigEndian.AppendUint16([]byte{0xf9}, h))
+	} else if f32 := float32(f); float64(f32) == f {
+		e.buf.Write(binary.BigEndian.AppendUint32([]byte{0xfa}, math.Float32bits(f32)))
+	} else {
+		e.buf.Write(binary.BigEndian.AppendUint64([]byte{0xfb}, math.Float64bits(f)))
+	}
+}
+
+// toHalf converts a float to IEEE 754 half precision if that holds it
+// exactly. NaN becomes the canonical quiet NaN, 0x7e00.
+func toHalf(f float64) (uint16, bool) {
+	switch {
+	case math.IsNaN(f):
+		return 0x7e00, true
+	case math.IsInf(f, 1):
+		return 0x7c00, true
+	case math.IsInf(f, -1):
+		return 0xfc00, true
+	}
+	bits := math.Float64bits(f)
+	sign := uint16(bits>>48) & 0x8000
+	exp := int(bits>>52&0x7ff) - 1023
+	mant := bits & (1<<52 - 1)
+	switch {
+	case f == 0:
+		return sign, true
+	case exp >= -14 && exp <= 15:
+		// Normal: 10 mantissa bits
+		if mant&(1<<42-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>42), true
+	case exp >= -24 && exp < -14:
+		// Subnormal: the value is a multiple of 2^-24
+		shift := uint(42 + (-14 - exp))
+		full := mant | 1<<52
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	}
+	return 0, false
+}
@@ -0,0 +1,254 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+func infer(t *testing.T, samples ...string) *Shape {
+	t.Helper()
+	s := &Shape{}
+	for _, sample := range samples {
+		s.Add(testutil.JSON(t, sample))
+	}
+	return s
+}
+
+func TestInfer(t *testing.T) {
+	s := infer(t, `{"a": 1, "b": "x", "c": [1, 2.5]}`, `{"a": null, "c": [], "d": true}`)
+	tests := []struct {
+		field    string
+		kinds    Kind
+		optional bool
+	}{
+		{"a", Int | Null, false},
+		{"b", String, true},
+		{"c", Array, false},
+		{"d", Bool, true},
+	}
+	if len(s.Fields) != len(tests) || s.Objects != 2 {
+		t.Fatalf("expected %d fields of 2 objects, got %d of %d", len(tests), len(s.Fields), s.Objects)
+	}
+	for i, tt := range tests {
+		f := s.Fields[i]
+		if f.Name != tt.field || f.Shape.Kinds != tt.kinds || s.Optional(f) != tt.optional {
+			t.Errorf("field %d: expected %s %s optional=%v, got %s %s optional=%v",
+				i, tt.field, tt.kinds, tt.optional, f.Name, f.Shape.Kinds, s.Optional(f))
+		}
+	}
+	if elem := s.Fields[2].Shape.Elem; elem == nil || elem.Kinds != Int|Float {
+		t.Errorf("expected the elements of c to be int|float, got %v", elem)
+	}
+	if !s.Fields[0].Shape.Nullable() || s.Fields[1].Shape.Nullable() {
+		t.Errorf("expected only a to be nullable")
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"user_id", "UserID"},
+		{"userId", "UserID"},
+		{"UserID", "UserID"},
+		{"html-url", "HTMLURL"},
+		{"HTTPServer", "HTTPServer"},
+		{"createdAt2", "CreatedAt2"},
+		{"first name", "FirstName"},
+		{"ABC", "Abc"},
+		{"2fa", "X2fa"},
+		{"名前", "X名前"},
+		{"ünïcode", "Ünïcode"},
+		{"", "Field"},
+		{"$$", "Field"},
+	}
+	for _, tt := range tests {
+		if got := pascal(tt.input); got != tt.expected {
+			t.Errorf("pascal(%q): expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+
+	plurals := map[string]string{
+		"items": "item", "categories": "category", "addresses": "address", "boxes": "box",
+		"matches": "match", "status": "status", "analysis": "analysis", "data": "data", "s": "s",
+	}
+	for plural, expected := range plurals {
+		if got := singular(plural); got != expected {
+			t.Errorf("singular(%q): expected %s, got %s", plural, expected, got)
+		}
+	}
+}
+
+func TestGenerate_Go(t *testing.T) {
+	s := infer(t,
+		`{"id": 1, "user_id": "u1", "price": 9.5, "customer": {"name": "Ada", "email": null}, "items": [{"sku": "A", "qty": 2}], "tags": ["x", 1], "meta": {}}`,
+		`{"id": 2, "user_id": "u2", "price": 10, "customer": {"name": "Bob", "email": "b@x"}, "items": [{"sku": "B", "qty": 1, "note": "n"}], "tags": [], "coupon": {"code": "C"}}`,
+	)
+	got, err := Generate(s, Options{Name: "order", Package: "shop"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "package shop\n" +
+		"\n" +
+		"type Order struct {\n" +
+		"\tID       int64          `json:\"id\"`\n" +
+		"\tUserID   string         `json:\"user_id\"`\n" +
+		"\tPrice    float64        `json:\"price\"`\n" +
+		"\tCustomer OrderCustomer  `json:\"customer\"`\n" +
+		"\tItems    []OrderItem    `json:\"items\"`\n" +
+		"\tTags     []any          `json:\"tags\"`\n" +
+		"\tMeta     map[string]any `json:\"meta,omitempty\"`\n" +
+		"\tCoupon   *OrderCoupon   `json:\"coupon,omitempty\"`\n" +
+		"}\n" +
+		"\n" +
+		"type OrderCustomer struct {\n" +
+		"\tName  string  `json:\"name\"`\n" +
+		"\tEmail *string `json:\"email\"`\n" +
+		"}\n" +
+		"\n" +
+		"type OrderItem struct {\n" +
+		"\tSku  string  `json:\"sku\"`\n" +
+		"\tQty  int64   `json:\"qty\"`\n" +
+		"\tNote *string `json:\"note,omitempty\"`\n" +
+		"}\n" +
+		"\n" +
+		"type OrderCoupon struct {\n" +
+		"\tCode string `json:\"code\"`\n" +
+		"}\n"
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestGenerate_TypeScript(t *testing.T) {
+	s := infer(t,
+		`{"id": 1, "html-url": "x", "tags": ["x", 1], "owner": {"login": "ada"}, "extra": null}`,
+		`{"id": 2.5, "html-url": null, "tags": [[true]], "owner": {"login": "bob"}, "extra": {}}`,
+	)
+	got, err := Generate(s, Options{Language: TypeScript, Name: "Repo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `export interface Repo {
+  id: number;
+  "html-url": string | null;
+  tags: (number | string | boolean[])[];
+  owner: RepoOwner;
+  extra: Record<string, unknown> | null;
+}
+
+export interface RepoOwner {
+  login: string;
+}
+`
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestGenerate_TagNames(t *testing.T) {
+	// A json tag cannot name these members, so Go leaves them and their
+	// types out; "a-b c" is fine
+	s := infer(t, `{"": {"x": 1}, "a,b": 1, "x\"y": 2, "c\\d": 3, "a-b c": 4}`)
+	got, err := Generate(s, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "package main\n" +
+		"\n" +
+		"type Root struct {\n" +
+		"\t// The member \"\" is left out: encoding/json cannot map it to a field.\n" +
+		"\t// The member \"a,b\" is left out: encoding/json cannot map it to a field.\n" +
+		"\t// The member \"x\\\"y\" is left out: encoding/json cannot map it to a field.\n" +
+		"\t// The member \"c\\\\d\" is left out: encoding/json cannot map it to a field.\n" +
+		"\tABC int64 `json:\"a-b c\"`\n" +
+		"}\n"
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	got, err = Generate(s, Options{Language: TypeScript})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(got, `  "": RootField;`) || !strings.Contains(got, `  "a,b": number;`) {
+		t.Errorf("expected TypeScript to keep every member, got:\n%s", got)
+	}
+}
+
+func TestGenerate_RootTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []string
+		lang     Language
+		expected string
+	}{
+		{"array of records", []string{`[{"a": 1}, {"a": 2.5, "b": [null, 1]}]`}, Go,
+			"package main\n\ntype Root []RootItem\n\ntype RootItem struct {\n\tA float64  `json:\"a\"`\n\tB []*int64 `json:\"b,omitempty\"`\n}\n"},
+		{"array of records in TypeScript", []string{`[{"a": 1}]`}, TypeScript,
+			"export type Root = RootItem[];\n\nexport interface RootItem {\n  a: number;\n}\n"},
+		{"scalars", []string{`1`, `"x"`}, Go, "package main\n\ntype Root any\n"},
+		{"scalars in TypeScript", []string{`1`, `"x"`, `null`}, TypeScript, "export type Root = number | string | null;\n"},
+		{"null samples", []string{`{"a": true}`, `null`}, Go, "package main\n\ntype Root struct {\n\tA bool `json:\"a\"`\n}\n"},
+		{"odd keys", []string{`{"-": 1, "a` + "`" + `b": 2, "A B": 3, "a_b": 4}`}, Go,
+			"package main\n\ntype Root struct {\n\tField int64 `json:\"-,\"`\n\t// The member \"a`b\" is left out: encoding/json cannot map it to a field.\n\tAB  int64 `json:\"A B\"`\n\tAB2 int64 `json:\"a_b\"`\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(infer(t, tt.samples...), Options{Language: tt.lang})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGenerate_Naming(t *testing.T) {
+	// Two different "address" shapes and two equal "contact" shapes
+	s := infer(t, `{
+		"billing": {"address": {"street": "x"}, "contact": {"email": "a"}},
+		"shipping": {"address": {"street": "y", "zip": "1"}, "contact": {"email": "b"}}
+	}`)
+	tests := []struct {
+		naming   Naming
+		expected []string
+	}{
+		{NamePath, []string{"Root", "RootBilling", "RootShipping", "RootBillingAddress", "RootBillingContact", "RootShippingAddress"}},
+		{NameField, []string{"Root", "Billing", "Shipping", "Address", "Contact", "ShippingAddress"}},
+	}
+	for _, tt := range tests {
+		got, err := Generate(s, Options{Language: TypeScript, Naming: tt.naming})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.naming, err)
+		}
+		var names []string
+		for _, line := range strings.Split(got, "\n") {
+			if name, ok := strings.CutPrefix(line, "export interface "); ok {
+				names = append(names, strings.TrimSuffix(name, " {"))
+			}
+		}
+		if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s: expected types %v, got %v", tt.naming, tt.expected, names)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := Generate(infer(t, `{"a": 1}`), Options{Package: "my-pkg"}); err == nil || !strings.Contains(err.Error(), "generated invalid Go") {
+		t.Errorf("expected an invalid package name to fail, got %v", err)
+	}
+	for _, name := range []string{"rust", "ts2"} {
+		if _, err := ParseLanguage(name); err == nil {
+			t.Errorf("expected language %q to be rejected", name)
+		}
+	}
+	if _, err := ParseNaming("camel"); err == nil {
+		t.Errorf("expected naming camel to be rejected")
+	}
+}
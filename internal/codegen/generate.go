@@ -0,0 +1,302 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// Language selects the output of Generate.
+type Language int
+
+const (
+	// Go writes structs with json tags.
+	Go Language = iota
+	// TypeScript writes exported interfaces.
+	TypeScript
+)
+
+// String returns the language name used on the command line.
+func (l Language) String() string {
+	switch l {
+	case Go:
+		return "go"
+	case TypeScript:
+		return "typescript"
+	default:
+		return fmt.Sprintf("Language(%d)", int(l))
+	}
+}
+
+// ParseLanguage maps a language name, as accepted on the command line, to a
+// Language.
+func ParseLanguage(name string) (Language, error) {
+	switch name {
+	case "", "go":
+		return Go, nil
+	case "typescript", "ts":
+		return TypeScript, nil
+	default:
+		return Go, fmt.Errorf("unknown language %q (want go or typescript)", name)
+	}
+}
+
+// Options configure Generate.
+type Options struct {
+	Language Language
+	Name     string // Root type name; Root when empty
+	Package  string // Package clause of Go output; main when empty
+	Naming   Naming // Names of nested types
+}
+
+// Generate writes the type declarations of a shape, starting with the root
+// type and followed by the nested object types in the order they are
+// first used. Objects with the same shape share a type.
+//
+// Members missing from some samples are optional: a pointer with
+// omitempty in Go, and a "?" property in TypeScript. Members that were
+// sometimes null are pointers in Go and "| null" in TypeScript. Numbers
+// are int64 when every sample held an integer and float64 otherwise.
+// Values of several kinds become any in Go and a union in TypeScript, and
+// objects without members a map or Record. Go structs leave out members
+// whose names cannot go in a json tag, such as "" or "a,b", which
+// encoding/json cannot read or write, and say so in a comment.
+func Generate(s *Shape, opts Options) (string, error) {
+	if opts.Name == "" {
+		opts.Name = "Root"
+	}
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	g := &generator{opts: opts, named: make(map[*Shape]string), taken: make(map[string]*Shape)}
+
+	// The root is a struct or interface when the samples were objects (or
+	// null), and a named type for the merged kinds otherwise
+	root := pascal(opts.Name)
+	alias := ""
+	if s.Kinds&^Null == Object && len(s.Fields) > 0 {
+		g.claim(s, root)
+	} else {
+		g.taken[root] = nil
+		alias = g.typeOf(s, root, "item")
+	}
+
+	var sb strings.Builder
+	if opts.Language == Go {
+		sb.WriteString("package " + opts.Package + "\n")
+		if alias != "" {
+			fmt.Fprintf(&sb, "\ntype %s %s\n", root, alias)
+		}
+	} else if alias != "" {
+		fmt.Fprintf(&sb, "export type %s = %s;\n", root, alias)
+	}
+	for i := 0; i < len(g.queue); i++ {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		g.declaration(&sb, g.queue[i])
+	}
+
+	if opts.Language != Go {
+		return sb.String(), nil
+	}
+	src, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("generated invalid Go: %v", err)
+	}
+	return string(src), nil
+}
+
+type generator struct {
+	opts  Options
+	named map[*Shape]string // Type names of declared shapes
+	taken map[string]*Shape // Shapes of taken names; nil for the root alias
+	queue []*Shape          // Shapes to declare, in order
+}
+
+// declare returns the type name of an object shape, reserving a name and
+// queuing its declaration the first time.
+func (g *generator) declare(s *Shape, parent, field string) string {
+	if name, ok := g.named[s]; ok {
+		return name
+	}
+	for _, other := range g.queue {
+		if equal(other, s) {
+			g.named[s] = g.named[other]
+			return g.named[s]
+		}
+	}
+	path := parent + pascal(field)
+	candidates := []string{path}
+	if g.opts.Naming == NameField {
+		candidates = []string{pascal(field), path}
+	}
+	for _, name := range candidates {
+		if g.claim(s, name) {
+			return name
+		}
+	}
+	for i := 2; ; i++ {
+		if name := path + strconv.Itoa(i); g.claim(s, name) {
+			return name
+		}
+	}
+}
+
+// claim gives a shape a name that is free, or taken by an equal shape.
+func (g *generator) claim(s *Shape, name string) bool {
+	if other, ok := g.taken[name]; ok {
+		if other == nil || !equal(other, s) {
+			return false
+		}
+		g.named[s] = name
+		return true
+	}
+	g.taken[name] = s
+	g.named[s] = name
+	g.queue = append(g.queue, s)
+	return true
+}
+
+// typeOf returns the type expression of a shape found at a member of the
+// parent type.
+func (g *generator) typeOf(s *Shape, parent, field string) string {
+	if g.opts.Language == Go {
+		return g.goType(s, parent, field)
+	}
+	return g.tsType(s, parent, field)
+}
+
+func (g *generator) declaration(sb *strings.Builder, s *Shape) {
+	name := g.named[s]
+	if g.opts.Language == TypeScript {
+		fmt.Fprintf(sb, "export interface %s {\n", name)
+		for _, f := range s.Fields {
+			key := f.Name
+			if !isIdentifier(key) {
+				key = printer.New("").Print(parser.String(key))
+			}
+			if s.Optional(f) {
+				key += "?"
+			}
+			fmt.Fprintf(sb, "  %s: %s;\n", key, g.tsType(f.Shape, name, f.Name))
+		}
+		sb.WriteString("}\n")
+		return
+	}
+
+	fmt.Fprintf(sb, "type %s struct {\n", name)
+	used := make(map[string]bool)
+	for _, f := range s.Fields {
+		if !isTagName(f.Name) {
+			// encoding/json takes an invalid tag name to mean the field name
+			fmt.Fprintf(sb, "\t// The member %s is left out: encoding/json cannot map it to a field.\n", strconv.Quote(f.Name))
+			continue
+		}
+		field := pascal(f.Name)
+		for i := 2; used[field]; i++ {
+			field = pascal(f.Name) + strconv.Itoa(i)
+		}
+		used[field] = true
+
+		optional := s.Optional(f)
+		typ := g.goType(f.Shape, name, f.Name)
+		if (optional || f.Shape.Kinds&Null != 0) && pointable(f.Shape) {
+			typ = "*" + typ
+		}
+		tag := `json:"` + f.Name
+		if f.Name == "-" {
+			tag += ","
+		}
+		if optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(sb, "\t%s %s `%s\"`\n", field, typ, tag)
+	}
+	sb.WriteString("}\n")
+}
+
+// goType returns the Go type of a shape, without the pointer of a nullable
+// or optional member.
+func (g *generator) goType(s *Shape, parent, field string) string {
+	switch s.Kinds &^ Null {
+	case Bool:
+		return "bool"
+	case Int:
+		return "int64"
+	case Float, Int | Float:
+		return "float64"
+	case String:
+		return "string"
+	case Array:
+		if s.Elem == nil {
+			return "[]any"
+		}
+		elem := g.goType(s.Elem, parent, singular(field))
+		if s.Elem.Kinds&Null != 0 && pointable(s.Elem) {
+			elem = "*" + elem
+		}
+		return "[]" + elem
+	case Object:
+		if len(s.Fields) == 0 {
+			return "map[string]any"
+		}
+		return g.declare(s, parent, field)
+	}
+	return "any"
+}
+
+// pointable reports whether the Go type of a shape needs a pointer to tell
+// null or a missing member from the zero value. Slices, maps and any
+// already have nil.
+func pointable(s *Shape) bool {
+	switch s.Kinds &^ Null {
+	case Bool, Int, Float, Int | Float, String:
+		return true
+	case Object:
+		return len(s.Fields) > 0
+	}
+	return false
+}
+
+// tsType returns the TypeScript type of a shape: the union of its kinds.
+func (g *generator) tsType(s *Shape, parent, field string) string {
+	var parts []string
+	if s.Kinds&Bool != 0 {
+		parts = append(parts, "boolean")
+	}
+	if s.Kinds&(Int|Float) != 0 {
+		parts = append(parts, "number")
+	}
+	if s.Kinds&String != 0 {
+		parts = append(parts, "string")
+	}
+	if s.Kinds&Array != 0 {
+		elem := "unknown"
+		if s.Elem != nil {
+			elem = g.tsType(s.Elem, parent, singular(field))
+		}
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		parts = append(parts, elem+"[]")
+	}
+	if s.Kinds&Object != 0 {
+		if len(s.Fields) == 0 {
+			parts = append(parts, "Record<string, unknown>")
+		} else {
+			parts = append(parts, g.declare(s, parent, field))
+		}
+	}
+	if s.Kinds&Null != 0 {
+		parts = append(parts, "null")
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " | ")
+}
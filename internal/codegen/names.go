@@ -0,0 +1,152 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Naming selects how nested types are named.
+type Naming int
+
+const (
+	// NamePath joins the parent type name and the member name, so the
+	// "customer" member of Order gives OrderCustomer.
+	NamePath Naming = iota
+	// NameField uses the member name alone, giving Customer, and falls back
+	// to the path name when two different shapes want the same name.
+	NameField
+)
+
+// String returns the naming name used on the command line.
+func (n Naming) String() string {
+	switch n {
+	case NamePath:
+		return "path"
+	case NameField:
+		return "field"
+	default:
+		return fmt.Sprintf("Naming(%d)", int(n))
+	}
+}
+
+// ParseNaming maps a naming name, as accepted on the command line, to a
+// Naming.
+func ParseNaming(name string) (Naming, error) {
+	switch name {
+	case "", "path":
+		return NamePath, nil
+	case "field":
+		return NameField, nil
+	default:
+		return NamePath, fmt.Errorf("unknown naming %q (want path or field)", name)
+	}
+}
+
+// initialisms are written in upper case, as Go style asks.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UI": true, "URI": true, "URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// words splits a name into words at punctuation and case changes, so that
+// "user_id", "userId" and "UserID" all give user and id.
+func words(name string) []string {
+	var result []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			result = append(result, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return result
+}
+
+// pascal joins the words of a name in PascalCase, writing initialisms in
+// upper case. The result starts with an upper-case letter, with an X in
+// front when the name starts otherwise, and is "Field" for a name without
+// letters or digits.
+func pascal(name string) string {
+	var sb strings.Builder
+	for _, w := range words(name) {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	s := sb.String()
+	if s == "" {
+		return "Field"
+	}
+	if first := []rune(s)[0]; !unicode.IsUpper(first) {
+		return "X" + s
+	}
+	return s
+}
+
+// singular guesses the singular of an English plural, so that the elements
+// of "items" are named Item and those of "categories" Category.
+func singular(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && !strings.HasSuffix(lower, "us") && !strings.HasSuffix(lower, "is") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// isTagName reports whether a member name can be the name in a json struct
+// tag, by the rule encoding/json applies: letters, digits and punctuation
+// other than quotes, backslashes and commas.
+func isTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", r) && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdentifier reports whether a member name can be written unquoted as a
+// TypeScript property.
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
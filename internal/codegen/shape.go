@@ -0,0 +1,138 @@
+// Package codegen generates Go and TypeScript type definitions from sample
+// JSON documents. The samples are first merged into a Shape, which records
+// every kind of value seen at each place and how often each object member
+// appeared; Generate then writes the declarations for that shape.
+package codegen
+
+import (
+	"math"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Kind is a set of JSON value kinds. Numbers are split into integers and
+// floats so that fields holding only integers get an integer type.
+type Kind uint8
+
+// Kind flags
+const (
+	Null Kind = 1 << iota
+	Bool
+	Int
+	Float
+	String
+	Array
+	Object
+)
+
+// String lists the kinds in a set, as in "int|string".
+func (k Kind) String() string {
+	var names []string
+	for i, name := range []string{"null", "bool", "int", "float", "string", "array", "object"} {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// A Shape is the merged type of every value seen at one place in the
+// samples.
+type Shape struct {
+	Kinds   Kind
+	Elem    *Shape   // Elements of every array seen; nil if all were empty
+	Fields  []*Field // Members of every object seen, in first-seen order
+	Objects int      // Number of objects merged
+
+	fields map[string]*Field
+}
+
+// A Field is an object member and the merged shape of its values.
+type Field struct {
+	Name  string
+	Shape *Shape
+	Count int // Number of objects holding the member
+}
+
+// Infer merges samples into a single shape.
+func Infer(samples ...parser.Value) *Shape {
+	s := &Shape{}
+	for _, v := range samples {
+		s.Add(v)
+	}
+	return s
+}
+
+// Add merges a value into the shape.
+func (s *Shape) Add(v parser.Value) {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		s.Kinds |= Object
+		s.Objects++
+		if s.fields == nil {
+			s.fields = make(map[string]*Field)
+		}
+		for _, key := range n.OrderedKeys() {
+			f, ok := s.fields[key]
+			if !ok {
+				f = &Field{Name: key, Shape: &Shape{}}
+				s.fields[key] = f
+				s.Fields = append(s.Fields, f)
+			}
+			f.Count++
+			f.Shape.Add(n.Pairs[key])
+		}
+	case *parser.ArrayValue:
+		s.Kinds |= Array
+		for _, elem := range n.Elements {
+			if s.Elem == nil {
+				s.Elem = &Shape{}
+			}
+			s.Elem.Add(elem)
+		}
+	case *parser.StringValue:
+		s.Kinds |= String
+	case *parser.NumberValue:
+		if f := n.Value; f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			s.Kinds |= Int
+		} else {
+			s.Kinds |= Float
+		}
+	case *parser.BooleanValue:
+		s.Kinds |= Bool
+	case *parser.NullValue:
+		s.Kinds |= Null
+	}
+}
+
+// Optional reports whether some objects of the shape lack a field.
+func (s *Shape) Optional(f *Field) bool {
+	return f.Count < s.Objects
+}
+
+// Nullable reports whether null was seen alongside other kinds.
+func (s *Shape) Nullable() bool {
+	return s.Kinds&Null != 0 && s.Kinds != Null
+}
+
+// equal reports whether two shapes would generate the same declaration:
+// the same kinds, elements and fields, with the same optionality.
+func equal(a, b *Shape) bool {
+	if a.Kinds != b.Kinds || (a.Elem == nil) != (b.Elem == nil) || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	if a.Elem != nil && !equal(a.Elem, b.Elem) {
+		return false
+	}
+	for _, f := range a.Fields {
+		g, ok := b.fields[f.Name]
+		if !ok || a.Optional(f) != b.Optional(g) || !equal(f.Shape, g.Shape) {
+			return false
+		}
+	}
+	return true
+}
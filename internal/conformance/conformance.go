@@ -0,0 +1,192 @@
+// Package conformance checks the parser against test suites laid out like
+// JSONTestSuite (https://github.com/nst/JSONTestSuite): files whose names
+// start with y_ must be accepted, n_ must be rejected, and i_ may go either
+// way.
+package conformance
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// Expectation is what a suite file expects of the parser.
+type Expectation int
+
+const (
+	Accept Expectation = iota // y_ files: valid JSON
+	Reject                    // n_ files: invalid JSON
+	Either                    // i_ files: implementation-defined
+)
+
+// String returns the name of the expectation.
+func (e Expectation) String() string {
+	switch e {
+	case Accept:
+		return "accept"
+	case Reject:
+		return "reject"
+	default:
+		return "either"
+	}
+}
+
+// expectationFor returns the expectation encoded in a file name prefix.
+func expectationFor(name string) (Expectation, bool) {
+	switch {
+	case strings.HasPrefix(name, "y_"):
+		return Accept, true
+	case strings.HasPrefix(name, "n_"):
+		return Reject, true
+	case strings.HasPrefix(name, "i_"):
+		return Either, true
+	default:
+		return 0, false
+	}
+}
+
+// Result is the outcome of checking one file.
+type Result struct {
+	Name      string
+	Expected  Expectation
+	Accepted  bool  // Whether the parser accepted the file
+	Err       error // Parse error of a rejected file
+	RoundTrip error // Round-trip failure of an accepted file, if any
+}
+
+// Passed reports whether the parser met the file's expectation. Accepted
+// files must also survive the round trip, whatever their expectation.
+func (r *Result) Passed() bool {
+	if r.Accepted && r.RoundTrip != nil {
+		return false
+	}
+	switch r.Expected {
+	case Accept:
+		return r.Accepted
+	case Reject:
+		return !r.Accepted
+	default:
+		return true
+	}
+}
+
+// Report holds the results of a suite run in file name order.
+type Report struct {
+	Results []*Result
+}
+
+// Score returns how many of the files with a definite expectation (y_ and
+// n_) passed, out of how many. Implementation-defined files do not count.
+func (r *Report) Score() (passed, total int) {
+	for _, result := range r.Results {
+		if result.Expected == Either {
+			continue
+		}
+		total++
+		if result.Passed() {
+			passed++
+		}
+	}
+	return passed, total
+}
+
+// Failures returns the results that did not pass, including i_ files
+// that failed the round trip.
+func (r *Report) Failures() []*Result {
+	var failures []*Result
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// WriteMatrix writes one line per file with its expectation and outcome,
+// followed by the compliance score.
+func (r *Report) WriteMatrix(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tEXPECTED\tPARSED\tROUND TRIP\tRESULT")
+	for _, result := range r.Results {
+		parsed, roundTrip, status := "rejected", "-", "FAIL"
+		if result.Accepted {
+			parsed, roundTrip = "accepted", "ok"
+			if result.RoundTrip != nil {
+				roundTrip = "failed"
+			}
+		}
+		if result.Passed() {
+			status = "pass"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Expected, parsed, roundTrip, status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	passed, total := r.Score()
+	score := 100.0
+	if total > 0 {
+		score = 100 * float64(passed) / float64(total)
+	}
+	_, err := fmt.Fprintf(w, "\nCompliance: %.1f%% (%d of %d files), %d implementation-defined\n",
+		score, passed, total, len(r.Results)-total)
+	return err
+}
+
+// Run checks every y_, n_ and i_ .json file in dir. Other files are ignored.
+func Run(dir string) (*Report, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for _, entry := range entries {
+		name := entry.Name()
+		expected, ok := expectationFor(name)
+		if !ok || entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		result := Check(data)
+		result.Name, result.Expected = name, expected
+		report.Results = append(report.Results, result)
+	}
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].Name < report.Results[j].Name })
+	return report, nil
+}
+
+// Check parses data as strict JSON and, if it is accepted, serializes and
+// reparses it, expecting the second serialization to match the first.
+func Check(data []byte) *Result {
+	root, err := parser.New(lexer.New(string(data))).Parse()
+	if err != nil {
+		return &Result{Err: err}
+	}
+	return &Result{Accepted: true, RoundTrip: roundTrip(root)}
+}
+
+// roundTrip serializes root, parses the output again and compares the two.
+func roundTrip(root parser.Node) error {
+	serialized := printer.New("").Print(root)
+	reparsed, err := parser.New(lexer.New(serialized)).Parse()
+	if err != nil {
+		return fmt.Errorf("serialized form %q does not parse: %w", serialized, err)
+	}
+	if again := printer.New("").Print(reparsed); again != serialized {
+		return fmt.Errorf("serialized form changed from %q to %q", serialized, again)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		accepted bool
+	}{
+		{"Object", `{"a": [1, 2.5, "x"]}`, true},
+		{"Scalar Root", `-0`, true},
+		{"Escapes", `["é😀\n"]`, true},
+		{"Trailing Tokens", `[] []`, false},
+		{"Raw Control Character", "[\"\x01\"]", false},
+		{"Empty", ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Check([]byte(tt.input))
+			if result.Accepted != tt.accepted {
+				t.Fatalf("expected accepted=%v, got %v (error: %v)", tt.accepted, result.Accepted, result.Err)
+			}
+			if result.RoundTrip != nil {
+				t.Errorf("unexpected round-trip failure: %v", result.RoundTrip)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"y_ok.json":      `[1]`,
+		"y_broken.json":  `[1,]`,
+		"n_ok.json":      `{"a"}`,
+		"n_broken.json":  `{}`,
+		"i_either.json":  `[1e999]`,
+		"README.md":      `not a suite file`,
+		"x_unknown.json": `[]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Run(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, result := range report.Results {
+		names = append(names, result.Name)
+	}
+	if got := strings.Join(names, " "); got != "i_either.json n_broken.json n_ok.json y_broken.json y_ok.json" {
+		t.Errorf("unexpected files: %s", got)
+	}
+
+	if passed, total := report.Score(); passed != 2 || total != 4 {
+		t.Errorf("expected score 2/4, got %d/%d", passed, total)
+	}
+	if failures := report.Failures(); len(failures) != 2 || failures[0].Name != "n_broken.json" || failures[1].Name != "y_broken.json" {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+
+	var sb strings.Builder
+	if err := report.WriteMatrix(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"y_broken.json  accept    rejected", "Compliance: 50.0% (2 of 4 files), 1 implementation-defined"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("expected matrix to contain %q, got:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestRun_Suite(t *testing.T) {
+	report, err := Run(filepath.Join("..", "..", "test", "conformance"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Results) == 0 {
+		t.Fatal("expected suite files, found none")
+	}
+	for _, result := range report.Failures() {
+		t.Errorf("%s: expected %s, accepted=%v, error: %v, round trip: %v",
+			result.Name, result.Expected, result.Accepted, result.Err, result.RoundTrip)
+	}
+}
@@ -0,0 +1,193 @@
+package cst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// Kind identifies the syntactic category of a Node.
+type Kind string
+
+// Kind constants define the node types of the concrete syntax tree.
+const (
+	DocumentNode Kind = "Document" // The whole input: a value followed by the EOF token
+	ObjectNode   Kind = "Object"   // '{', members separated by ',', '}'
+	MemberNode   Kind = "Member"   // key, ':', value
+	ArrayNode    Kind = "Array"    // '[', values separated by ',', ']'
+	ScalarNode   Kind = "Scalar"   // A single string, number, boolean or null token
+)
+
+// Element is a child of a Node: either a *Token or a *Node.
+type Element interface {
+	writeTo(sb *strings.Builder)
+}
+
+// Token is a lexical token together with the trivia in front of it.
+// Concatenating Leading and Text over all tokens reproduces the input.
+type Token struct {
+	Type    lexer.TokenType
+	Leading string // Whitespace and comments before the token, verbatim
+	Text    string // The token exactly as spelled in the source
+	Value   string // The decoded literal, e.g. a string without quotes or escapes
+	Line    int    // Line number where the token appears
+	Column  int    // Column number where the token appears
+	Offset  int    // Byte offset where the token starts
+
+	index  int   // Position in the tree's token list, -1 while not yet numbered
+	starts *Node // Value node beginning with this token, for reuse by Tree.Apply
+}
+
+func (t *Token) writeTo(sb *strings.Builder) {
+	sb.WriteString(t.Leading)
+	sb.WriteString(t.Text)
+}
+
+// Node is an interior node of the concrete syntax tree. Its children hold
+// every token of the construct, punctuation included, in source order.
+type Node struct {
+	Kind     Kind
+	Children []Element
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	for _, child := range n.Children {
+		child.writeTo(sb)
+	}
+}
+
+// String prints the node exactly as it appeared in the input.
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+// Tokens returns every token below the node in source order.
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *Token:
+			tokens = append(tokens, c)
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		}
+	}
+	return tokens
+}
+
+// Span returns the byte offsets where the node's first token starts and its
+// last token ends. Leading trivia is not included.
+func (n *Node) Span() (start, end int) {
+	return n.firstToken().Offset, n.lastToken().End()
+}
+
+// firstToken returns the first token below the node.
+func (n *Node) firstToken() *Token {
+	switch c := n.Children[0].(type) {
+	case *Token:
+		return c
+	default:
+		return c.(*Node).firstToken()
+	}
+}
+
+// lastToken returns the last token below the node.
+func (n *Node) lastToken() *Token {
+	switch c := n.Children[len(n.Children)-1].(type) {
+	case *Token:
+		return c
+	default:
+		return c.(*Node).lastToken()
+	}
+}
+
+// End returns the byte offset just past the token.
+func (t *Token) End() int {
+	return t.Offset + len(t.Text)
+}
+
+// Nodes returns the direct child nodes, skipping punctuation tokens.
+func (n *Node) Nodes() []*Node {
+	var nodes []*Node
+	for _, child := range n.Children {
+		if c, ok := child.(*Node); ok {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// Key returns the key token of a member node, or nil for other kinds.
+func (n *Node) Key() *Token {
+	if n.Kind != MemberNode {
+		return nil
+	}
+	return n.Children[0].(*Token)
+}
+
+// Value returns the value node of a member or document node, or nil for other kinds.
+func (n *Node) Value() *Node {
+	if n.Kind != MemberNode && n.Kind != DocumentNode {
+		return nil
+	}
+	nodes := n.Nodes()
+	return nodes[len(nodes)-1]
+}
+
+// ToAST converts the node to the equivalent parser.Value, dropping trivia.
+// Object members keep their source order.
+func (n *Node) ToAST() (parser.Value, error) {
+	switch n.Kind {
+	case DocumentNode, MemberNode:
+		return n.Value().ToAST()
+	case ObjectNode:
+		object := &parser.ObjectValue{Pairs: make(map[string]parser.Value)}
+		for _, member := range n.Nodes() {
+			value, err := member.ToAST()
+			if err != nil {
+				return nil, err
+			}
+			object.Set(member.Key().Value, value)
+		}
+		return object, nil
+	case ArrayNode:
+		array := &parser.ArrayValue{Elements: []parser.Value{}}
+		for _, elem := range n.Nodes() {
+			value, err := elem.ToAST()
+			if err != nil {
+				return nil, err
+			}
+			array.Elements = append(array.Elements, value)
+		}
+		return array, nil
+	case ScalarNode:
+		return scalarToAST(n.Children[0].(*Token))
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", n.Kind)
+	}
+}
+
+// scalarToAST converts a scalar token to its AST value.
+func scalarToAST(tok *Token) (parser.Value, error) {
+	switch tok.Type {
+	case lexer.STRING:
+		return &parser.StringValue{Value: tok.Value}, nil
+	case lexer.NUMBER:
+		num, err := parser.ParseNumber(tok.Text)
+		if err != nil {
+			return nil, errors.NewParseError(tok.Line, tok.Column, "could not parse number: "+err.Error())
+		}
+		return &parser.NumberValue{Value: num}, nil
+	case lexer.TRUE:
+		return &parser.BooleanValue{Value: true}, nil
+	case lexer.FALSE:
+		return &parser.BooleanValue{Value: false}, nil
+	default:
+		return &parser.NullValue{}, nil
+	}
+}
@@ -0,0 +1,262 @@
+package cst
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		dialect lexer.Dialect
+	}{
+		{"Compact", `{"a":1,"b":[true,false,null]}`, lexer.Strict},
+		{"Odd Whitespace", " \t{ \"a\" :\r\n 1.50 ,\"b\":[ ] }\n\n", lexer.Strict},
+		{"Escapes Kept Verbatim", `{"aA": "\/x\n"}`, lexer.Strict},
+		{"Scalar Root", `  -1e+10  `, lexer.Strict},
+		{"JSONC", "// head\n{\n  \"a\": 1, // tail\n  /* b */ \"b\": [1,],\n}\n// end\n", lexer.JSONC},
+		{"JSON5", "{key: 'v', hex: 0xFF, n: +.5,}", lexer.JSON5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Parse(tt.input, tt.dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := root.String(); got != tt.input {
+				t.Errorf("expected %q, got %q", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{"", "{", `{"a" 1}`, `[1,]`, `{"a":1} x`, `{a: 1}`} {
+		if _, err := Parse(input, lexer.Strict); err == nil {
+			t.Errorf("expected error for input %q, got none", input)
+		}
+	}
+}
+
+func TestToAST(t *testing.T) {
+	input := `{"z": [1, "two", {"x": null}], "a": true, "n": 0x10}`
+	root, err := Parse(input, lexer.JSON5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, err := root.ToAST()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"z":[1,"two",{"x":null}],"a":true,"n":16}`
+	if got := printer.New("").Print(value); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestMinimalDiffRewrite(t *testing.T) {
+	input := "{\n  \"port\":   8080, // keep me\n  \"host\": \"localhost\"\n}"
+	root, err := Parse(input, lexer.JSONC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Change only the spelling of the port value
+	for _, member := range root.Value().Nodes() {
+		if member.Key().Value == "port" {
+			member.Value().Tokens()[0].Text = "9090"
+		}
+	}
+
+	expected := "{\n  \"port\":   9090, // keep me\n  \"host\": \"localhost\"\n}"
+	if got := root.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	value, err := root.ToAST()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port := value.(*parser.ObjectValue).Pairs["port"].(*parser.NumberValue).Value; port != 9090 {
+		t.Errorf("expected port 9090, got %v", port)
+	}
+}
+
+func TestTreeApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		dialect lexer.Dialect
+		edit    Edit
+	}{
+		{"Replace Value", `{"a": 1, "b": [true, null]}`, lexer.Strict, Edit{Start: 6, End: 7, Text: "12345"}},
+		{"Insert Member", "{\n  \"a\": 1\n}", lexer.Strict, Edit{Start: 10, End: 10, Text: ",\n  \"b\": {\"c\": []}"}},
+		{"Delete Member", `{"a": 1, "b": 2, "c": 3}`, lexer.Strict, Edit{Start: 7, End: 15, Text: ""}},
+		{"Extend Token", `{"a": 1, "b": 2}`, lexer.Strict, Edit{Start: 7, End: 7, Text: "00"}},
+		{"Join Lines", "[1,\n2,\n3]", lexer.Strict, Edit{Start: 3, End: 4, Text: " "}},
+		{"Split Lines", `[1, 2, 3]`, lexer.Strict, Edit{Start: 3, End: 4, Text: "\n\n  "}},
+		{"Open String", `{"a": "x", "b": "y"}`, lexer.Strict, Edit{Start: 6, End: 7, Text: ""}},
+		{"Comment Out", "{\n  \"a\": 1,\n  \"b\": 2\n}", lexer.JSONC, Edit{Start: 3, End: 3, Text: "// "}},
+		{"Identifier Becomes Key", "{a: [1], c: 1}", lexer.JSON5, Edit{Start: 1, End: 1, Text: "x"}},
+		{"Whole Document", `{"a": 1}`, lexer.Strict, Edit{Start: 0, End: 8, Text: `[1, 2]`}},
+		{"At End", `[1, 2]`, lexer.Strict, Edit{Start: 6, End: 6, Text: "\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := NewTree(tt.input, tt.dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			text := tt.input[:tt.edit.Start] + tt.edit.Text + tt.input[tt.edit.End:]
+			expected, expectedErr := NewTree(text, tt.dialect)
+
+			got, err := tree.Apply(tt.edit)
+			if expectedErr != nil {
+				if err == nil || err.Error() != expectedErr.Error() {
+					t.Fatalf("expected error %v, got %v", expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			compareTrees(t, expected.Root, got.Root)
+			if got.Text() != text {
+				t.Errorf("expected text %q, got %q", text, got.Text())
+			}
+		})
+	}
+}
+
+func TestTreeApply_Sequence(t *testing.T) {
+	text := "{\n  \"name\": \"demo\",\n  \"tags\": [\"a\", \"b\"]\n}"
+	tree, err := NewTree(text, lexer.JSONC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	edits := []struct {
+		anchor string // Text the edit starts at
+		remove int
+		insert string
+	}{
+		{`"demo"`, 6, `"renamed"`},
+		{`"tags"`, 0, "// tags\n  "},
+		{"\n", 0, "\n  \"id\": 7,"},
+		{"{", 0, "/* head */ "},
+	}
+	for _, e := range edits {
+		start := strings.Index(text, e.anchor)
+		edit := Edit{Start: start, End: start + e.remove, Text: e.insert}
+		text = text[:edit.Start] + edit.Text + text[edit.End:]
+		if tree, err = tree.Apply(edit); err != nil {
+			t.Fatalf("unexpected error after edit %+v: %v", edit, err)
+		}
+		expected, err := NewTree(text, lexer.JSONC)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		compareTrees(t, expected.Root, tree.Root)
+	}
+}
+
+func TestTreeApply_ReusesSubtrees(t *testing.T) {
+	input := `{"before": {"x": [1, 2]}, "edited": 1, "after": [{"y": true}]}`
+	tree, err := NewTree(input, lexer.Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	members := tree.Root.Value().Nodes()
+	before, after := members[0].Value(), members[2].Value()
+
+	start := strings.Index(input, `"edited": 1`) + len(`"edited": `)
+	tree, err = tree.Apply(Edit{Start: start, End: start + 1, Text: "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	members = tree.Root.Value().Nodes()
+	if members[0].Value() != before {
+		t.Errorf("expected subtree before the edit to be reused")
+	}
+	if members[2].Value() != after {
+		t.Errorf("expected subtree after the edit to be reused")
+	}
+	if got := members[1].Value().String(); got != " 2" {
+		t.Errorf("expected edited value %q, got %q", " 2", got)
+	}
+}
+
+func TestTreeApply_Error(t *testing.T) {
+	input := "{\n  \"a\": [1, 2],\n  \"b\": {\"c\": 3}\n}"
+	tree, err := NewTree(input, lexer.Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A failed edit leaves the tree as it was, so it can take the next one
+	start := strings.Index(input, "2")
+	if _, err := tree.Apply(Edit{Start: start, End: start, Text: "1\n "}); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	original, _ := NewTree(input, lexer.Strict)
+	compareTrees(t, original.Root, tree.Root)
+
+	start = strings.Index(input, "3")
+	got, err := tree.Apply(Edit{Start: start, End: start + 1, Text: "[4]"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, _ := NewTree(strings.Replace(input, "3", "[4]", 1), lexer.Strict)
+	compareTrees(t, expected.Root, got.Root)
+}
+
+func TestTreeApply_OutOfBounds(t *testing.T) {
+	tree, err := NewTree(`[1]`, lexer.Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, edit := range []Edit{{Start: -1, End: 0}, {Start: 2, End: 1}, {Start: 0, End: 4}} {
+		if _, err := tree.Apply(edit); err == nil {
+			t.Errorf("expected error for edit %+v, got none", edit)
+		}
+	}
+}
+
+// compareTrees checks that two trees have the same shape and tokens.
+func compareTrees(t *testing.T, expected, got *Node) {
+	t.Helper()
+	if expected.String() != got.String() {
+		t.Fatalf("expected %q, got %q", expected.String(), got.String())
+	}
+	if expected.Kind != got.Kind || len(expected.Children) != len(got.Children) {
+		t.Fatalf("expected %v node with %d children, got %v node with %d", expected.Kind, len(expected.Children), got.Kind, len(got.Children))
+	}
+	for i, child := range expected.Children {
+		switch e := child.(type) {
+		case *Node:
+			g, ok := got.Children[i].(*Node)
+			if !ok {
+				t.Fatalf("expected node at child %d of %q", i, expected.String())
+			}
+			compareTrees(t, e, g)
+		case *Token:
+			g, ok := got.Children[i].(*Token)
+			if !ok {
+				t.Fatalf("expected token at child %d of %q", i, expected.String())
+			}
+			if e.Type != g.Type || e.Leading != g.Leading || e.Text != g.Text || e.Value != g.Value ||
+				e.Line != g.Line || e.Column != g.Column || e.Offset != g.Offset {
+				t.Errorf("token mismatch: expected %+v, got %+v", *e, *g)
+			}
+		}
+	}
+}
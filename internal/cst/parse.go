@@ -0,0 +1,236 @@
+package cst
+
+import (
+	"fmt"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// builder builds a concrete syntax tree from a slice of tokens.
+type builder struct {
+	tokens  []*Token
+	pos     int // Index of the current token
+	dialect lexer.Dialect
+
+	// reuse, when set, is offered every old value node starting at the
+	// current token. It returns the index of the token following the node
+	// if the node can be reused unchanged.
+	reuse func(n *Node) (int, bool)
+
+	// built holds the value nodes parsed so far with their first tokens.
+	// The tokens, which may belong to an old tree, only point to them once
+	// the whole document parses.
+	built []builtNode
+}
+
+// builtNode is a value node and the token it starts with.
+type builtNode struct {
+	start *Token
+	node  *Node
+}
+
+// Parse builds the concrete syntax tree of input in the given dialect. The
+// root is a DocumentNode whose String method returns input unchanged.
+func Parse(input string, dialect lexer.Dialect) (*Node, error) {
+	tree, err := NewTree(input, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Root, nil
+}
+
+// tokenize lexes input from the lexer's current position until EOF, an
+// ILLEGAL token, or stop returns true for a token. Comments are left in the
+// input and picked up as trivia. prevEnd is the offset where the trivia of
+// the first token starts.
+func tokenize(l *lexer.Lexer, input string, prevEnd int, stop func(tok lexer.Token, prevEnd int) bool) []*Token {
+	var tokens []*Token
+	for {
+		lt := l.NextToken()
+		if lt.Type == lexer.COMMENT {
+			continue
+		}
+		if stop != nil && stop(lt, prevEnd) {
+			return tokens
+		}
+
+		tokens = append(tokens, &Token{
+			Type:    lt.Type,
+			Leading: input[prevEnd:lt.Offset],
+			Text:    input[lt.Offset:lt.End],
+			Value:   lt.Literal,
+			Line:    lt.Line,
+			Column:  lt.Column,
+			Offset:  lt.Offset,
+		})
+		prevEnd = lt.End
+
+		if lt.Type == lexer.EOF || lt.Type == lexer.ILLEGAL {
+			return tokens
+		}
+	}
+}
+
+// build parses a whole document from tokens.
+func build(tokens []*Token, dialect lexer.Dialect, reuse func(n *Node) (int, bool)) (*Node, error) {
+	b := &builder{tokens: tokens, dialect: dialect, reuse: reuse}
+
+	value, err := b.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if b.cur().Type != lexer.EOF {
+		return nil, b.unexpected("end of input")
+	}
+	for _, built := range b.built {
+		built.start.starts = built.node
+	}
+	return &Node{Kind: DocumentNode, Children: []Element{value, b.take()}}, nil
+}
+
+// cur returns the current token.
+func (b *builder) cur() *Token {
+	return b.tokens[min(b.pos, len(b.tokens)-1)]
+}
+
+// take returns the current token and advances.
+func (b *builder) take() *Token {
+	tok := b.cur()
+	b.pos++
+	return tok
+}
+
+// expect takes the current token if it has the given type.
+func (b *builder) expect(tokenType lexer.TokenType, what string) (*Token, error) {
+	if b.cur().Type != tokenType {
+		return nil, b.unexpected(what)
+	}
+	return b.take(), nil
+}
+
+// unexpected reports the current token as a parse error.
+func (b *builder) unexpected(what string) error {
+	tok := b.cur()
+	if tok.Type == lexer.ILLEGAL {
+		return errors.NewParseError(tok.Line, tok.Column, tok.Value)
+	}
+	return errors.NewParseError(tok.Line, tok.Column, fmt.Sprintf("expected %s, got %s", what, tok.Type))
+}
+
+// allowsTrailingCommas reports whether the dialect accepts a comma before a closing bracket.
+func (b *builder) allowsTrailingCommas() bool {
+	return b.dialect == lexer.JSON5 || b.dialect == lexer.JSONC
+}
+
+// parseValue parses any value, reusing an old node when possible.
+func (b *builder) parseValue() (*Node, error) {
+	tok := b.cur()
+	if b.reuse != nil && tok.starts != nil {
+		if next, ok := b.reuse(tok.starts); ok {
+			b.pos = next
+			return tok.starts, nil
+		}
+	}
+
+	var value *Node
+	var err error
+	switch tok.Type {
+	case lexer.LBRACE:
+		value, err = b.parseObject()
+	case lexer.LBRACKET:
+		value, err = b.parseArray()
+	case lexer.STRING, lexer.NUMBER, lexer.TRUE, lexer.FALSE, lexer.NULL:
+		value = &Node{Kind: ScalarNode, Children: []Element{b.take()}}
+	default:
+		return nil, b.unexpected("value")
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.built = append(b.built, builtNode{start: tok, node: value})
+	return value, nil
+}
+
+// parseObject parses an object, keeping braces and commas as children.
+func (b *builder) parseObject() (*Node, error) {
+	object := &Node{Kind: ObjectNode, Children: []Element{b.take()}}
+	if b.cur().Type == lexer.RBRACE {
+		object.Children = append(object.Children, b.take())
+		return object, nil
+	}
+
+	for {
+		member, err := b.parseMember()
+		if err != nil {
+			return nil, err
+		}
+		object.Children = append(object.Children, member)
+
+		if b.cur().Type == lexer.RBRACE {
+			object.Children = append(object.Children, b.take())
+			return object, nil
+		}
+		comma, err := b.expect(lexer.COMMA, "',' or '}'")
+		if err != nil {
+			return nil, err
+		}
+		object.Children = append(object.Children, comma)
+
+		if b.allowsTrailingCommas() && b.cur().Type == lexer.RBRACE {
+			object.Children = append(object.Children, b.take())
+			return object, nil
+		}
+	}
+}
+
+// parseMember parses a key, colon and value.
+func (b *builder) parseMember() (*Node, error) {
+	if b.cur().Type != lexer.STRING && !(b.dialect == lexer.JSON5 && b.cur().Type == lexer.IDENT) {
+		return nil, b.unexpected("string key")
+	}
+	key := b.take()
+
+	colon, err := b.expect(lexer.COLON, "':'")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := b.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: MemberNode, Children: []Element{key, colon, value}}, nil
+}
+
+// parseArray parses an array, keeping brackets and commas as children.
+func (b *builder) parseArray() (*Node, error) {
+	array := &Node{Kind: ArrayNode, Children: []Element{b.take()}}
+	if b.cur().Type == lexer.RBRACKET {
+		array.Children = append(array.Children, b.take())
+		return array, nil
+	}
+
+	for {
+		value, err := b.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array.Children = append(array.Children, value)
+
+		if b.cur().Type == lexer.RBRACKET {
+			array.Children = append(array.Children, b.take())
+			return array, nil
+		}
+		comma, err := b.expect(lexer.COMMA, "',' or ']'")
+		if err != nil {
+			return nil, err
+		}
+		array.Children = append(array.Children, comma)
+
+		if b.allowsTrailingCommas() && b.cur().Type == lexer.RBRACKET {
+			array.Children = append(array.Children, b.take())
+			return array, nil
+		}
+	}
+}
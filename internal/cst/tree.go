@@ -0,0 +1,166 @@
+package cst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+)
+
+// Tree is a concrete syntax tree together with its source text and token
+// list, which lets it be updated incrementally after an edit.
+type Tree struct {
+	Root    *Node
+	text    string
+	dialect lexer.Dialect
+	tokens  []*Token // Significant tokens in source order, ending with EOF
+}
+
+// Edit replaces the bytes [Start, End) of a document with Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// NewTree parses input in the given dialect and returns its tree.
+func NewTree(input string, dialect lexer.Dialect) (*Tree, error) {
+	tokens := tokenize(lexer.NewWithDialect(input, dialect), input, 0, nil)
+	root, err := build(tokens, dialect, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newTree(root, input, dialect, tokens), nil
+}
+
+// newTree assembles a Tree and numbers its tokens.
+func newTree(root *Node, text string, dialect lexer.Dialect, tokens []*Token) *Tree {
+	for i, tok := range tokens {
+		tok.index = i
+	}
+	return &Tree{Root: root, text: text, dialect: dialect, tokens: tokens}
+}
+
+// Text returns the source text of the tree.
+func (t *Tree) Text() string {
+	return t.text
+}
+
+// Apply returns the tree of the text with edit applied. Only the edited
+// region is re-lexed: tokens before it are kept, lexing stops as soon as it
+// lines up with the old tokens again, and the remaining tokens are shifted
+// into place. Value nodes made only of kept tokens are reused as they are.
+// The result is the same as parsing the new text with NewTree.
+//
+// Apply takes ownership of t: its nodes and tokens are shared with and
+// updated for the new tree, so t must not be used once Apply succeeds. If
+// the new text does not parse, the changes are undone and t stays valid,
+// so a caller can keep the last tree that parsed.
+func (t *Tree) Apply(edit Edit) (*Tree, error) {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(t.text) {
+		return nil, fmt.Errorf("edit range [%d, %d) out of bounds for %d bytes", edit.Start, edit.End, len(t.text))
+	}
+
+	text := t.text[:edit.Start] + edit.Text + t.text[edit.End:]
+	delta := len(edit.Text) - (edit.End - edit.Start)
+	newEnd := edit.Start + len(edit.Text)
+
+	// Keep the tokens ending strictly before the edit. An identifier-like
+	// token is re-lexed too, since the lexer looks past it for a colon.
+	prefixLen := sort.Search(len(t.tokens), func(i int) bool { return t.tokens[i].End() >= edit.Start })
+	if prefixLen > 0 && isIdentifierLike(t.tokens[prefixLen-1]) {
+		prefixLen--
+	}
+	relexStart := 0
+	if prefixLen > 0 {
+		relexStart = t.tokens[prefixLen-1].End()
+	}
+
+	// Re-lex until a new token and its trivia line up with an old token
+	// after the edit; from there on the token streams are identical.
+	suffixStart := len(t.tokens)
+	l := lexer.NewWithDialect(text, t.dialect)
+	l.Seek(relexStart)
+	fresh := tokenize(l, text, relexStart, func(tok lexer.Token, prevEnd int) bool {
+		if prevEnd < newEnd {
+			return false
+		}
+		j := sort.Search(len(t.tokens), func(i int) bool { return t.tokens[i].Offset >= tok.Offset-delta })
+		if j < prefixLen || j >= len(t.tokens) {
+			return false
+		}
+		old := t.tokens[j]
+		if old.Offset != tok.Offset-delta || old.Offset-len(old.Leading) != prevEnd-delta || old.Type != tok.Type {
+			return false
+		}
+		suffixStart = j
+		return true
+	})
+	for _, tok := range fresh {
+		tok.index = -1
+	}
+
+	// Shift the kept tokens after the edit to their new positions.
+	shiftTokens(t.tokens[suffixStart:], t.text, text, edit.End, newEnd, delta)
+
+	tokens := make([]*Token, 0, prefixLen+len(fresh)+len(t.tokens)-suffixStart)
+	tokens = append(tokens, t.tokens[:prefixLen]...)
+	tokens = append(tokens, fresh...)
+	tokens = append(tokens, t.tokens[suffixStart:]...)
+
+	// Old value nodes can be reused if all their tokens were kept on the
+	// same side of the edit.
+	suffixShift := prefixLen + len(fresh) - suffixStart
+	reuse := func(n *Node) (int, bool) {
+		first, last := n.firstToken(), n.lastToken()
+		switch {
+		case first.index >= 0 && last.index < prefixLen:
+			return last.index + 1, true
+		case first.index >= suffixStart && last.index >= suffixStart:
+			return last.index + suffixShift + 1, true
+		default:
+			return 0, false
+		}
+	}
+
+	root, err := build(tokens, t.dialect, reuse)
+	if err != nil {
+		shiftTokens(t.tokens[suffixStart:], text, t.text, newEnd, edit.End, -delta)
+		return nil, err
+	}
+	return newTree(root, text, t.dialect, tokens), nil
+}
+
+// shiftTokens moves tokens that followed the old edit end to their place
+// after the new edit end, adjusting columns of tokens on the same line.
+func shiftTokens(tokens []*Token, oldText, newText string, oldEnd, newEnd, delta int) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	endLine := 1 + strings.Count(oldText[:oldEnd], "\n")
+	lineDelta := strings.Count(newText[:newEnd], "\n") + 1 - endLine
+	columnDelta := (newEnd - strings.LastIndexByte(newText[:newEnd], '\n')) - (oldEnd - strings.LastIndexByte(oldText[:oldEnd], '\n'))
+
+	for _, tok := range tokens {
+		if tok.Line == endLine {
+			tok.Column += columnDelta
+		}
+		tok.Line += lineDelta
+		tok.Offset += delta
+	}
+}
+
+// isIdentifierLike reports whether the lexer decided the token's type by
+// looking beyond its end.
+func isIdentifierLike(tok *Token) bool {
+	switch tok.Type {
+	case lexer.IDENT, lexer.TRUE, lexer.FALSE, lexer.NULL:
+		return true
+	case lexer.NUMBER:
+		return tok.Text == "Infinity" || tok.Text == "NaN"
+	default:
+		return false
+	}
+}
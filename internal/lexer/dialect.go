@@ -0,0 +1,96 @@
+package lexer
+
+import "fmt"
+
+// Dialect selects the flavour of JSON accepted by the lexer and parser.
+type Dialect int
+
+// Dialect constants define the supported JSON grammars.
+const (
+	Strict Dialect = iota // RFC 8259 JSON, the default
+	JSON5                 // JSON5 (https://spec.json5.org)
+	JSONC                 // JSON with comments and trailing commas, as in VS Code settings
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	switch d {
+	case Strict:
+		return "strict"
+	case JSON5:
+		return "json5"
+	case JSONC:
+		return "jsonc"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// ParseDialect maps a dialect name, as accepted on the command line, to a Dialect.
+func ParseDialect(name string) (Dialect, error) {
+	switch name {
+	case "", "strict", "json":
+		return Strict, nil
+	case "json5":
+		return JSON5, nil
+	case "jsonc":
+		return JSONC, nil
+	default:
+		return Strict, fmt.Errorf("unknown dialect %q", name)
+	}
+}
+
+// ExtensionKind names a JSON5 feature that is not part of RFC 8259.
+type ExtensionKind string
+
+// ExtensionKind constants list the JSON5 extensions recognised by the lexer.
+const (
+	ExtLineComment          ExtensionKind = "line comment"
+	ExtBlockComment         ExtensionKind = "block comment"
+	ExtTrailingComma        ExtensionKind = "trailing comma"
+	ExtSingleQuotedString   ExtensionKind = "single-quoted string"
+	ExtIdentifierKey        ExtensionKind = "unquoted key"
+	ExtHexNumber            ExtensionKind = "hexadecimal number"
+	ExtLeadingDecimalPoint  ExtensionKind = "leading decimal point"
+	ExtTrailingDecimalPoint ExtensionKind = "trailing decimal point"
+	ExtExplicitPlusSign     ExtensionKind = "explicit plus sign"
+	ExtInfinity             ExtensionKind = "Infinity"
+	ExtNaN                  ExtensionKind = "NaN"
+	ExtLineContinuation     ExtensionKind = "escaped line continuation"
+	ExtCharacterEscape      ExtensionKind = "JSON5 escape sequence"
+)
+
+// allows reports whether the dialect accepts the given extension. JSONC only
+// adds comments and trailing commas to strict JSON.
+func (d Dialect) allows(kind ExtensionKind) bool {
+	switch d {
+	case JSON5:
+		return true
+	case JSONC:
+		return kind == ExtLineComment || kind == ExtBlockComment || kind == ExtTrailingComma
+	default:
+		return false
+	}
+}
+
+// Extension records a use of a JSON5 extension at a position in the input.
+type Extension struct {
+	Kind   ExtensionKind // Which extension was used
+	Line   int           // Line number where the extension appears
+	Column int           // Column number where the extension appears
+}
+
+// String formats the extension as "line L, column C: kind".
+func (e Extension) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Kind)
+}
+
+// ScanExtensions tokenizes the input as JSON5 and returns every extension
+// it uses, in input order. It is used to explain why a JSON5 document was
+// rejected in strict mode.
+func ScanExtensions(input string) []Extension {
+	l := NewWithDialect(input, JSON5)
+	for tok := l.NextToken(); tok.Type != EOF; tok = l.NextToken() {
+	}
+	return l.Extensions()
+}
@@ -1,26 +1,62 @@
 package lexer
 
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
 // Lexer tokenizes input string for parsing.
 type Lexer struct {
-	input        string // The input being tokenized.
-	position     int    // Current position in the input (points to the current char)
-	readPosition int    // Next position to read from input
-	ch           byte   // Current character being examined
-	line         int    // Current line in the input
-	column       int    // Current column in the input
+	input        string      // The input being tokenized.
+	position     int         // Current position in the input (points to the current char)
+	readPosition int         // Next position to read from input
+	ch           byte        // Current character being examined
+	line         int         // Current line in the input
+	column       int         // Current column in the input
+	dialect      Dialect     // Grammar accepted by the lexer
+	extensions   []Extension // JSON5 extensions encountered so far
+	start        int         // Offset where the token being scanned starts
 }
 
-// New initializes and returns a new lexer instance.
+// New initializes and returns a new lexer instance for strict RFC 8259 JSON.
 func New(input string) *Lexer {
+	return NewWithDialect(input, Strict)
+}
+
+// NewWithDialect initializes and returns a new lexer instance for the given dialect.
+func NewWithDialect(input string, dialect Dialect) *Lexer {
 	l := &Lexer{
-		input:  input,
-		line:   1,
-		column: 0,
+		input:   input,
+		line:    1,
+		column:  0,
+		dialect: dialect,
 	}
 	l.readChar() // Initialize the first character
 	return l
 }
 
+// Dialect returns the dialect the lexer was created with.
+func (l *Lexer) Dialect() Dialect {
+	return l.dialect
+}
+
+// Extensions returns the JSON5 extensions encountered so far, in input order.
+// In strict mode the first one also caused an ILLEGAL token.
+func (l *Lexer) Extensions() []Extension {
+	return l.extensions
+}
+
+// Seek positions the lexer at offset, as if the input before it had already
+// been tokenized. It is used to re-lex part of an edited document.
+func (l *Lexer) Seek(offset int) {
+	offset = max(0, min(offset, len(l.input)))
+	l.readPosition = offset
+	l.line = 1 + strings.Count(l.input[:offset], "\n")
+	l.column = offset - 1 - strings.LastIndexByte(l.input[:offset], '\n')
+	l.readChar()
+}
+
 // readChar advances the lexer to the next character in the input.
 func (l *Lexer) readChar() {
 	if l.readPosition >= len(l.input) {
@@ -40,24 +76,64 @@ func (l *Lexer) readChar() {
 	}
 }
 
+// peekChar returns the character after the current one without consuming it.
+func (l *Lexer) peekChar() byte {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition]
+}
+
+// atEOF reports whether the lexer has consumed the whole input.
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
 // NextToken extracts the next token from the input.
 func (l *Lexer) NextToken() Token {
+	tok := l.scanToken()
+	tok.Offset = min(l.start, len(l.input))
+	tok.End = min(l.position, len(l.input))
+	return tok
+}
+
+// scanToken scans the next token; NextToken fills in its offsets.
+func (l *Lexer) scanToken() Token {
 	var tok Token
 
-	l.skipWhitespace()
+	for {
+		l.skipWhitespace()
+		l.start = l.position
+		if l.ch != '/' || (l.peekChar() != '/' && l.peekChar() != '*') {
+			break
+		}
+		if tok, ok := l.skipComment(); !ok || l.dialect == JSONC {
+			return tok
+		}
+	}
 
 	switch l.ch {
-	case '{', '}', '[', ']', ':', ',':
+	case '{', '}', '[', ']', ':':
 		tok = l.makeSingleCharToken()
-	case '"':
-		tok = l.readString()
+	case ',':
+		tok = l.makeSingleCharToken()
+		if next := l.significantByteFrom(l.readPosition); next == '}' || next == ']' {
+			// The parser decides whether a trailing comma is acceptable.
+			l.useExtension(ExtTrailingComma, tok.Line, tok.Column)
+		}
+	case '"', '\'':
+		tok = l.readString(l.ch)
 	case 0:
+		if !l.atEOF() {
+			tok = l.newToken(ILLEGAL, string(l.ch))
+			break
+		}
 		tok = l.newToken(EOF, "")
 	default:
-		if isLetter(l.ch) {
+		if isIdentStart(l.ch) {
 			return l.readIdentifier()
-		} else if isDigit(l.ch) {
-			return l.newToken(NUMBER, l.readNumber())
+		} else if isDigit(l.ch) || l.ch == '-' || l.ch == '+' || l.ch == '.' {
+			return l.readNumber()
 		} else {
 			tok = l.newToken(ILLEGAL, string(l.ch))
 		}
@@ -68,65 +144,395 @@ func (l *Lexer) NextToken() Token {
 }
 
 // skipWhitespace skips over spaces, tabs, and newlines in the input.
+// JSON5 additionally treats vertical tabs and form feeds as whitespace.
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for isWhitespace(l.ch) || (l.dialect == JSON5 && (l.ch == '\v' || l.ch == '\f')) {
 		l.readChar()
 	}
 }
 
+// skipComment consumes a // or /* */ comment starting at the current character
+// and returns it as a COMMENT token. It returns false together with an ILLEGAL
+// token when comments are not allowed or the comment is unterminated.
+func (l *Lexer) skipComment() (Token, bool) {
+	line, column := l.line, l.column
+	start := l.position
+	kind := ExtLineComment
+	if l.peekChar() == '*' {
+		kind = ExtBlockComment
+	}
+	allowed := l.useExtension(kind, line, column)
+
+	l.readChar()
+	if kind == ExtLineComment {
+		for l.ch != '\n' && !l.atEOF() {
+			l.readChar()
+		}
+	} else {
+		l.readChar()
+		for !(l.ch == '*' && l.peekChar() == '/') {
+			if l.atEOF() {
+				return l.illegalAt("Unterminated block comment", line, column), false
+			}
+			l.readChar()
+		}
+		l.readChar()
+		l.readChar()
+	}
+
+	if !allowed {
+		return l.extensionError(kind, line, column), false
+	}
+	return Token{Type: COMMENT, Literal: l.input[start:l.position], Line: line, Column: column}, true
+}
+
 // makeSingleCharToken creates tokens for single-character symbols.
 func (l *Lexer) makeSingleCharToken() Token {
 	tokenType := singleCharTokenType(l.ch)
 	return l.newToken(tokenType, string(l.ch))
 }
 
-// readString reads a string literal, handling any errors.
-func (l *Lexer) readString() Token {
+// readString reads a string literal delimited by quote, decoding escape
+// sequences and handling any errors.
+func (l *Lexer) readString(quote byte) Token {
 	startLine, startColumn := l.line, l.column
-	start := l.position + 1
+	var rejected *Extension
 
+	if quote == '\'' && !l.useExtension(ExtSingleQuotedString, startLine, startColumn) {
+		rejected = &Extension{Kind: ExtSingleQuotedString, Line: startLine, Column: startColumn}
+	}
+
+	var sb strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
+		if l.atEOF() {
+			return l.illegalAt("Unterminated string", startLine, startColumn)
+		}
+		if l.ch == quote {
 			break
 		}
+		if l.ch < 0x20 && (l.dialect != JSON5 || l.ch == '\n' || l.ch == '\r') {
+			return l.illegalAt("Invalid control character in string", l.line, l.column)
+		}
+		if l.ch != '\\' {
+			sb.WriteByte(l.ch)
+			continue
+		}
+
+		escLine, escColumn := l.line, l.column
+		l.readChar()
+		kind, ok := l.readEscape(&sb)
+		if !ok {
+			return l.illegalAt("Invalid escape sequence", escLine, escColumn)
+		}
+		if kind != "" && !l.useExtension(kind, escLine, escColumn) && rejected == nil {
+			rejected = &Extension{Kind: kind, Line: escLine, Column: escColumn}
+		}
 	}
 
-	if l.ch == 0 {
-		return Token{
-			Type:    ILLEGAL,
-			Literal: "Unterminated string",
-			Line:    startLine,
-			Column:  startColumn,
+	if rejected != nil {
+		return l.extensionError(rejected.Kind, rejected.Line, rejected.Column)
+	}
+	return Token{
+		Type:    STRING,
+		Literal: sb.String(),
+		Line:    startLine,
+		Column:  startColumn,
+	}
+}
+
+// readEscape decodes the escape sequence whose first character (after the
+// backslash) is current, leaving the lexer on its last character. It returns
+// the JSON5 extension the escape relies on, if any, and false if the escape
+// is invalid in every dialect.
+func (l *Lexer) readEscape(sb *strings.Builder) (ExtensionKind, bool) {
+	switch l.ch {
+	case '"', '\\', '/':
+		sb.WriteByte(l.ch)
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'u':
+		r, ok := l.readUnicodeEscape()
+		if !ok {
+			return "", false
+		}
+		sb.WriteRune(r)
+	case '\n':
+		return ExtLineContinuation, true
+	case '\r':
+		if l.peekChar() == '\n' {
+			l.readChar()
+		}
+		return ExtLineContinuation, true
+	case '\'':
+		sb.WriteByte('\'')
+		return ExtCharacterEscape, true
+	case 'v':
+		sb.WriteByte('\v')
+		return ExtCharacterEscape, true
+	case '0':
+		if isDigit(l.peekChar()) {
+			return "", false
+		}
+		sb.WriteByte(0)
+		return ExtCharacterEscape, true
+	case 'x':
+		hi, lo := l.peekChar(), byte(0)
+		if l.readPosition+1 < len(l.input) {
+			lo = l.input[l.readPosition+1]
 		}
+		if !isHexDigit(hi) || !isHexDigit(lo) {
+			return "", false
+		}
+		l.readChar()
+		l.readChar()
+		sb.WriteRune(rune(hexValue(hi)<<4 | hexValue(lo)))
+		return ExtCharacterEscape, true
+	default:
+		if l.atEOF() || isDigit(l.ch) {
+			return "", false
+		}
+		if strings.HasPrefix(l.input[l.position:], "\u2028") || strings.HasPrefix(l.input[l.position:], "\u2029") {
+			l.readChar()
+			l.readChar()
+			return ExtLineContinuation, true
+		}
+		// In JSON5 any other character escapes to itself.
+		sb.WriteByte(l.ch)
+		return ExtCharacterEscape, true
 	}
+	return "", true
+}
 
-	return l.newToken(STRING, l.input[start:l.position])
+// readUnicodeEscape decodes a \uXXXX escape, combining UTF-16 surrogate pairs.
+// The lexer is on the 'u' on entry and on the last hex digit on exit.
+func (l *Lexer) readUnicodeEscape() (rune, bool) {
+	r, ok := l.readHex4()
+	if !ok {
+		return 0, false
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, true
+	}
+	if strings.HasPrefix(l.input[l.readPosition:], `\u`) {
+		l.readChar()
+		l.readChar()
+		low, ok := l.readHex4()
+		if !ok {
+			return 0, false
+		}
+		if decoded := utf16.DecodeRune(r, low); decoded != utf8.RuneError {
+			return decoded, true
+		}
+	}
+	return utf8.RuneError, true
+}
+
+// readHex4 reads the four hex digits following the current character.
+func (l *Lexer) readHex4() (rune, bool) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		l.readChar()
+		if !isHexDigit(l.ch) {
+			return 0, false
+		}
+		r = r<<4 | hexValue(l.ch)
+	}
+	return r, true
 }
 
-// readNumber reads a numeric literal.
-func (l *Lexer) readNumber() string {
+// readNumber reads a numeric literal. In strict mode only the RFC 8259
+// grammar is accepted; JSON5 additionally allows hexadecimal integers,
+// leading or trailing decimal points, an explicit plus sign, Infinity and NaN.
+func (l *Lexer) readNumber() Token {
+	line, column := l.line, l.column
 	start := l.position
-	decimalSeen := false
+	var used []ExtensionKind
+
+	if l.ch == '+' {
+		used = append(used, ExtExplicitPlusSign)
+	}
+	if l.ch == '+' || l.ch == '-' {
+		l.readChar()
+	}
+
+	switch {
+	case isIdentStart(l.ch):
+		switch l.readWord() {
+		case "Infinity":
+			used = append(used, ExtInfinity)
+		case "NaN":
+			used = append(used, ExtNaN)
+		default:
+			return l.illegalAt("Invalid number", line, column)
+		}
+	case l.ch == '0' && (l.peekChar() == 'x' || l.peekChar() == 'X'):
+		used = append(used, ExtHexNumber)
+		l.readChar()
+		l.readChar()
+		if !isHexDigit(l.ch) {
+			return l.illegalAt("Invalid hexadecimal number", line, column)
+		}
+		for isHexDigit(l.ch) {
+			l.readChar()
+		}
+	default:
+		intDigits := false
+		if l.ch == '0' {
+			l.readChar()
+			if isDigit(l.ch) {
+				return l.illegalAt("Leading zeros are not allowed", line, column)
+			}
+			intDigits = true
+		} else if isDigit(l.ch) {
+			l.readDigits()
+			intDigits = true
+		}
 
-	for isDigit(l.ch) || (l.ch == '.' && !decimalSeen) {
 		if l.ch == '.' {
-			decimalSeen = true
+			l.readChar()
+			switch {
+			case isDigit(l.ch):
+				if !intDigits {
+					used = append(used, ExtLeadingDecimalPoint)
+				}
+				l.readDigits()
+			case intDigits:
+				used = append(used, ExtTrailingDecimalPoint)
+			default:
+				return l.illegalAt("Invalid number", line, column)
+			}
+		} else if !intDigits {
+			return l.illegalAt("Invalid number", line, column)
 		}
+
+		if l.ch == 'e' || l.ch == 'E' {
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			if !isDigit(l.ch) {
+				return l.illegalAt("Invalid number exponent", line, column)
+			}
+			l.readDigits()
+		}
+	}
+
+	allowed := true
+	for _, kind := range used {
+		allowed = l.useExtension(kind, line, column) && allowed
+	}
+	if !allowed {
+		return l.extensionError(used[0], line, column)
+	}
+
+	return Token{
+		Type:    NUMBER,
+		Literal: l.input[start:l.position],
+		Line:    line,
+		Column:  column,
+	}
+}
+
+// readDigits consumes a run of decimal digits.
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+}
+
+// readWord consumes an identifier-like run of characters and returns it.
+func (l *Lexer) readWord() string {
+	start := l.position
+	for isIdentPart(l.ch) {
 		l.readChar()
 	}
 	return l.input[start:l.position]
 }
 
 // readIdentifier reads an identifier or keyword and returns the appropriate token.
+// An identifier directly followed by a colon is an unquoted object key.
 func (l *Lexer) readIdentifier() Token {
-	start := l.position
-	for isLetter(l.ch) {
-		l.readChar()
+	line, column := l.line, l.column
+	ident := l.readWord()
+
+	if l.significantByteFrom(l.position) == ':' {
+		if !l.useExtension(ExtIdentifierKey, line, column) {
+			return l.extensionError(ExtIdentifierKey, line, column)
+		}
+		return Token{Type: IDENT, Literal: ident, Line: line, Column: column}
 	}
-	ident := l.input[start:l.position]
+
 	tokenType := lookupKeyword(ident)
-	return l.newToken(tokenType, ident)
+	switch ident {
+	case "Infinity", "NaN":
+		kind := ExtInfinity
+		if ident == "NaN" {
+			kind = ExtNaN
+		}
+		if !l.useExtension(kind, line, column) {
+			return l.extensionError(kind, line, column)
+		}
+		tokenType = NUMBER
+	}
+	return Token{Type: tokenType, Literal: ident, Line: line, Column: column}
+}
+
+// significantByteFrom returns the first byte at or after offset i that is not
+// whitespace or part of a comment, or 0 at the end of input.
+func (l *Lexer) significantByteFrom(i int) byte {
+	for i < len(l.input) {
+		c := l.input[i]
+		switch {
+		case isWhitespace(c):
+			i++
+		case strings.HasPrefix(l.input[i:], "//"):
+			end := strings.IndexByte(l.input[i:], '\n')
+			if end < 0 {
+				return 0
+			}
+			i += end
+		case strings.HasPrefix(l.input[i:], "/*"):
+			end := strings.Index(l.input[i+2:], "*/")
+			if end < 0 {
+				return 0
+			}
+			i += end + 4
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// useExtension records a JSON5 extension at the given position and reports
+// whether the lexer's dialect accepts it.
+func (l *Lexer) useExtension(kind ExtensionKind, line, column int) bool {
+	l.extensions = append(l.extensions, Extension{Kind: kind, Line: line, Column: column})
+	return l.dialect.allows(kind)
+}
+
+// extensionError returns the ILLEGAL token produced for a JSON5 extension in strict mode.
+func (l *Lexer) extensionError(kind ExtensionKind, line, column int) Token {
+	return l.illegalAt(string(kind)+" is a JSON5 extension", line, column)
+}
+
+// illegalAt creates an ILLEGAL token describing an error at the given position.
+func (l *Lexer) illegalAt(message string, line, column int) Token {
+	return Token{
+		Type:    ILLEGAL,
+		Literal: message,
+		Line:    line,
+		Column:  column,
+	}
 }
 
 // newToken creates a new Token with the current lexer state.
@@ -159,16 +565,49 @@ func singleCharTokenType(ch byte) TokenType {
 	}
 }
 
+// isWhitespace checks if a character is JSON insignificant whitespace.
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
 // isDigit checks if a character is a digit.
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// isHexDigit checks if a character is a hexadecimal digit.
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+// hexValue returns the numeric value of a hexadecimal digit.
+func hexValue(ch byte) rune {
+	switch {
+	case isDigit(ch):
+		return rune(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return rune(ch-'a') + 10
+	default:
+		return rune(ch-'A') + 10
+	}
+}
+
 // isLetter checks if a character is a letter (a-z or A-Z).
 func isLetter(ch byte) bool {
 	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
 }
 
+// isIdentStart checks if a character can start an identifier. Bytes of
+// multi-byte UTF-8 sequences are accepted so that JSON5 keys may be Unicode.
+func isIdentStart(ch byte) bool {
+	return isLetter(ch) || ch == '_' || ch == '$' || ch >= utf8.RuneSelf
+}
+
+// isIdentPart checks if a character can continue an identifier.
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch)
+}
+
 // lookupKeyword determines if an identifier matches a keyword.
 func lookupKeyword(ident string) TokenType {
 	switch ident {
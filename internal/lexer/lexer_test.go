@@ -150,3 +150,190 @@ func TestNextToken_ValidCharacters(t *testing.T) {
 		}
 	}
 }
+
+func TestNextToken_JSON5(t *testing.T) {
+	// Define an input exercising every JSON5 extension
+	input := "{\n// comment\n/* block */ key: 'single', hex: 0x1F, lead: .5, trail: 5., plus: +1, inf: -Infinity, nan: NaN, cont: \"a\\\nb\",}"
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+	}{
+		{LBRACE, "{"},         // Start of object
+		{IDENT, "key"},        // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{STRING, "single"},    // Single-quoted string
+		{COMMA, ","},          // Comma separator
+		{IDENT, "hex"},        // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, "0x1F"},      // Hexadecimal number
+		{COMMA, ","},          // Comma separator
+		{IDENT, "lead"},       // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, ".5"},        // Leading decimal point
+		{COMMA, ","},          // Comma separator
+		{IDENT, "trail"},      // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, "5."},        // Trailing decimal point
+		{COMMA, ","},          // Comma separator
+		{IDENT, "plus"},       // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, "+1"},        // Explicit plus sign
+		{COMMA, ","},          // Comma separator
+		{IDENT, "inf"},        // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, "-Infinity"}, // Infinity
+		{COMMA, ","},          // Comma separator
+		{IDENT, "nan"},        // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{NUMBER, "NaN"},       // NaN
+		{COMMA, ","},          // Comma separator
+		{IDENT, "cont"},       // Unquoted key
+		{COLON, ":"},          // Colon separator
+		{STRING, "ab"},        // Escaped line continuation
+		{COMMA, ","},          // Trailing comma
+		{RBRACE, "}"},         // End of object
+		{EOF, ""},             // End of input
+	}
+
+	lexer := NewWithDialect(input, JSON5)
+
+	for i, tt := range tests {
+		tok := lexer.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %q %q, got %q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	// Every kind of extension, including the trailing comma, should have been recorded
+	kinds := make(map[ExtensionKind]bool)
+	for _, ext := range lexer.Extensions() {
+		kinds[ext.Kind] = true
+	}
+	if len(kinds) != 12 {
+		t.Errorf("expected 12 extension kinds, got %d: %v", len(kinds), lexer.Extensions())
+	}
+}
+
+func TestNextToken_StrictRejectsExtensions(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ExtensionKind
+	}{
+		{"// comment", ExtLineComment},
+		{"/* comment */", ExtBlockComment},
+		{"'single'", ExtSingleQuotedString},
+		{"key: 1", ExtIdentifierKey},
+		{"0x1F", ExtHexNumber},
+		{".5", ExtLeadingDecimalPoint},
+		{"5.", ExtTrailingDecimalPoint},
+		{"+1", ExtExplicitPlusSign},
+		{"Infinity", ExtInfinity},
+		{"NaN", ExtNaN},
+		{"\"a\\\nb\"", ExtLineContinuation},
+	}
+
+	for _, tt := range tests {
+		lexer := New(tt.input)
+		tok := lexer.NextToken()
+		if tok.Type != ILLEGAL {
+			t.Errorf("input %q: expected ILLEGAL, got %q (literal=%q)", tt.input, tok.Type, tok.Literal)
+			continue
+		}
+		if exts := lexer.Extensions(); len(exts) == 0 || exts[0].Kind != tt.kind {
+			t.Errorf("input %q: expected extension %q, got %v", tt.input, tt.kind, exts)
+		}
+	}
+}
+
+func TestNextToken_StrictStringsAndNumbers(t *testing.T) {
+	// Define RFC 8259 strings and numbers that need escape decoding or signs
+	input := `["a\"b\\cé😀", -12.5e+3, 0, "\/"]`
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+	}{
+		{LBRACKET, "["},
+		{STRING, "a\"b\\cé\U0001F600"},
+		{COMMA, ","},
+		{NUMBER, "-12.5e+3"},
+		{COMMA, ","},
+		{NUMBER, "0"},
+		{COMMA, ","},
+		{STRING, "/"},
+		{RBRACKET, "]"},
+		{EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		tok := lexer.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %q %q, got %q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	// Leading zeros and bad escapes are errors in every dialect
+	for _, bad := range []string{"01", `"\q"`, "1e", "-"} {
+		if tok := New(bad).NextToken(); tok.Type != ILLEGAL {
+			t.Errorf("input %q: expected ILLEGAL, got %q (literal=%q)", bad, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNextToken_JSONCComments(t *testing.T) {
+	// Comments are emitted as tokens in JSONC mode
+	input := "{ // line\n\"a\": /* block */ 1,}"
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+	}{
+		{LBRACE, "{"},
+		{COMMENT, "// line"},
+		{STRING, "a"},
+		{COLON, ":"},
+		{COMMENT, "/* block */"},
+		{NUMBER, "1"},
+		{COMMA, ","},
+		{RBRACE, "}"},
+		{EOF, ""},
+	}
+
+	lexer := NewWithDialect(input, JSONC)
+
+	for i, tt := range tests {
+		tok := lexer.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %q %q, got %q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	// Other JSON5 extensions remain errors in JSONC
+	if tok := NewWithDialect("'single'", JSONC).NextToken(); tok.Type != ILLEGAL {
+		t.Errorf("expected ILLEGAL for single-quoted string in JSONC, got %q", tok.Type)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\": 2\n}"
+
+	// Seeking to the start of the second member yields the same tokens as a full scan
+	full := New(input)
+	var expected []Token
+	for tok := full.NextToken(); tok.Type != EOF; tok = full.NextToken() {
+		if tok.Offset >= 11 {
+			expected = append(expected, tok)
+		}
+	}
+
+	lexer := New(input)
+	lexer.Seek(11)
+	for i, want := range expected {
+		if got := lexer.NextToken(); got != want {
+			t.Fatalf("tokens[%d] - expected %+v, got %+v", i, want, got)
+		}
+	}
+}
@@ -17,6 +17,9 @@ const (
 
 	STRING TokenType = "STRING" // String literal
 	NUMBER TokenType = "NUMBER" // Numeric literal
+	IDENT  TokenType = "IDENT"  // Unquoted identifier (JSON5 object keys)
+
+	COMMENT TokenType = "COMMENT" // Comment, only emitted in JSONC mode
 
 	TRUE  TokenType = "TRUE"  // Boolean literal: true
 	FALSE TokenType = "FALSE" // Boolean literal: false
@@ -29,4 +32,6 @@ type Token struct {
 	Literal string    // The literal value of the token
 	Line    int       // Line number where the token appears
 	Column  int       // Column number where the token appears
+	Offset  int       // Byte offset where the token starts
+	End     int       // Byte offset just past the end of the token
 }
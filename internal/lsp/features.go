@@ -0,0 +1,223 @@
+package lsp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/cst"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// location describes what lies under an offset in the syntax tree.
+type location struct {
+	path   []string  // Path of keys and array indices from the root
+	node   *cst.Node // Innermost node containing the offset
+	member *cst.Node // Enclosing member when the offset is on a member's key or colon
+	object *cst.Node // Innermost object containing the offset
+	inKey  bool      // Whether the offset is on the member's key
+}
+
+// locate finds the innermost node of tree containing offset.
+func locate(tree *cst.Node, offset int) location {
+	loc := location{node: tree.Value()}
+	for {
+		var next *cst.Node
+		switch loc.node.Kind {
+		case cst.ObjectNode:
+			loc.object = loc.node
+			for _, member := range loc.node.Nodes() {
+				if !contains(member, offset) {
+					continue
+				}
+				key := member.Key()
+				loc.path = append(loc.path, key.Value)
+				loc.member = member
+				if offset >= key.Offset && offset <= key.End() {
+					loc.node, loc.inKey = member, true
+					return loc
+				}
+				if !contains(member.Value(), offset) {
+					loc.node = member
+					return loc
+				}
+				loc.member = nil
+				next = member.Value()
+			}
+		case cst.ArrayNode:
+			for i, elem := range loc.node.Nodes() {
+				if contains(elem, offset) {
+					loc.path = append(loc.path, strconv.Itoa(i))
+					next = elem
+				}
+			}
+		}
+		if next == nil {
+			return loc
+		}
+		loc.node = next
+	}
+}
+
+// nodeAt returns the node of tree at path, or the member holding it when
+// the path ends in a key, so that a bad key is covered too. It returns nil
+// if tree is nil or has no such node.
+func nodeAt(tree *cst.Node, path parser.Path) *cst.Node {
+	if tree == nil {
+		return nil
+	}
+	node, member := tree.Value(), (*cst.Node)(nil)
+	for _, seg := range path {
+		var next *cst.Node
+		member = nil
+		switch {
+		case seg.IsIndex() && node.Kind == cst.ArrayNode:
+			if elements := node.Nodes(); seg.Index < len(elements) {
+				next = elements[seg.Index]
+			}
+		case !seg.IsIndex() && node.Kind == cst.ObjectNode:
+			for _, m := range node.Nodes() {
+				if m.Key().Value == seg.Key {
+					member, next = m, m.Value() // The last duplicate wins, as in the AST
+				}
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	if member != nil {
+		return member
+	}
+	return node
+}
+
+// contains reports whether offset lies within the node or at its end.
+func contains(n *cst.Node, offset int) bool {
+	start, end := n.Span()
+	return offset >= start && offset <= end
+}
+
+// jsonPointer formats a path as an RFC 6901 JSON Pointer.
+func jsonPointer(path []string) string {
+	var sb strings.Builder
+	for _, segment := range path {
+		sb.WriteByte('/')
+		sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(segment))
+	}
+	return sb.String()
+}
+
+// symbols returns the outline of a document: one symbol per object member
+// and array element, nested like the document.
+func (s *Server) symbols(doc *document) []DocumentSymbol {
+	if doc == nil || doc.tree == nil {
+		return nil
+	}
+	return childSymbols(doc, doc.tree.Value())
+}
+
+// childSymbols returns the symbols for the members or elements of a container.
+func childSymbols(doc *document, n *cst.Node) []DocumentSymbol {
+	var symbols []DocumentSymbol
+	switch n.Kind {
+	case cst.ObjectNode:
+		for _, member := range n.Nodes() {
+			key := member.Key()
+			symbols = append(symbols, valueSymbol(doc, key.Value, member.Value(), member, doc.lines.rangeOf(key.Offset, key.End())))
+		}
+	case cst.ArrayNode:
+		for i, elem := range n.Nodes() {
+			start, end := elem.Span()
+			symbols = append(symbols, valueSymbol(doc, strconv.Itoa(i), elem, elem, doc.lines.rangeOf(start, end)))
+		}
+	}
+	return symbols
+}
+
+// valueSymbol builds the symbol for a value spanning the given node.
+func valueSymbol(doc *document, name string, value, span *cst.Node, selection Range) DocumentSymbol {
+	start, end := span.Span()
+	symbol := DocumentSymbol{
+		Name:           name,
+		Range:          doc.lines.rangeOf(start, end),
+		SelectionRange: selection,
+		Children:       childSymbols(doc, value),
+	}
+
+	switch value.Kind {
+	case cst.ObjectNode:
+		symbol.Kind = SymbolKindObject
+	case cst.ArrayNode:
+		symbol.Kind = SymbolKindArray
+	default:
+		tok := value.Tokens()[0]
+		symbol.Detail = tok.Text
+		switch tok.Type {
+		case lexer.STRING:
+			symbol.Kind = SymbolKindString
+		case lexer.NUMBER:
+			symbol.Kind = SymbolKindNumber
+		case lexer.TRUE, lexer.FALSE:
+			symbol.Kind = SymbolKindBoolean
+		default:
+			symbol.Kind = SymbolKindNull
+		}
+	}
+	return symbol
+}
+
+// hover shows the JSON Pointer of the node under the cursor, followed by
+// the schema description of that location when a schema is associated.
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	if doc == nil || doc.tree == nil {
+		return nil
+	}
+
+	loc := locate(doc.tree, doc.lines.offset(pos))
+	start, end := loc.node.Span()
+	if loc.inKey {
+		key := loc.member.Key()
+		start, end = key.Offset, key.End()
+	}
+
+	pointer := jsonPointer(loc.path)
+	text := "`" + pointer + "`"
+	if pointer == "" {
+		text = "`\"\"` (document root)"
+	}
+	if schema := s.schemaFor(doc); schema != nil {
+		if description := schema.describe(loc.path); description != "" {
+			text += "\n\n" + description
+		}
+	}
+
+	r := doc.lines.rangeOf(start, end)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: &r}
+}
+
+// foldingRanges returns a range for every object and array spanning several
+// lines, leaving the closing bracket visible.
+func (s *Server) foldingRanges(doc *document) []FoldingRange {
+	if doc == nil || doc.tree == nil {
+		return nil
+	}
+
+	var ranges []FoldingRange
+	var walk func(n *cst.Node)
+	walk = func(n *cst.Node) {
+		if n.Kind == cst.ObjectNode || n.Kind == cst.ArrayNode {
+			start, end := n.Span()
+			startLine, endLine := doc.lines.position(start).Line, doc.lines.position(end).Line
+			if endLine-1 > startLine {
+				ranges = append(ranges, FoldingRange{StartLine: startLine, EndLine: endLine - 1})
+			}
+		}
+		for _, child := range n.Nodes() {
+			walk(child)
+		}
+	}
+	walk(doc.tree)
+	return ranges
+}
@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// lineIndex converts between byte offsets and LSP positions, which count
+// lines from zero and characters in UTF-16 code units.
+type lineIndex struct {
+	text   string
+	starts []int // Byte offset of the start of each line
+}
+
+// newLineIndex indexes the line starts of text.
+func newLineIndex(text string) *lineIndex {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &lineIndex{text: text, starts: starts}
+}
+
+// position converts a byte offset to an LSP position.
+func (li *lineIndex) position(offset int) Position {
+	offset = max(0, min(offset, len(li.text)))
+	line := sort.Search(len(li.starts), func(i int) bool { return li.starts[i] > offset }) - 1
+
+	character := 0
+	for _, r := range li.text[li.starts[line]:offset] {
+		character += utf16.RuneLen(r)
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offset converts an LSP position to a byte offset, clamping out of range positions.
+func (li *lineIndex) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(li.starts) {
+		return len(li.text)
+	}
+
+	offset := li.starts[pos.Line]
+	for units := 0; units < pos.Character && offset < len(li.text) && li.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(li.text[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// lineColumn converts a one-based line and byte column, as reported by the
+// lexer, to a byte offset.
+func (li *lineIndex) lineColumn(line, column int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(li.starts) {
+		return len(li.text)
+	}
+	return min(li.starts[line-1]+max(column-1, 0), len(li.text))
+}
+
+// rangeOf converts a pair of byte offsets to an LSP range.
+func (li *lineIndex) rangeOf(start, end int) Range {
+	return Range{Start: li.position(start), End: li.position(end)}
+}
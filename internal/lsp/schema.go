@@ -0,0 +1,394 @@
+package lsp
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/cst"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// maxRefDepth bounds $ref and combinator expansion so cyclic schemas terminate.
+const maxRefDepth = 16
+
+// SchemaAssociation maps file name patterns to a JSON Schema file. Clients
+// pass them in the initializationOptions of the initialize request as
+// {"schemas": [{"fileMatch": ["*.config.json"], "url": "file:///path/schema.json"}]}.
+type SchemaAssociation struct {
+	FileMatch []string `json:"fileMatch"`
+	URL       string   `json:"url"`
+}
+
+// schema is a loaded JSON Schema document.
+type schema struct {
+	root *parser.ObjectValue
+}
+
+// schemaFor returns the schema associated with a document, either through its
+// "$schema" member or through the client's schema associations.
+func (s *Server) schemaFor(doc *document) *schema {
+	docPath := uriPath(doc.uri)
+	if doc.schema != "" {
+		location := doc.schema
+		if u, err := url.Parse(location); err == nil && u.Scheme == "" && !filepath.IsAbs(location) {
+			location = filepath.Join(filepath.Dir(docPath), filepath.FromSlash(location))
+		}
+		if sc := s.loadSchema(location); sc != nil {
+			return sc
+		}
+	}
+
+	for _, assoc := range s.schemas {
+		for _, pattern := range assoc.FileMatch {
+			if matchesFile(pattern, docPath) {
+				return s.loadSchema(assoc.URL)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesFile reports whether a fileMatch pattern matches the document's base
+// name, its whole path or a trailing part of it.
+func matchesFile(pattern, file string) bool {
+	slashed := filepath.ToSlash(file)
+	if ok, _ := path.Match(pattern, path.Base(slashed)); ok {
+		return true
+	}
+	if ok, _ := path.Match(pattern, slashed); ok {
+		return true
+	}
+	return strings.HasSuffix(slashed, "/"+strings.TrimPrefix(pattern, "/"))
+}
+
+// loadSchema returns the schema at a file path or file:// URI, reading it
+// the first time and again once the client reports that the file changed.
+// Schemas that cannot be read or parsed are ignored.
+func (s *Server) loadSchema(location string) *schema {
+	file := uriPath(location)
+	if sc, ok := s.loaded[file]; ok {
+		return sc
+	}
+	sc := readSchema(file)
+	s.loaded[file] = sc
+	return sc
+}
+
+// readSchema reads and parses the schema in a file.
+func readSchema(file string) *schema {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	root, err := parser.New(lexer.New(string(data))).Parse()
+	if err != nil {
+		return nil
+	}
+	object, ok := root.(*parser.ObjectValue)
+	if !ok {
+		return nil
+	}
+	return &schema{root: object}
+}
+
+// at returns the subschemas that apply to the value at path.
+func (sc *schema) at(path []string) []*parser.ObjectValue {
+	current := sc.expand(sc.root, 0)
+	for _, segment := range path {
+		var next []*parser.ObjectValue
+		for _, sub := range current {
+			for _, child := range sc.child(sub, segment) {
+				next = append(next, sc.expand(child, 0)...)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// child returns the subschema for a member or element of values matching sub.
+func (sc *schema) child(sub *parser.ObjectValue, segment string) []*parser.ObjectValue {
+	if props, ok := sub.Pairs["properties"].(*parser.ObjectValue); ok {
+		if prop, ok := props.Pairs[segment].(*parser.ObjectValue); ok {
+			return []*parser.ObjectValue{prop}
+		}
+	}
+	if index, err := strconv.Atoi(segment); err == nil {
+		if prefix, ok := sub.Pairs["prefixItems"].(*parser.ArrayValue); ok && index < len(prefix.Elements) {
+			if item, ok := prefix.Elements[index].(*parser.ObjectValue); ok {
+				return []*parser.ObjectValue{item}
+			}
+		}
+		if items, ok := sub.Pairs["items"].(*parser.ObjectValue); ok {
+			return []*parser.ObjectValue{items}
+		}
+	}
+	if additional, ok := sub.Pairs["additionalProperties"].(*parser.ObjectValue); ok {
+		return []*parser.ObjectValue{additional}
+	}
+	return nil
+}
+
+// expand returns sub together with the schemas it pulls in through $ref,
+// allOf, anyOf and oneOf.
+func (sc *schema) expand(sub *parser.ObjectValue, depth int) []*parser.ObjectValue {
+	if depth > maxRefDepth {
+		return nil
+	}
+
+	result := []*parser.ObjectValue{sub}
+	if ref, ok := sub.Pairs["$ref"].(*parser.StringValue); ok {
+		if target, ok := sc.resolve(ref.Value).(*parser.ObjectValue); ok {
+			result = append(result, sc.expand(target, depth+1)...)
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if branches, ok := sub.Pairs[keyword].(*parser.ArrayValue); ok {
+			for _, branch := range branches.Elements {
+				if b, ok := branch.(*parser.ObjectValue); ok {
+					result = append(result, sc.expand(b, depth+1)...)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// resolve evaluates a local "#/..." reference against the schema root.
+func (sc *schema) resolve(ref string) parser.Value {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil
+	}
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+
+	var current parser.Value = sc.root
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch c := current.(type) {
+		case *parser.ObjectValue:
+			current = c.Pairs[token]
+		case *parser.ArrayValue:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(c.Elements) {
+				return nil
+			}
+			current = c.Elements[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// describe returns the titles and descriptions of the schemas at path.
+func (sc *schema) describe(path []string) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, sub := range sc.at(path) {
+		for _, keyword := range []string{"title", "description"} {
+			if text, ok := sub.Pairs[keyword].(*parser.StringValue); ok && !seen[text.Value] {
+				parts = append(parts, text.Value)
+				seen[text.Value] = true
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// property is a property name proposed by the schema.
+type property struct {
+	name   string
+	schema *parser.ObjectValue
+}
+
+// properties returns the properties the schemas at path declare, in schema order.
+func (sc *schema) properties(path []string) []property {
+	var props []property
+	seen := make(map[string]bool)
+	for _, sub := range sc.at(path) {
+		declared, ok := sub.Pairs["properties"].(*parser.ObjectValue)
+		if !ok {
+			continue
+		}
+		for _, name := range declared.OrderedKeys() {
+			if prop, ok := declared.Pairs[name].(*parser.ObjectValue); ok && !seen[name] {
+				props = append(props, property{name: name, schema: prop})
+				seen[name] = true
+			}
+		}
+	}
+	return props
+}
+
+// values returns the literal values the schemas at path suggest: enum
+// members, const and default values, and the boolean and null literals.
+func (sc *schema) values(path []string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	add := func(v parser.Value) {
+		text := printer.New("").Print(v)
+		if !seen[text] {
+			values = append(values, text)
+			seen[text] = true
+		}
+	}
+
+	for _, sub := range sc.at(path) {
+		if enum, ok := sub.Pairs["enum"].(*parser.ArrayValue); ok {
+			for _, v := range enum.Elements {
+				add(v)
+			}
+		}
+		for _, keyword := range []string{"const", "default"} {
+			if v, ok := sub.Pairs[keyword]; ok {
+				add(v)
+			}
+		}
+		for _, t := range schemaTypes(sub) {
+			switch t {
+			case "boolean":
+				add(&parser.BooleanValue{Value: true})
+				add(&parser.BooleanValue{Value: false})
+			case "null":
+				add(&parser.NullValue{})
+			}
+		}
+	}
+	return values
+}
+
+// schemaTypes returns the "type" keyword of a schema as a list.
+func schemaTypes(sub *parser.ObjectValue) []string {
+	switch t := sub.Pairs["type"].(type) {
+	case *parser.StringValue:
+		return []string{t.Value}
+	case *parser.ArrayValue:
+		var types []string
+		for _, elem := range t.Elements {
+			if s, ok := elem.(*parser.StringValue); ok {
+				types = append(types, s.Value)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// placeholders are inserted at the cursor to make an incomplete document
+// parse, so that completion still knows where the cursor is.
+var placeholders = []string{`"":null`, "null", ":null", `"":null,`}
+
+// completion proposes property names inside objects and values after a
+// colon, using the schema associated with the document.
+func (s *Server) completion(doc *document, pos Position) []CompletionItem {
+	items := []CompletionItem{}
+	if doc == nil {
+		return items
+	}
+	sc := s.schemaFor(doc)
+	if sc == nil {
+		return items
+	}
+
+	offset := doc.lines.offset(pos)
+	tree, repaired := doc.tree, false
+	for i := 0; tree == nil && i < len(placeholders); i++ {
+		text := doc.text[:offset] + placeholders[i] + doc.text[offset:]
+		if t, err := cst.Parse(text, doc.dialect); err == nil {
+			tree, repaired = t, true
+		}
+	}
+	if tree == nil {
+		return items
+	}
+
+	loc := locate(tree, offset)
+	var replace *Range
+	switch {
+	case loc.inKey:
+		if !repaired {
+			key := loc.member.Key()
+			r := doc.lines.rangeOf(key.Offset, key.End())
+			replace = &r
+		}
+		return propertyItems(sc, loc.path[:len(loc.path)-1], existingKeys(loc.object, loc.member), replace)
+	case loc.node.Kind == cst.ObjectNode:
+		return propertyItems(sc, loc.path, existingKeys(loc.node, nil), nil)
+	case loc.member != nil && len(loc.path) > 0:
+		return valueItems(sc, loc.path, nil)
+	case loc.node.Kind == cst.ScalarNode && len(loc.path) > 0:
+		if !repaired {
+			start, end := loc.node.Span()
+			r := doc.lines.rangeOf(start, end)
+			replace = &r
+		}
+		return valueItems(sc, loc.path, replace)
+	}
+	return items
+}
+
+// existingKeys returns the keys already present in an object, except the one of skip.
+func existingKeys(object, skip *cst.Node) map[string]bool {
+	keys := make(map[string]bool)
+	if object == nil {
+		return keys
+	}
+	for _, member := range object.Nodes() {
+		if member != skip {
+			keys[member.Key().Value] = true
+		}
+	}
+	return keys
+}
+
+// propertyItems builds completion items for the properties of the object at path.
+func propertyItems(sc *schema, path []string, existing map[string]bool, replace *Range) []CompletionItem {
+	items := []CompletionItem{}
+	for _, prop := range sc.properties(path) {
+		if existing[prop.name] {
+			continue
+		}
+		item := CompletionItem{
+			Label:  prop.name,
+			Kind:   CompletionKindProperty,
+			Detail: strings.Join(schemaTypes(prop.schema), " | "),
+		}
+		if description, ok := prop.schema.Pairs["description"].(*parser.StringValue); ok {
+			item.Documentation = description.Value
+		}
+
+		quoted := printer.New("").Print(&parser.StringValue{Value: prop.name})
+		if replace != nil {
+			item.TextEdit = &TextEdit{Range: *replace, NewText: quoted}
+		} else {
+			item.InsertText = quoted + ": "
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// valueItems builds completion items for the value at path.
+func valueItems(sc *schema, path []string, replace *Range) []CompletionItem {
+	items := []CompletionItem{}
+	for _, value := range sc.values(path) {
+		item := CompletionItem{Label: value, Kind: CompletionKindValue}
+		if replace != nil {
+			item.TextEdit = &TextEdit{Range: *replace, NewText: value}
+		} else {
+			item.InsertText = value
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,348 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/letsmakecakes/jsonparser/internal/cst"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+	"github.com/letsmakecakes/jsonparser/internal/validator"
+	e "github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client sends exit
+// without a preceding shutdown request.
+var ErrExitWithoutShutdown = errors.New("exit received before shutdown")
+
+// Server is a Language Server Protocol server for JSON files. It speaks
+// JSON-RPC over a byte stream, normally the process's stdin and stdout.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	writeMu  sync.Mutex
+	maxDepth int
+
+	docs     map[string]*document
+	schemas  []SchemaAssociation
+	loaded   map[string]*schema // Schemas by file path; nil if they did not load
+	shutdown bool
+}
+
+// document is an open text document and its parse results.
+type document struct {
+	uri     string
+	text    string
+	dialect lexer.Dialect
+	lines   *lineIndex
+	root    *parser.Document // nil if the text does not parse
+	tree    *cst.Node        // nil if the text does not parse
+	err     error            // Parse error, if any
+	schema  string           // "$schema" member of the root object, kept while the text does not parse
+}
+
+// New creates a new Server reading requests from in and writing to out.
+// Documents are validated with the given maximum nesting depth.
+func New(in io.Reader, out io.Writer, maxDepth int) *Server {
+	return &Server{
+		in:       bufio.NewReader(in),
+		out:      out,
+		maxDepth: maxDepth,
+		docs:     make(map[string]*document),
+		loaded:   make(map[string]*schema),
+	}
+}
+
+// Run serves requests until the client sends exit or the input is closed.
+func (s *Server) Run() error {
+	for {
+		msg, err := readMessage(s.in)
+		if err != nil {
+			var rpcErr *responseError
+			if errors.As(err, &rpcErr) {
+				s.reply(nil, nil, rpcErr)
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, rpcErr := s.handle(msg)
+		if msg.ID != nil {
+			s.reply(msg.ID, result, rpcErr)
+		}
+	}
+}
+
+// handle dispatches a request or notification to its handler.
+func (s *Server) handle(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.schemas = params.InitializationOptions.Schemas
+		return s.capabilities(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.LanguageID, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.update(params.TextDocument.URI, "", params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params documentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", map[string]any{"uri": params.TextDocument.URI, "diagnostics": []Diagnostic{}})
+		return nil, nil
+	case "textDocument/formatting":
+		var params formattingParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		indent := "\t"
+		if params.Options.InsertSpaces {
+			indent = strings.Repeat(" ", max(params.Options.TabSize, 1))
+		}
+		return s.format(s.docs[params.TextDocument.URI], indent), nil
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.symbols(s.docs[params.TextDocument.URI]), nil
+	case "textDocument/hover":
+		var params positionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(s.docs[params.TextDocument.URI], params.Position), nil
+	case "textDocument/foldingRange":
+		var params documentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.foldingRanges(s.docs[params.TextDocument.URI]), nil
+	case "textDocument/completion":
+		var params positionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(s.docs[params.TextDocument.URI], params.Position), nil
+	case "workspace/didChangeWatchedFiles":
+		var params didChangeWatchedFilesParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		for _, change := range params.Changes {
+			delete(s.loaded, uriPath(change.URI))
+		}
+		return nil, nil
+	default:
+		if msg.ID == nil {
+			return nil, nil // Unknown notifications are ignored
+		}
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+// capabilities describes the features the server supports.
+func (s *Server) capabilities() map[string]any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync":           1, // Full document synchronisation
+			"documentFormattingProvider": true,
+			"documentSymbolProvider":     true,
+			"hoverProvider":              true,
+			"foldingRangeProvider":       true,
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{`"`, ":"},
+			},
+		},
+		"serverInfo": map[string]any{"name": "jsonls"},
+	}
+}
+
+// update stores the new text of a document, reparses it and publishes diagnostics.
+func (s *Server) update(uri, languageID, text string) {
+	dialect := dialectFor(uri, languageID)
+	old, ok := s.docs[uri]
+	if ok && languageID == "" {
+		dialect = old.dialect
+	}
+
+	doc := &document{uri: uri, text: text, dialect: dialect, lines: newLineIndex(text)}
+	doc.root, doc.err = parser.New(lexer.NewWithDialect(text, dialect)).ParseDocument()
+	if tree, err := cst.Parse(text, dialect); err == nil {
+		doc.tree = tree
+	}
+	switch {
+	case doc.err == nil:
+		if root, ok := doc.root.Root.(*parser.ObjectValue); ok {
+			if location, ok := root.Pairs["$schema"].(*parser.StringValue); ok {
+				doc.schema = location.Value
+			}
+		}
+	case ok:
+		doc.schema = old.schema
+	}
+	s.docs[uri] = doc
+
+	s.notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": s.diagnostics(doc)})
+}
+
+// diagnostics reports the parse error of a document or, if it parses, its validation errors.
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	if doc.err != nil {
+		start := 0
+		var parseErr *e.ParseError
+		if errors.As(doc.err, &parseErr) {
+			start = doc.lines.lineColumn(parseErr.Line, parseErr.Column)
+		}
+		message := doc.err.Error()
+		if parseErr != nil {
+			message = parseErr.Message
+		}
+		return append(diagnostics, Diagnostic{
+			Range:    doc.lines.rangeOf(start, min(start+1, len(doc.text))),
+			Severity: SeverityError,
+			Source:   "jsonparser",
+			Message:  message,
+		})
+	}
+
+	if path, err := validator.New(s.maxDepth).ValidatePath(doc.root.Root); err != nil {
+		start, end := 0, 0
+		if n := nodeAt(doc.tree, path); n != nil {
+			start, end = n.Span()
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    doc.lines.rangeOf(start, end),
+			Severity: SeverityWarning,
+			Source:   "jsonparser",
+			Message:  err.Error(),
+		})
+	}
+	return diagnostics
+}
+
+// format pretty-prints a document, keeping JSONC comments. JSON5 documents
+// are left alone because printing them as JSON would drop their comments.
+func (s *Server) format(doc *document, indent string) []TextEdit {
+	if doc == nil || doc.root == nil || doc.dialect == lexer.JSON5 {
+		return nil
+	}
+
+	formatted := printer.New(indent).PrintDocument(doc.root)
+	if strings.HasSuffix(doc.text, "\n") {
+		formatted += "\n"
+	}
+	if formatted == doc.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: doc.lines.rangeOf(0, len(doc.text)), NewText: formatted}}
+}
+
+// reply sends the response to a request.
+func (s *Server) reply(id *json.RawMessage, result any, rpcErr *responseError) {
+	resp := response{JSONRPC: "2.0", ID: id}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		body, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &responseError{Code: codeInvalidRequest, Message: err.Error()}
+		} else {
+			resp.Result = body
+		}
+	}
+	s.write(resp)
+}
+
+// notify sends a notification to the client.
+func (s *Server) notify(method string, params any) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends a message, logging failures since there is nobody to report them to.
+func (s *Server) write(v any) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := writeMessage(s.out, v); err != nil {
+		log.Printf("error writing message: %v", err)
+	}
+}
+
+// unmarshalParams decodes the parameters of a message.
+func unmarshalParams(msg *message, v any) *responseError {
+	if len(msg.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// dialectFor picks the dialect of a document from its language ID or file extension.
+func dialectFor(uri, languageID string) lexer.Dialect {
+	switch languageID {
+	case "jsonc":
+		return lexer.JSONC
+	case "json5":
+		return lexer.JSON5
+	}
+	switch strings.ToLower(filepath.Ext(uriPath(uri))) {
+	case ".jsonc":
+		return lexer.JSONC
+	case ".json5":
+		return lexer.JSON5
+	default:
+		return lexer.Strict
+	}
+}
+
+// uriPath returns the file system path of a file:// URI, or the URI itself.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,254 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// session drives a Server through an in-memory transcript of requests.
+type session struct {
+	t   *testing.T
+	in  bytes.Buffer
+	out bytes.Buffer
+	id  int
+}
+
+func (s *session) send(method string, params any) {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if !strings.HasPrefix(method, "textDocument/did") && method != "initialized" && method != "exit" {
+		s.id++
+		msg["id"] = s.id
+	}
+	if err := writeMessage(&s.in, msg); err != nil {
+		s.t.Fatalf("error writing request: %v", err)
+	}
+}
+
+// run serves the transcript and returns the messages the server wrote.
+func (s *session) run() []map[string]json.RawMessage {
+	if err := New(&s.in, &s.out, 32).Run(); err != nil {
+		s.t.Fatalf("unexpected error: %v", err)
+	}
+
+	var messages []map[string]json.RawMessage
+	r := bufio.NewReader(&s.out)
+	for {
+		body, err := readFrame(r)
+		if err != nil {
+			break
+		}
+		var fields map[string]json.RawMessage
+		_ = json.Unmarshal(body, &fields)
+		messages = append(messages, fields)
+	}
+	return messages
+}
+
+func TestServer_Session(t *testing.T) {
+	s := &session{t: t}
+	s.send("initialize", map[string]any{})
+	s.send("textDocument/didOpen", map[string]any{"textDocument": map[string]any{
+		"uri": "file:///tmp/a.json", "languageId": "json", "text": "{\"a\": 1,}",
+	}})
+	s.send("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": "file:///tmp/a.json"},
+		"contentChanges": []map[string]any{{"text": "{\"a\": {\"b\": [1,\n2]}}"}},
+	})
+	s.send("textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": "file:///tmp/a.json"},
+		"position":     map[string]any{"line": 1, "character": 0},
+	})
+	s.send("shutdown", nil)
+	s.send("exit", nil)
+
+	messages := s.run()
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(messages))
+	}
+
+	// The first notification reports the trailing comma
+	var first struct {
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	_ = json.Unmarshal(messages[1]["params"], &first)
+	if len(first.Diagnostics) != 1 || first.Diagnostics[0].Range.Start != (Position{Line: 0, Character: 8}) {
+		t.Errorf("unexpected diagnostics: %+v", first.Diagnostics)
+	}
+
+	// The fixed document has no diagnostics
+	var second struct {
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	_ = json.Unmarshal(messages[2]["params"], &second)
+	if len(second.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", second.Diagnostics)
+	}
+
+	var hover Hover
+	_ = json.Unmarshal(messages[3]["result"], &hover)
+	if hover.Contents.Value != "`/a/b/1`" {
+		t.Errorf("unexpected hover: %+v", hover)
+	}
+}
+
+func TestServer_Features(t *testing.T) {
+	s := New(nil, &bytes.Buffer{}, 32)
+	text := "// settings\n{\n  \"name\": \"x\",\n  \"list\": [\n    1,\n    2\n  ]\n}\n"
+	s.update("file:///tmp/settings.jsonc", "", text)
+	doc := s.docs["file:///tmp/settings.jsonc"]
+
+	symbols := s.symbols(doc)
+	if len(symbols) != 2 || symbols[0].Name != "name" || symbols[1].Kind != SymbolKindArray || len(symbols[1].Children) != 2 {
+		t.Errorf("unexpected symbols: %+v", symbols)
+	}
+
+	folds := s.foldingRanges(doc)
+	if len(folds) != 2 || folds[0] != (FoldingRange{StartLine: 1, EndLine: 6}) || folds[1] != (FoldingRange{StartLine: 3, EndLine: 5}) {
+		t.Errorf("unexpected folding ranges: %+v", folds)
+	}
+
+	edits := s.format(doc, "\t")
+	expected := "// settings\n{\n\t\"name\": \"x\",\n\t\"list\": [\n\t\t1,\n\t\t2\n\t]\n}\n"
+	if len(edits) != 1 || edits[0].NewText != expected {
+		t.Errorf("unexpected formatting edits: %+v", edits)
+	}
+
+	// Validation errors cover the value, or the member for a bad key
+	tests := []struct {
+		text     string
+		expected Range
+	}{
+		{"{\n  \"ok\": 1,\n  \"bad\": [\"a\\u0001\"]\n}", Range{Start: Position{2, 10}, End: Position{2, 19}}},
+		{"{\"a\\u0001\": 1}", Range{Start: Position{0, 1}, End: Position{0, 13}}},
+	}
+	for _, tt := range tests {
+		s.update("file:///tmp/invalid.json", "", tt.text)
+		diagnostics := s.diagnostics(s.docs["file:///tmp/invalid.json"])
+		if len(diagnostics) != 1 || diagnostics[0].Range != tt.expected {
+			t.Errorf("%q: expected a diagnostic at %+v, got %+v", tt.text, tt.expected, diagnostics)
+		}
+	}
+}
+
+func TestServer_SchemaCompletion(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{
+  "type": "object",
+  "properties": {
+    "mode": {"$ref": "#/$defs/mode"},
+    "debug": {"type": "boolean", "description": "Enable debug output"},
+    "port": {"type": "integer"}
+  },
+  "$defs": {"mode": {"enum": ["fast", "safe"], "description": "Run mode"}}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(nil, &bytes.Buffer{}, 32)
+	s.schemas = []SchemaAssociation{{FileMatch: []string{"*.app.json"}, URL: filepath.Join(dir, "schema.json")}}
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "x.app.json"))
+
+	// Property names, skipping keys already present; the document does not parse yet
+	s.update(uri, "", "{\"port\": 1, }")
+	items := s.completion(s.docs[uri], Position{Line: 0, Character: 12})
+	if len(items) != 2 || items[0].Label != "mode" || items[1].Label != "debug" || items[1].Documentation != "Enable debug output" {
+		t.Errorf("unexpected property completions: %+v", items)
+	}
+
+	// Values after a colon come from enums, following $ref
+	s.update(uri, "", "{\"mode\": }")
+	items = s.completion(s.docs[uri], Position{Line: 0, Character: 9})
+	if len(items) != 2 || items[0].InsertText != `"fast"` || items[1].InsertText != `"safe"` {
+		t.Errorf("unexpected value completions: %+v", items)
+	}
+
+	// Hover shows the schema description
+	s.update(uri, "", "{\"debug\": true}")
+	hover := s.hover(s.docs[uri], Position{Line: 0, Character: 3})
+	if hover == nil || hover.Contents.Value != "`/debug`\n\nEnable debug output" {
+		t.Errorf("unexpected hover: %+v", hover)
+	}
+
+	// The schema is read once, and again after the client reports a change
+	changed := strings.Replace(schema, "Enable debug output", "Print more", 1)
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if hover := s.hover(s.docs[uri], Position{Line: 0, Character: 3}); hover == nil || !strings.HasSuffix(hover.Contents.Value, "Enable debug output") {
+		t.Errorf("expected the cached schema, got %+v", hover)
+	}
+
+	// A "$schema" member of the root object names the schema relative to the
+	// document, and still applies while an edit leaves the text broken
+	other := "file://" + filepath.ToSlash(filepath.Join(dir, "other.json"))
+	s.update(other, "", `{"$schema": "schema.json", "debug": true}`)
+	s.update(other, "", `{"$schema": "schema.json", "debug": true,}`)
+	if items := s.completion(s.docs[other], Position{Line: 0, Character: 41}); len(items) != 2 {
+		t.Errorf("expected completions from the $schema member, got %+v", items)
+	}
+	s.update(other, "", `{"nested": {"$schema": "schema.json"}, "debug": true}`)
+	if hover := s.hover(s.docs[other], Position{Line: 0, Character: 41}); hover == nil || hover.Contents.Value != "`/debug`" {
+		t.Errorf("expected a nested $schema member to be ignored, got %+v", hover)
+	}
+
+	params, _ := json.Marshal(map[string]any{"changes": []map[string]any{{"uri": "file://" + filepath.ToSlash(filepath.Join(dir, "schema.json")), "type": 2}}})
+	s.handle(&message{Method: "workspace/didChangeWatchedFiles", Params: params})
+	if hover := s.hover(s.docs[uri], Position{Line: 0, Character: 3}); hover == nil || !strings.HasSuffix(hover.Contents.Value, "Print more") {
+		t.Errorf("expected the changed schema, got %+v", hover)
+	}
+}
+
+func TestReadFrame_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"missing length", "Content-Type: x\r\n\r\n{}", "missing Content-Length"},
+		{"negative length", "Content-Length: -1\r\n\r\n", "-1 is outside"},
+		{"huge length", "Content-Length: 1099511627776\r\n\r\n", "is outside"},
+		{"not a number", "Content-Length: ten\r\n\r\n", "invalid Content-Length"},
+		{"short body", "Content-Length: 5\r\n\r\n{}", "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readFrame(bufio.NewReader(strings.NewReader(tt.input)))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// The server stops instead of crashing
+	err := New(strings.NewReader("Content-Length: -1\r\n\r\n"), &bytes.Buffer{}, 32).Run()
+	if err == nil || !strings.Contains(err.Error(), "Content-Length") {
+		t.Errorf("expected Run to fail on a negative length, got %v", err)
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	li := newLineIndex("ab\n😀x\n")
+	tests := []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{3, Position{1, 0}},
+		{7, Position{1, 2}},
+		{9, Position{2, 0}},
+	}
+	for _, tt := range tests {
+		if got := li.position(tt.offset); got != tt.pos {
+			t.Errorf("position(%d): expected %+v, got %+v", tt.offset, tt.pos, got)
+		}
+		if got := li.offset(tt.pos); got != tt.offset {
+			t.Errorf("offset(%+v): expected %d, got %d", tt.pos, tt.offset, got)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// maxDepth bounds the nesting of arrays and maps.
+const maxDepth = 1000
+
+// A Decoder reads a stream of concatenated MessagePack objects, one per
+// call to Decode. It buffers its input, so it may read past the object it
+// returns.
+type Decoder struct {
+	r      *bufio.Reader
+	offset int64 // Bytes consumed so far
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next object and converts it to a value. Integers and
+// floats become numbers, so integers beyond 2^53 lose precision, bin data
+// becomes a base64 string, and extensions become tagged values. Map keys
+// that are not strings become their compact JSON text, so the key 1
+// becomes "1". At the end of the stream Decode returns io.EOF; a stream
+// that ends inside an object gives an error wrapping io.ErrUnexpectedEOF.
+func (d *Decoder) Decode() (parser.Value, error) {
+	if _, err := d.r.Peek(1); err == io.EOF {
+		return nil, io.EOF
+	}
+	return d.value(0)
+}
+
+// Parse decodes data holding exactly one MessagePack object.
+func Parse(data []byte) (parser.Value, error) {
+	d := NewDecoder(bytes.NewReader(data))
+	v, err := d.Decode()
+	if err == io.EOF {
+		return nil, fmt.Errorf("msgpack: no object in the input")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.offset != int64(len(data)) {
+		return nil, d.errorf(d.offset, "unexpected data after the object")
+	}
+	return v, nil
+}
+
+// ParseAll decodes every object of a stream held in memory.
+func ParseAll(data []byte) ([]parser.Value, error) {
+	d := NewDecoder(bytes.NewReader(data))
+	var values []parser.Value
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
+
+func (d *Decoder) errorf(offset int64, format string, args ...any) error {
+	return fmt.Errorf("msgpack: "+format+" at offset %d", append(args, offset)...)
+}
+
+func (d *Decoder) unexpectedEOF() error {
+	return fmt.Errorf("msgpack: unexpected end of data at offset %d: %w", d.offset, io.ErrUnexpectedEOF)
+}
+
+// read reads exactly n bytes, growing the buffer as data arrives so that a
+// corrupt length cannot allocate more than the stream holds.
+func (d *Decoder) read(n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d.r, int64(min(n, math.MaxInt64)))
+	d.offset += copied
+	if err == io.EOF {
+		return nil, d.unexpectedEOF()
+	}
+	return buf.Bytes(), err
+}
+
+func (d *Decoder) readUint(size int) (uint64, error) {
+	b, err := d.read(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// value reads one object.
+func (d *Decoder) value(depth int) (parser.Value, error) {
+	start := d.offset
+	if depth > maxDepth {
+		return nil, d.errorf(start, "nesting exceeds %d levels", maxDepth)
+	}
+	c, err := d.r.ReadByte()
+	if err == io.EOF {
+		return nil, d.unexpectedEOF()
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.offset++
+
+	switch {
+	case c <= 0x7f:
+		return parser.Number(float64(c)), nil
+	case c >= 0xe0:
+		return parser.Number(float64(int8(c))), nil
+	case c <= 0x8f:
+		return d.mapValue(uint64(c&0x0f), depth)
+	case c <= 0x9f:
+		return d.array(uint64(c&0x0f), depth)
+	case c <= 0xbf:
+		return d.str(start, uint64(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return parser.Null(), nil
+	case 0xc2:
+		return parser.Bool(false), nil
+	case 0xc3:
+		return parser.Bool(true), nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		return parser.String(base64.StdEncoding.EncodeToString(b)), nil
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		bits, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return parser.Number(float64(math.Float32frombits(uint32(bits)))), nil
+	case 0xcb:
+		bits, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return parser.Number(math.Float64frombits(bits)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8 to 64
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return parser.Number(float64(u)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8 to 64
+		size := 1 << (c - 0xd0)
+		u, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the top bit of the value
+		shift := 64 - 8*size
+		return parser.Number(float64(int64(u<<shift) >> shift)), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1 to 16
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(start, n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(n, depth)
+	}
+	return nil, d.errorf(start, "invalid format byte 0x%02x", c)
+}
+
+func (d *Decoder) str(start int64, n uint64) (parser.Value, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(b) {
+		return nil, d.errorf(start, "string is not valid UTF-8")
+	}
+	return parser.String(string(b)), nil
+}
+
+func (d *Decoder) array(n uint64, depth int) (parser.Value, error) {
+	arr := &parser.ArrayValue{Elements: make([]parser.Value, 0, min(n, 1024))}
+	for range n {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr.Append(v)
+	}
+	return arr, nil
+}
+
+func (d *Decoder) mapValue(n uint64, depth int) (parser.Value, error) {
+	o := parser.Object()
+	for range n {
+		keyStart := d.offset
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key := printer.New("").Print(k)
+		if s, ok := k.(*parser.StringValue); ok {
+			key = s.Value
+		}
+		if _, ok := o.Pairs[key]; ok {
+			return nil, d.errorf(keyStart, "duplicate map key %q", key)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		o.Set(key, v)
+	}
+	return o, nil
+}
+
+// ext reads the type and payload of an extension and returns it as a
+// tagged value. A timestamp holds its time unless it is outside the years
+// RFC 3339 can write.
+func (d *Decoder) ext(n uint64) (parser.Value, error) {
+	typ, err := d.readUint(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	tagged := parser.Object().Set(extKey, parser.Number(float64(int8(typ))))
+	if int8(typ) == timestampType {
+		if t, ok := decodeTimestamp(data); ok {
+			return tagged.Set(timestampKey, parser.String(t.Format(time.RFC3339Nano))), nil
+		}
+	}
+	return tagged.Set(dataKey, parser.String(base64.StdEncoding.EncodeToString(data))), nil
+}
+
+// decodeTimestamp decodes the 32, 64 and 96-bit timestamp formats.
+func decodeTimestamp(data []byte) (time.Time, bool) {
+	var sec int64
+	var nsec uint64
+	switch len(data) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		sec, nsec = int64(v&(1<<34-1)), v>>34
+	case 12:
+		nsec = uint64(binary.BigEndian.Uint32(data))
+		sec = int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return time.Time{}, false
+	}
+	// RFC 3339 covers the years 0000 to 9999
+	if nsec > 999999999 || sec < -62167219200 || sec >= 253402300800 {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, int64(nsec)).UTC(), true
+}
@@ -0,0 +1,232 @@
+// Package msgpack converts between the parser's AST and MessagePack.
+// Marshal picks the smallest encoding of every value; a Decoder reads a
+// stream of concatenated MessagePack objects one value at a time.
+//
+// Extension types have no JSON equivalent and are surfaced as tagged
+// values: objects holding the type in "$ext" and the payload, base64
+// encoded, in "data". The timestamp extension (type -1) holds an RFC 3339
+// time in "timestamp" instead. Marshal writes such objects back as
+// extensions, so they round-trip.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Members of a tagged extension value
+const (
+	extKey       = "$ext"
+	dataKey      = "data"
+	timestampKey = "timestamp"
+)
+
+// timestampType is the extension type of timestamps.
+const timestampType = -1
+
+// Marshal encodes a value as a MessagePack object. Integral numbers from
+// -2^63 to 2^64 are written as the smallest integer format that holds
+// them, and other numbers as float 32 when that is exact and float 64
+// otherwise. Strings, arrays and maps use their fix formats when short
+// enough. Objects shaped like tagged extension values become extensions.
+func Marshal(v parser.Value) ([]byte, error) {
+	e := &encoder{}
+	if err := e.write(nil, v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) write(path parser.Path, v parser.Value) error {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		if isExt(n) {
+			return e.writeExt(path, n)
+		}
+		e.writeLength(len(n.Pairs), 0x80, 15, 0xde)
+		for _, key := range n.OrderedKeys() {
+			child := append(path.Clone(), parser.KeySegment(key))
+			if err := e.writeString(child, key); err != nil {
+				return err
+			}
+			if err := e.write(child, n.Pairs[key]); err != nil {
+				return err
+			}
+		}
+	case *parser.ArrayValue:
+		e.writeLength(len(n.Elements), 0x90, 15, 0xdc)
+		for i, elem := range n.Elements {
+			if err := e.write(append(path, parser.IndexSegment(i)), elem); err != nil {
+				return err
+			}
+		}
+	case *parser.StringValue:
+		return e.writeString(path, n.Value)
+	case *parser.NumberValue:
+		e.writeNumber(n.Value)
+	case *parser.BooleanValue:
+		if n.Value {
+			e.buf.WriteByte(0xc3)
+		} else {
+			e.buf.WriteByte(0xc2)
+		}
+	case *parser.NullValue:
+		e.buf.WriteByte(0xc0)
+	default:
+		return fmt.Errorf("cannot encode %T at %s", v, path.Describe())
+	}
+	return nil
+}
+
+// writeLength writes the header of a string, array or map: the fix format
+// for lengths up to fixMax, and otherwise the 16-bit format, which the
+// 32-bit one follows.
+func (e *encoder) writeLength(n int, fix byte, fixMax int, format16 byte) {
+	switch {
+	case n <= fixMax:
+		e.buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		e.buf.Write(binary.BigEndian.AppendUint16([]byte{format16}, uint16(n)))
+	default:
+		e.buf.Write(binary.BigEndian.AppendUint32([]byte{format16 + 1}, uint32(n)))
+	}
+}
+
+func (e *encoder) writeString(path parser.Path, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("cannot encode the string at %s: it is not valid UTF-8", path.Describe())
+	}
+	if len(s) > 31 && len(s) <= math.MaxUint8 {
+		e.buf.Write([]byte{0xd9, byte(len(s))})
+	} else {
+		e.writeLength(len(s), 0xa0, 31, 0xda)
+	}
+	e.buf.WriteString(s)
+	return nil
+}
+
+// writeNumber writes integral values as the smallest integer format and
+// everything else as a float.
+func (e *encoder) writeNumber(f float64) {
+	const two63, two64 = 1 << 63, 1 << 64
+	switch {
+	case f != math.Trunc(f) || f < -two63 || f >= two64:
+		// Not an integer, including NaN and the infinities
+		if f32 := float32(f); float64(f32) == f || math.IsNaN(f) {
+			e.buf.Write(binary.BigEndian.AppendUint32([]byte{0xca}, math.Float32bits(f32)))
+		} else {
+			e.buf.Write(binary.BigEndian.AppendUint64([]byte{0xcb}, math.Float64bits(f)))
+		}
+	case f >= 0:
+		u := uint64(f)
+		switch {
+		case u <= 0x7f:
+			e.buf.WriteByte(byte(u))
+		case u <= math.MaxUint8:
+			e.buf.Write([]byte{0xcc, byte(u)})
+		case u <= math.MaxUint16:
+			e.buf.Write(binary.BigEndian.AppendUint16([]byte{0xcd}, uint16(u)))
+		case u <= math.MaxUint32:
+			e.buf.Write(binary.BigEndian.AppendUint32([]byte{0xce}, uint32(u)))
+		default:
+			e.buf.Write(binary.BigEndian.AppendUint64([]byte{0xcf}, u))
+		}
+	default:
+		i := int64(f)
+		switch {
+		case i >= -32:
+			e.buf.WriteByte(byte(i))
+		case i >= math.MinInt8:
+			e.buf.Write([]byte{0xd0, byte(i)})
+		case i >= math.MinInt16:
+			e.buf.Write(binary.BigEndian.AppendUint16([]byte{0xd1}, uint16(i)))
+		case i >= math.MinInt32:
+			e.buf.Write(binary.BigEndian.AppendUint32([]byte{0xd2}, uint32(i)))
+		default:
+			e.buf.Write(binary.BigEndian.AppendUint64([]byte{0xd3}, uint64(i)))
+		}
+	}
+}
+
+// isExt reports whether an object has the members of a tagged extension
+// value, and no others.
+func isExt(o *parser.ObjectValue) bool {
+	if len(o.Pairs) != 2 {
+		return false
+	}
+	_, hasType := o.Pairs[extKey]
+	_, hasData := o.Pairs[dataKey]
+	_, hasTimestamp := o.Pairs[timestampKey]
+	return hasType && (hasData || hasTimestamp)
+}
+
+// writeExt writes a tagged extension value.
+func (e *encoder) writeExt(path parser.Path, o *parser.ObjectValue) error {
+	n, ok := o.Pairs[extKey].(*parser.NumberValue)
+	if !ok || n.Value != math.Trunc(n.Value) || n.Value < math.MinInt8 || n.Value > math.MaxInt8 {
+		return fmt.Errorf("invalid extension value at %s: %q must be an integer from -128 to 127", path.Describe(), extKey)
+	}
+	typ := int8(n.Value)
+
+	var data []byte
+	if ts, ok := o.Pairs[timestampKey]; ok {
+		s, ok := ts.(*parser.StringValue)
+		if !ok || typ != timestampType {
+			return fmt.Errorf("invalid extension value at %s: %q needs an RFC 3339 string and type %d", path.Describe(), timestampKey, timestampType)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s.Value)
+		if err != nil {
+			return fmt.Errorf("invalid extension value at %s: %v", path.Describe(), err)
+		}
+		data = encodeTimestamp(t)
+	} else {
+		s, ok := o.Pairs[dataKey].(*parser.StringValue)
+		if !ok {
+			return fmt.Errorf("invalid extension value at %s: %q must be a base64 string", path.Describe(), dataKey)
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(s.Value); err != nil {
+			return fmt.Errorf("invalid extension value at %s: %q must be a base64 string: %v", path.Describe(), dataKey, err)
+		}
+	}
+
+	switch n := len(data); {
+	case n == 1 || n == 2 || n == 4 || n == 8 || n == 16:
+		e.buf.WriteByte(0xd4 + byte(bits.TrailingZeros(uint(n)))) // fixext 1 to 16
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xc7, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.Write(binary.BigEndian.AppendUint16([]byte{0xc8}, uint16(n)))
+	default:
+		e.buf.Write(binary.BigEndian.AppendUint32([]byte{0xc9}, uint32(n)))
+	}
+	e.buf.WriteByte(byte(typ))
+	e.buf.Write(data)
+	return nil
+}
+
+// encodeTimestamp encodes a time in the smallest of the 32, 64 and 96-bit
+// timestamp formats that holds it.
+func encodeTimestamp(t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		return binary.BigEndian.AppendUint32(nil, uint32(sec))
+	case sec >= 0 && sec < 1<<34:
+		return binary.BigEndian.AppendUint64(nil, nsec<<34|uint64(sec))
+	default:
+		return binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint32(nil, uint32(nsec)), uint64(sec))
+	}
+}
@@ -0,0 +1,239 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		input    string // JSON5
+		expected string // Hex
+	}{
+		{`null`, "c0"},
+		{`false`, "c2"},
+		{`true`, "c3"},
+		{`0`, "00"},
+		{`127`, "7f"},
+		{`128`, "cc80"},
+		{`255`, "ccff"},
+		{`256`, "cd0100"},
+		{`65536`, "ce00010000"},
+		{`4294967296`, "cf0000000100000000"},
+		{`18446744073709549568`, "cffffffffffffff800"},
+		{`-1`, "ff"},
+		{`-32`, "e0"},
+		{`-33`, "d0df"},
+		{`-128`, "d080"},
+		{`-129`, "d1ff7f"},
+		{`-32769`, "d2ffff7fff"},
+		{`-2147483649`, "d3ffffffff7fffffff"},
+		{`-9223372036854775808`, "d38000000000000000"},
+		{`1.0`, "01"},
+		{`1.5`, "ca3fc00000"},
+		{`0.1`, "cb3fb999999999999a"},
+		{`1e300`, "cb7e37e43c8800759c"},
+		{`18446744073709551616`, "ca5f800000"},
+		{`Infinity`, "ca7f800000"},
+		{`""`, "a0"},
+		{`"abc"`, "a3616263"},
+		{`"` + strings.Repeat("x", 31) + `"`, "bf" + strings.Repeat("78", 31)},
+		{`"` + strings.Repeat("x", 32) + `"`, "d920" + strings.Repeat("78", 32)},
+		{`"` + strings.Repeat("x", 256) + `"`, "da0100" + strings.Repeat("78", 256)},
+		{`[]`, "90"},
+		{`[1, [2]]`, "920191 02"},
+		{`{}`, "80"},
+		{`{"a": 1, "b": [true]}`, "82a16101a1629 1c3"},
+		{`{"$ext": 5, "data": "AQ=="}`, "d40501"},
+		{`{"$ext": -128, "data": "AQI="}`, "d58001 02"},
+		{`{"$ext": 1, "data": "AQID"}`, "c703 01 010203"},
+		{`{"$ext": 1, "data": ""}`, "c700 01"},
+		{`{"$ext": -1, "timestamp": "2013-03-21T20:04:00Z"}`, "d6ff514b67b0"},
+		{`{"$ext": -1, "timestamp": "2013-03-21T20:04:00.5Z"}`, "d7ff77359400514b67b0"},
+		{`{"$ext": -1, "timestamp": "1969-12-31T23:59:59Z"}`, "c70cff00000000ffffffffffffffff"},
+		{`{"$ext": 1, "other": ""}`, "82a4246578740 1a56f74686572a0"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(testutil.JSON(t, tt.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if expected := strings.ReplaceAll(tt.expected, " ", ""); hex.EncodeToString(got) != expected {
+			t.Errorf("%s: expected %s, got %x", tt.input, expected, got)
+		}
+	}
+}
+
+func TestMarshal_Sizes(t *testing.T) {
+	tests := []struct {
+		count  int
+		header string
+	}{
+		{15, "9f"},
+		{16, "dc0010"},
+		{65536, "dd00010000"},
+	}
+	for _, tt := range tests {
+		arr := parser.Array()
+		obj := parser.Object()
+		for i := range tt.count {
+			arr.Append(parser.Null())
+			obj.Set(strings.Repeat("k", i%5)+string(rune('a'+i%26))+strings.Repeat("z", i/130), parser.Null())
+		}
+		got, _ := Marshal(arr)
+		if !strings.HasPrefix(hex.EncodeToString(got), tt.header) {
+			t.Errorf("array of %d: expected header %s, got %x", tt.count, tt.header, got[:5])
+		}
+	}
+
+	obj := parser.Object()
+	for i := range 16 {
+		obj.Set(string(rune('a'+i)), parser.Null())
+	}
+	if got, _ := Marshal(obj); !strings.HasPrefix(hex.EncodeToString(got), "de0010") {
+		t.Errorf("map of 16: expected map 16, got %x", got[:3])
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`{"x": {"$ext": 200, "data": ""}}`, `invalid extension value at x: "$ext" must be an integer from -128 to 127`},
+		{`{"$ext": 1.5, "data": ""}`, "must be an integer"},
+		{`{"$ext": 1, "data": "!"}`, `"data" must be a base64 string`},
+		{`{"$ext": 1, "data": 1}`, `"data" must be a base64 string`},
+		{`{"$ext": 1, "timestamp": "2013-03-21T20:04:00Z"}`, `"timestamp" needs an RFC 3339 string and type -1`},
+		{`{"$ext": -1, "timestamp": "yesterday"}`, "cannot parse"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(testutil.JSON(t, tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.message, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // Hex
+		expected string // JSON5
+	}{
+		{"fixints", "93 00 7f e0", `[0, 127, -32]`},
+		{"unsigned", "94 cc80 cd0100 ce00010000 cf0000000100000000", `[128, 256, 65536, 4294967296]`},
+		{"signed", "94 d0df d1ff7f d2ffff7fff d3ffffffff7fffffff", `[-33, -129, -32769, -2147483649]`},
+		{"unsigned formats for small values", "93 cc01 cd0002 cf0000000000000003", `[1, 2, 3]`},
+		{"floats", "92 ca3fc00000 cb3fb999999999999a", `[1.5, 0.1]`},
+		{"NaN of every width", "92 ca7fc00000 cb7ff8000000000000", `[NaN, NaN]`},
+		{"strings", "93 a0 d903616263 db00000001 78", `["", "abc", "x"]`},
+		{"bin", "93 c40101 c5000200ff c600000000", `["AQ==", "AP8=", ""]`},
+		{"arrays", "92 dc0001 c0 dd00000000", `[[null], []]`},
+		{"maps", "82 a161 de0001 a162c3 a163 df00000000", `{"a": {"b": true}, "c": {}}`},
+		{"non-string keys", "83 01 02 c3 03 9101 04", `{"1": 2, "true": 3, "[1]": 4}`},
+		{"fixext", "92 d40501 d8 7f 000102030405060708090a0b0c0d0e0f", `[{"$ext": 5, "data": "AQ=="}, {"$ext": 127, "data": "AAECAwQFBgcICQoLDA0ODw=="}]`},
+		{"ext", "93 c70001 c8000102 aa c90000000203 0102", `[{"$ext": 1, "data": ""}, {"$ext": 2, "data": "qg=="}, {"$ext": 3, "data": "AQI="}]`},
+		{"timestamp 32", "d6ff514b67b0", `{"$ext": -1, "timestamp": "2013-03-21T20:04:00Z"}`},
+		{"timestamp 64", "d7ff77359400514b67b0", `{"$ext": -1, "timestamp": "2013-03-21T20:04:00.5Z"}`},
+		{"timestamp 96", "c70cff 00000001 fffffffffffffffe", `{"$ext": -1, "timestamp": "1969-12-31T23:59:58.000000001Z"}`},
+		{"timestamp out of range", "c70cff 00000000 7fffffffffffffff", `{"$ext": -1, "data": "AAAAAH//////////"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(testutil.Hex(t, tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"empty", "", "no object in the input"},
+		{"trailing data", "01 02", "unexpected data after the object at offset 1"},
+		{"never used", "c1", "invalid format byte 0xc1 at offset 0"},
+		{"truncated integer", "cd01", "unexpected end of data at offset 2"},
+		{"truncated string", "a3 6161", "unexpected end of data at offset 3"},
+		{"huge string", "db ffffffff 61", "unexpected end of data at offset 6"},
+		{"truncated array", "93 01 02", "unexpected end of data at offset 3"},
+		{"huge map", "df ffffffff", "unexpected end of data at offset 5"},
+		{"invalid UTF-8", "a2 c328", "string is not valid UTF-8 at offset 0"},
+		{"duplicate key", "82 a161 01 a161 02", `duplicate map key "a" at offset 4`},
+		{"deep nesting", strings.Repeat("91", 1002) + "00", "nesting exceeds 1000 levels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(testutil.Hex(t, tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	// Objects arrive one at a time
+	r, w := io.Pipe()
+	go func() {
+		w.Write(testutil.Hex(t, "81a16101"))
+		w.Write(testutil.Hex(t, "92c3"))
+		w.Write(testutil.Hex(t, "c2 a0"))
+		w.Close()
+	}()
+	d := NewDecoder(r)
+	var got []string
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, printer.New("").Print(v))
+	}
+	if expected := `{"a":1} [true,false] ""`; strings.Join(got, " ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(got, " "))
+	}
+
+	// A stream cut inside an object
+	d = NewDecoder(bytes.NewReader(testutil.Hex(t, "01 92 01")))
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	values, err := ParseAll(testutil.Hex(t, "01 a178 c0"))
+	if err != nil || len(values) != 3 {
+		t.Errorf("expected three values, got %d (%v)", len(values), err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{"name": "Ada", "age": 36, "ratio": 0.5, "tags": ["a", "b"], "nested": {"x": null, "y": [true, false]}}`,
+		`[0, -0.5, 1.1, 127, 128, -32, -33, 65535, 65536, 4294967295, 4294967296, -2147483648, -2147483649, 9007199254740993, 1e300, 5e-324, -Infinity]`,
+		`["", "ü水😀", "\u0000", {"": {}}, [[[]]]]`,
+		`[{"$ext": 7, "data": "AAECAw=="}, {"$ext": -1, "timestamp": "2024-02-29T12:00:00.123456789Z"}, {"$ext": -1, "data": "AQ=="}]`,
+	}
+	for _, input := range inputs {
+		testutil.RoundTrip(t, input, Marshal, Parse)
+	}
+}
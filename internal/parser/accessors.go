@@ -0,0 +1,428 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// Accessors is the set of convenience methods every Value has for reading
+// data without type assertions. Conversions fail with an *errors.AccessError
+// when the value has a different type, and lookups when the path does not
+// exist, so
+//
+//	port, err := root.Get("server.port")
+//	...
+//	n, err := port.Int()
+//
+// replaces a chain of assertions on *ObjectValue and *NumberValue.
+type Accessors interface {
+	// Get returns the value at a path relative to this one, in the syntax
+	// of ParsePath: "server.port", "servers[0].host" or `tags["a.b"]`. A
+	// plain number also selects an array element, as in "servers.0.host".
+	// The empty path returns the value itself.
+	Get(path string) (Value, error)
+	// Exists reports whether Get would find a value at path.
+	Exists(path string) bool
+	// String returns the value of a string.
+	String() (string, error)
+	// Int returns the value of a number that is an integer within int64 range.
+	Int() (int64, error)
+	// Float returns the value of a number.
+	Float() (float64, error)
+	// Bool returns the value of a boolean.
+	Bool() (bool, error)
+	// IsNull reports whether the value is null.
+	IsNull() bool
+	// Len returns the number of elements of an array or members of an object.
+	Len() (int, error)
+	// Index returns element i of an array.
+	Index(i int) (Value, error)
+	// Keys returns the member keys of an object in source order.
+	Keys() ([]string, error)
+}
+
+// TypeName returns the JSON type of a value: "object", "array", "string",
+// "number", "boolean" or "null".
+func TypeName(v Value) string {
+	switch v.(type) {
+	case *ObjectValue:
+		return "object"
+	case *ArrayValue:
+		return "array"
+	case *StringValue:
+		return "string"
+	case *NumberValue:
+		return "number"
+	case *BooleanValue:
+		return "boolean"
+	case *NullValue:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// typeMismatch reports that v is not of the wanted type.
+func typeMismatch(v Value, want string) error {
+	return errors.NewAccessError("", fmt.Sprintf("expected %s, got %s", want, TypeName(v)))
+}
+
+// get looks up a path below v.
+func get(v Value, path string) (Value, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.NewAccessError("", err.Error())
+	}
+	return lookup(v, p)
+}
+
+// lookup follows path from v, reporting the first segment that cannot be followed.
+func lookup(v Value, path Path) (Value, error) {
+	for i, seg := range path {
+		at := path[:i].String()
+		switch c := v.(type) {
+		case *ObjectValue:
+			if seg.IsIndex() {
+				return nil, errors.NewAccessError(at, fmt.Sprintf("cannot index an object with [%d]", seg.Index))
+			}
+			child, ok := c.Pairs[seg.Key]
+			if !ok {
+				return nil, errors.NewAccessError(at, fmt.Sprintf("no member %q", seg.Key))
+			}
+			v = child
+		case *ArrayValue:
+			n := seg.Index
+			if !seg.IsIndex() {
+				if !isDigits(seg.Key) {
+					return nil, errors.NewAccessError(at, fmt.Sprintf("cannot look up member %q in an array", seg.Key))
+				}
+				n, _ = strconv.Atoi(seg.Key)
+			}
+			child, err := index(c, n)
+			if err != nil {
+				return nil, errors.NewAccessError(at, err.(*errors.AccessError).Message)
+			}
+			v = child
+		default:
+			return nil, errors.NewAccessError(at, fmt.Sprintf("cannot look up %s in a %s", Path{seg}, TypeName(v)))
+		}
+	}
+	return v, nil
+}
+
+func asString(v Value) (string, error) {
+	if s, ok := v.(*StringValue); ok {
+		return s.Value, nil
+	}
+	return "", typeMismatch(v, "string")
+}
+
+func asInt(v Value) (int64, error) {
+	n, ok := v.(*NumberValue)
+	if !ok {
+		return 0, typeMismatch(v, "number")
+	}
+	if n.Value != math.Trunc(n.Value) || n.Value < math.MinInt64 || n.Value >= math.MaxInt64 {
+		return 0, errors.NewAccessError("", fmt.Sprintf("number %v is not an int64", n.Value))
+	}
+	return int64(n.Value), nil
+}
+
+func asFloat(v Value) (float64, error) {
+	if n, ok := v.(*NumberValue); ok {
+		return n.Value, nil
+	}
+	return 0, typeMismatch(v, "number")
+}
+
+func asBool(v Value) (bool, error) {
+	if b, ok := v.(*BooleanValue); ok {
+		return b.Value, nil
+	}
+	return false, typeMismatch(v, "boolean")
+}
+
+func isNull(v Value) bool {
+	_, ok := v.(*NullValue)
+	return ok
+}
+
+func length(v Value) (int, error) {
+	switch c := v.(type) {
+	case *ObjectValue:
+		return len(c.Pairs), nil
+	case *ArrayValue:
+		return len(c.Elements), nil
+	default:
+		return 0, typeMismatch(v, "array or object")
+	}
+}
+
+func index(v Value, i int) (Value, error) {
+	a, ok := v.(*ArrayValue)
+	if !ok {
+		return nil, typeMismatch(v, "array")
+	}
+	if i < 0 || i >= len(a.Elements) {
+		return nil, errors.NewAccessError("", fmt.Sprintf("index %d out of range for array of length %d", i, len(a.Elements)))
+	}
+	return a.Elements[i], nil
+}
+
+func keys(v Value) ([]string, error) {
+	if o, ok := v.(*ObjectValue); ok {
+		return o.OrderedKeys(), nil
+	}
+	return nil, typeMismatch(v, "object")
+}
+
+// The Accessors methods of each value type, documented on Accessors.
+
+func (o *ObjectValue) Get(path string) (Value, error) {
+	return get(o, path)
+}
+
+func (o *ObjectValue) Exists(path string) bool {
+	_, err := get(o, path)
+	return err == nil
+}
+
+func (o *ObjectValue) String() (string, error) {
+	return asString(o)
+}
+
+func (o *ObjectValue) Int() (int64, error) {
+	return asInt(o)
+}
+
+func (o *ObjectValue) Float() (float64, error) {
+	return asFloat(o)
+}
+
+func (o *ObjectValue) Bool() (bool, error) {
+	return asBool(o)
+}
+
+func (o *ObjectValue) IsNull() bool {
+	return isNull(o)
+}
+
+func (o *ObjectValue) Len() (int, error) {
+	return length(o)
+}
+
+func (o *ObjectValue) Index(i int) (Value, error) {
+	return index(o, i)
+}
+
+func (o *ObjectValue) Keys() ([]string, error) {
+	return keys(o)
+}
+
+func (a *ArrayValue) Get(path string) (Value, error) {
+	return get(a, path)
+}
+
+func (a *ArrayValue) Exists(path string) bool {
+	_, err := get(a, path)
+	return err == nil
+}
+
+func (a *ArrayValue) String() (string, error) {
+	return asString(a)
+}
+
+func (a *ArrayValue) Int() (int64, error) {
+	return asInt(a)
+}
+
+func (a *ArrayValue) Float() (float64, error) {
+	return asFloat(a)
+}
+
+func (a *ArrayValue) Bool() (bool, error) {
+	return asBool(a)
+}
+
+func (a *ArrayValue) IsNull() bool {
+	return isNull(a)
+}
+
+func (a *ArrayValue) Len() (int, error) {
+	return length(a)
+}
+
+func (a *ArrayValue) Index(i int) (Value, error) {
+	return index(a, i)
+}
+
+func (a *ArrayValue) Keys() ([]string, error) {
+	return keys(a)
+}
+
+func (s *StringValue) Get(path string) (Value, error) {
+	return get(s, path)
+}
+
+func (s *StringValue) Exists(path string) bool {
+	_, err := get(s, path)
+	return err == nil
+}
+
+func (s *StringValue) String() (string, error) {
+	return asString(s)
+}
+
+func (s *StringValue) Int() (int64, error) {
+	return asInt(s)
+}
+
+func (s *StringValue) Float() (float64, error) {
+	return asFloat(s)
+}
+
+func (s *StringValue) Bool() (bool, error) {
+	return asBool(s)
+}
+
+func (s *StringValue) IsNull() bool {
+	return isNull(s)
+}
+
+func (s *StringValue) Len() (int, error) {
+	return length(s)
+}
+
+func (s *StringValue) Index(i int) (Value, error) {
+	return index(s, i)
+}
+
+func (s *StringValue) Keys() ([]string, error) {
+	return keys(s)
+}
+
+func (n *NumberValue) Get(path string) (Value, error) {
+	return get(n, path)
+}
+
+func (n *NumberValue) Exists(path string) bool {
+	_, err := get(n, path)
+	return err == nil
+}
+
+func (n *NumberValue) String() (string, error) {
+	return asString(n)
+}
+
+func (n *NumberValue) Int() (int64, error) {
+	return asInt(n)
+}
+
+func (n *NumberValue) Float() (float64, error) {
+	return asFloat(n)
+}
+
+func (n *NumberValue) Bool() (bool, error) {
+	return asBool(n)
+}
+
+func (n *NumberValue) IsNull() bool {
+	return isNull(n)
+}
+
+func (n *NumberValue) Len() (int, error) {
+	return length(n)
+}
+
+func (n *NumberValue) Index(i int) (Value, error) {
+	return index(n, i)
+}
+
+func (n *NumberValue) Keys() ([]string, error) {
+	return keys(n)
+}
+
+func (b *BooleanValue) Get(path string) (Value, error) {
+	return get(b, path)
+}
+
+func (b *BooleanValue) Exists(path string) bool {
+	_, err := get(b, path)
+	return err == nil
+}
+
+func (b *BooleanValue) String() (string, error) {
+	return asString(b)
+}
+
+func (b *BooleanValue) Int() (int64, error) {
+	return asInt(b)
+}
+
+func (b *BooleanValue) Float() (float64, error) {
+	return asFloat(b)
+}
+
+func (b *BooleanValue) Bool() (bool, error) {
+	return asBool(b)
+}
+
+func (b *BooleanValue) IsNull() bool {
+	return isNull(b)
+}
+
+func (b *BooleanValue) Len() (int, error) {
+	return length(b)
+}
+
+func (b *BooleanValue) Index(i int) (Value, error) {
+	return index(b, i)
+}
+
+func (b *BooleanValue) Keys() ([]string, error) {
+	return keys(b)
+}
+
+func (n *NullValue) Get(path string) (Value, error) {
+	return get(n, path)
+}
+
+func (n *NullValue) Exists(path string) bool {
+	_, err := get(n, path)
+	return err == nil
+}
+
+func (n *NullValue) String() (string, error) {
+	return asString(n)
+}
+
+func (n *NullValue) Int() (int64, error) {
+	return asInt(n)
+}
+
+func (n *NullValue) Float() (float64, error) {
+	return asFloat(n)
+}
+
+func (n *NullValue) Bool() (bool, error) {
+	return asBool(n)
+}
+
+func (n *NullValue) IsNull() bool {
+	return isNull(n)
+}
+
+func (n *NullValue) Len() (int, error) {
+	return length(n)
+}
+
+func (n *NullValue) Index(i int) (Value, error) {
+	return index(n, i)
+}
+
+func (n *NullValue) Keys() ([]string, error) {
+	return keys(n)
+}
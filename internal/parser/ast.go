@@ -1,5 +1,7 @@
 package parser
 
+import "sort"
+
 // Node represents a node in the AST.
 type Node interface {
 	TokenLiteral() string
@@ -8,12 +10,70 @@ type Node interface {
 // Value represents a value node in the AST.
 type Value interface {
 	Node
+	Accessors
 	valueNode()
 }
 
+// Comments holds the comments attached to an object member, an array element
+// or a whole document. They are only collected in JSONC mode.
+type Comments struct {
+	Leading  []string // Comments on the lines before the member, in source order
+	Trailing []string // Comments after the member on the same line
+}
+
 // ObjectValue represents an object value with key-value pairs.
 type ObjectValue struct {
-	Pairs map[string]Value
+	Pairs    map[string]Value
+	KeyOrder []string             // Member order as written in the source; see OrderedKeys
+	Comments map[string]*Comments // Comments attached to members, by key
+	Dangling []string             // Comments after the last member, before the closing brace
+}
+
+// OrderedKeys returns the keys of the object in source order. Keys missing
+// from KeyOrder, such as ones added directly to Pairs, follow in sorted order.
+func (o *ObjectValue) OrderedKeys() []string {
+	keys := make([]string, 0, len(o.Pairs))
+	seen := make(map[string]bool, len(o.Pairs))
+	for _, key := range o.KeyOrder {
+		if _, ok := o.Pairs[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range o.Pairs {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// Set adds or replaces a member, keeping its position and comments if it
+// already exists. It returns o, so calls can be chained.
+func (o *ObjectValue) Set(key string, value Value) *ObjectValue {
+	if o.Pairs == nil {
+		o.Pairs = make(map[string]Value)
+	}
+	if _, ok := o.Pairs[key]; !ok {
+		o.KeyOrder = append(o.KeyOrder, key)
+	}
+	o.Pairs[key] = value
+	return o
+}
+
+// Delete removes a member together with its comments.
+func (o *ObjectValue) Delete(key string) {
+	delete(o.Pairs, key)
+	delete(o.Comments, key)
+	for i, k := range o.KeyOrder {
+		if k == key {
+			o.KeyOrder = append(o.KeyOrder[:i:i], o.KeyOrder[i+1:]...)
+			break
+		}
+	}
 }
 
 func (o *ObjectValue) TokenLiteral() string {
@@ -25,6 +85,22 @@ func (o *ObjectValue) valueNode() {}
 // ArrayValue represents an array value with elements.
 type ArrayValue struct {
 	Elements []Value
+	Comments []*Comments // Comments attached to elements, by index; may be shorter than Elements
+	Dangling []string    // Comments after the last element, before the closing bracket
+}
+
+// ElementComments returns the comments attached to element i, or nil.
+func (a *ArrayValue) ElementComments(i int) *Comments {
+	if i < len(a.Comments) {
+		return a.Comments[i]
+	}
+	return nil
+}
+
+// Append adds elements to the end of the array. It returns a, so calls can be chained.
+func (a *ArrayValue) Append(values ...Value) *ArrayValue {
+	a.Elements = append(a.Elements, values...)
+	return a
 }
 
 func (a *ArrayValue) TokenLiteral() string {
@@ -74,3 +150,10 @@ func (n *NullValue) TokenLiteral() string {
 }
 
 func (n *NullValue) valueNode() {}
+
+// Document is a parsed input: the root value plus the comments before and
+// after it. Comments.Leading precede the root and Comments.Trailing follow it.
+type Document struct {
+	Root     Value
+	Comments Comments
+}
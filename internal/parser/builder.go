@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Object returns an empty object. Add members with Set:
+//
+//	Object().Set("a", Number(1)).Set("b", Array(String("x")))
+func Object() *ObjectValue {
+	return &ObjectValue{Pairs: make(map[string]Value)}
+}
+
+// Array returns an array of the given elements.
+func Array(elements ...Value) *ArrayValue {
+	return &ArrayValue{Elements: append([]Value{}, elements...)}
+}
+
+// String returns a string value.
+func String(s string) *StringValue {
+	return &StringValue{Value: s}
+}
+
+// Number returns a number value.
+func Number(f float64) *NumberValue {
+	return &NumberValue{Value: f}
+}
+
+// Bool returns a boolean value.
+func Bool(b bool) *BooleanValue {
+	return &BooleanValue{Value: b}
+}
+
+// Null returns a null value.
+func Null() *NullValue {
+	return &NullValue{}
+}
+
+// FromGo converts a Go value to an AST value. It accepts nil, booleans,
+// strings, all integer and floating-point types, json.Number, Value, and
+// maps with string keys, slices and arrays of those, through any number of
+// pointers and interfaces. Map members are added in sorted key order. Other
+// types, including structs, are an error, and so is a value that contains
+// itself through a pointer, map or slice.
+func FromGo(v any) (Value, error) {
+	return fromGo(reflect.ValueOf(v), nil, make(map[visit]bool))
+}
+
+// visit identifies a pointer, map or slice being converted. Slices also
+// record their length, as encoding/json does, since a slice and a shorter
+// slice of it share their first element.
+type visit struct {
+	ptr uintptr
+	len int
+}
+
+// valueType is the reflect.Type of the Value interface.
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
+
+// fromGo converts a reflected value; path locates it in error messages.
+// seen holds the pointers, maps and slices that contain it, to catch cycles.
+func fromGo(rv reflect.Value, path Path, seen map[visit]bool) (Value, error) {
+	if !rv.IsValid() {
+		return Null(), nil
+	}
+	if rv.Type().Implements(valueType) {
+		if v, ok := rv.Interface().(Value); ok && !(rv.Kind() == reflect.Pointer && rv.IsNil()) {
+			return v, nil
+		}
+		return Null(), nil
+	}
+	if n, ok := rv.Interface().(json.Number); ok {
+		f, err := ParseNumber(string(n))
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert json.Number %q%s: %v", n, path.At(), err)
+		}
+		return Number(f), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return Null(), nil
+		}
+		if rv.Kind() == reflect.Interface {
+			return fromGo(rv.Elem(), path, seen)
+		}
+		id := visit{ptr: rv.Pointer()}
+		if seen[id] {
+			return nil, cycleError(path)
+		}
+		seen[id] = true
+		defer delete(seen, id)
+		return fromGo(rv.Elem(), path, seen)
+	case reflect.Bool:
+		return Bool(rv.Bool()), nil
+	case reflect.String:
+		return String(rv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Number(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Number(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return Number(rv.Float()), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot convert %s%s: map keys must be strings", rv.Type(), path.At())
+		}
+		if rv.IsNil() {
+			return Null(), nil
+		}
+		id := visit{ptr: rv.Pointer()}
+		if seen[id] {
+			return nil, cycleError(path)
+		}
+		seen[id] = true
+		defer delete(seen, id)
+
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+
+		object := Object()
+		for _, key := range keys {
+			value, err := fromGo(rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())), append(path, KeySegment(key)), seen)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(key, value)
+		}
+		return object, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return Null(), nil
+			}
+			id := visit{ptr: rv.Pointer(), len: rv.Len()}
+			if seen[id] {
+				return nil, cycleError(path)
+			}
+			seen[id] = true
+			defer delete(seen, id)
+		}
+		array := Array()
+		for i := 0; i < rv.Len(); i++ {
+			value, err := fromGo(rv.Index(i), append(path, IndexSegment(i)), seen)
+			if err != nil {
+				return nil, err
+			}
+			array.Append(value)
+		}
+		return array, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s%s to a JSON value", rv.Type(), path.At())
+	}
+}
+
+// cycleError reports a value that contains itself at path.
+func cycleError(path Path) error {
+	return fmt.Errorf("cannot convert a cyclic value at %s", path.Describe())
+}
+
+// ToGo converts an AST value to the Go types encoding/json decodes into:
+// map[string]any, []any, string, float64, bool and nil.
+func ToGo(v Value) any {
+	switch n := v.(type) {
+	case *ObjectValue:
+		m := make(map[string]any, len(n.Pairs))
+		for key, value := range n.Pairs {
+			m[key] = ToGo(value)
+		}
+		return m
+	case *ArrayValue:
+		s := make([]any, len(n.Elements))
+		for i, elem := range n.Elements {
+			s[i] = ToGo(elem)
+		}
+		return s
+	case *StringValue:
+		return n.Value
+	case *NumberValue:
+		return n.Value
+	case *BooleanValue:
+		return n.Value
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// EqualOption changes how Equal compares values.
+type EqualOption func(*equalConfig)
+
+type equalConfig struct {
+	ignoreArrayOrder bool
+	ignorePaths      []Path
+}
+
+// IgnoreArrayOrder makes Equal compare arrays as multisets, so [1, 2]
+// equals [2, 1]. Paths below an element use that element's index in the
+// first value.
+func IgnoreArrayOrder() EqualOption {
+	return func(c *equalConfig) {
+		c.ignoreArrayOrder = true
+	}
+}
+
+// IgnorePaths makes Equal skip the values at the given paths, in the syntax
+// of ParsePath. A "*" segment matches any member or element, so
+// "items.*.id" ignores the id of every item. Object members at an ignored
+// path need not exist on both sides. Invalid paths are ignored.
+func IgnorePaths(paths ...string) EqualOption {
+	return func(c *equalConfig) {
+		for _, s := range paths {
+			if p, err := ParsePath(s); err == nil {
+				c.ignorePaths = append(c.ignorePaths, p)
+			}
+		}
+	}
+}
+
+// Equal reports whether two values are semantically equal: numbers are
+// compared by value, so 1.0 equals 1 and -0 equals 0, objects regardless of
+// member order, and comments are not compared. NaN equals NaN.
+func Equal(a, b Value, opts ...EqualOption) bool {
+	c := &equalConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c.equal(nil, a, b)
+}
+
+// ignored reports whether path matches one of the ignored paths.
+func (c *equalConfig) ignored(path Path) bool {
+	for _, ignore := range c.ignorePaths {
+		if len(ignore) == len(path) && matchPath(ignore, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath reports whether path matches pattern segment by segment.
+func matchPath(pattern, path Path) bool {
+	for i, seg := range pattern {
+		switch {
+		case !seg.IsIndex() && seg.Key == "*":
+		case seg.IsIndex() && path[i].IsIndex():
+			if seg.Index != path[i].Index {
+				return false
+			}
+		case !seg.IsIndex() && path[i].IsIndex():
+			// "items.0" names an element just like "items[0]"
+			if seg.Key != strconv.Itoa(path[i].Index) {
+				return false
+			}
+		default:
+			if seg != path[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *equalConfig) equal(path Path, a, b Value) bool {
+	if c.ignored(path) {
+		return true
+	}
+
+	switch x := a.(type) {
+	case *ObjectValue:
+		y, ok := b.(*ObjectValue)
+		if !ok {
+			return false
+		}
+		for key, value := range x.Pairs {
+			child := append(path, KeySegment(key))
+			other, ok := y.Pairs[key]
+			if !ok {
+				if !c.ignored(child) {
+					return false
+				}
+				continue
+			}
+			if !c.equal(child, value, other) {
+				return false
+			}
+		}
+		for key := range y.Pairs {
+			if _, ok := x.Pairs[key]; !ok && !c.ignored(append(path, KeySegment(key))) {
+				return false
+			}
+		}
+		return true
+	case *ArrayValue:
+		y, ok := b.(*ArrayValue)
+		if !ok || len(x.Elements) != len(y.Elements) {
+			return false
+		}
+		if c.ignoreArrayOrder {
+			return c.equalUnordered(path, x.Elements, y.Elements)
+		}
+		for i := range x.Elements {
+			if !c.equal(append(path, IndexSegment(i)), x.Elements[i], y.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return Compare(a, b) == 0
+	}
+}
+
+// equalUnordered matches every element of xs with a distinct equal element of ys.
+func (c *equalConfig) equalUnordered(path Path, xs, ys []Value) bool {
+	used := make([]bool, len(ys))
+	for i, x := range xs {
+		found := false
+		for j, y := range ys {
+			if !used[j] && c.equal(append(path, IndexSegment(i)), x, y) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// typeRank orders the JSON types for Compare.
+func typeRank(v Value) int {
+	switch x := v.(type) {
+	case *NullValue:
+		return 0
+	case *BooleanValue:
+		if x.Value {
+			return 2
+		}
+		return 1
+	case *NumberValue:
+		return 3
+	case *StringValue:
+		return 4
+	case *ArrayValue:
+		return 5
+	case *ObjectValue:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// Compare defines a total order on values, returning -1, 0 or +1. Values
+// of different types order as null < false < true < numbers < strings <
+// arrays < objects. Numbers compare by value with NaN first, strings by
+// code point, arrays element by element, and objects by their members in
+// sorted key order, comparing each key and then its value. Compare returns 0
+// exactly when Equal without options reports true.
+func Compare(a, b Value) int {
+	if r := cmp.Compare(typeRank(a), typeRank(b)); r != 0 {
+		return r
+	}
+
+	switch x := a.(type) {
+	case *NumberValue:
+		// cmp.Compare orders NaN before every other number and treats -0 as 0
+		return cmp.Compare(x.Value, b.(*NumberValue).Value)
+	case *StringValue:
+		return cmp.Compare(x.Value, b.(*StringValue).Value)
+	case *ArrayValue:
+		y := b.(*ArrayValue)
+		for i := 0; i < len(x.Elements) && i < len(y.Elements); i++ {
+			if r := Compare(x.Elements[i], y.Elements[i]); r != 0 {
+				return r
+			}
+		}
+		return cmp.Compare(len(x.Elements), len(y.Elements))
+	case *ObjectValue:
+		y := b.(*ObjectValue)
+		xKeys, yKeys := sortedKeys(x), sortedKeys(y)
+		for i := 0; i < len(xKeys) && i < len(yKeys); i++ {
+			if r := cmp.Compare(xKeys[i], yKeys[i]); r != 0 {
+				return r
+			}
+			if r := Compare(x.Pairs[xKeys[i]], y.Pairs[yKeys[i]]); r != 0 {
+				return r
+			}
+		}
+		return cmp.Compare(len(xKeys), len(yKeys))
+	default:
+		return 0
+	}
+}
+
+// sortedKeys returns the keys of an object in byte order.
+func sortedKeys(o *ObjectValue) []string {
+	keys := make([]string, 0, len(o.Pairs))
+	for key := range o.Pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SortValues sorts values in place by Compare.
+func SortValues(values []Value) {
+	slices.SortStableFunc(values, Compare)
+}
+
+// Hash returns a 64-bit FNV-1a hash of a value that is stable across runs
+// and processes. Values that are Equal without options hash the same, so the
+// hash can key maps and deduplicate values; member order and comments do
+// not affect it.
+func Hash(v Value) uint64 {
+	h := fnv.New64a()
+	writeHash(h, v)
+	return h.Sum64()
+}
+
+// writeHash writes an unambiguous encoding of v to h: a type tag, then
+// length-prefixed content.
+func writeHash(h hash.Hash64, v Value) {
+	h.Write([]byte{byte(typeRank(v))})
+	switch x := v.(type) {
+	case *NumberValue:
+		n := x.Value
+		switch {
+		case math.IsNaN(n):
+			n = math.NaN()
+		case n == 0:
+			n = 0 // Fold -0 into 0
+		}
+		writeHashUint(h, math.Float64bits(n))
+	case *StringValue:
+		writeHashString(h, x.Value)
+	case *ArrayValue:
+		writeHashUint(h, uint64(len(x.Elements)))
+		for _, elem := range x.Elements {
+			writeHash(h, elem)
+		}
+	case *ObjectValue:
+		keys := sortedKeys(x)
+		writeHashUint(h, uint64(len(keys)))
+		for _, key := range keys {
+			writeHashString(h, key)
+			writeHash(h, x.Pairs[key])
+		}
+	}
+}
+
+func writeHashUint(h hash.Hash64, n uint64) {
+	h.Write(binary.BigEndian.AppendUint64(nil, n))
+}
+
+func writeHashString(h hash.Hash64, s string) {
+	writeHashUint(h, uint64(len(s)))
+	h.Write([]byte(s))
+}
@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// Clone returns a deep copy of a value, including member order and comments.
+// The copy shares nothing with the original.
+func Clone(v Value) Value {
+	switch n := v.(type) {
+	case *ObjectValue:
+		c := &ObjectValue{
+			Pairs:    make(map[string]Value, len(n.Pairs)),
+			KeyOrder: slices.Clone(n.KeyOrder),
+			Dangling: slices.Clone(n.Dangling),
+		}
+		for key, value := range n.Pairs {
+			c.Pairs[key] = Clone(value)
+		}
+		if n.Comments != nil {
+			c.Comments = make(map[string]*Comments, len(n.Comments))
+			for key, comments := range n.Comments {
+				c.Comments[key] = cloneComments(comments)
+			}
+		}
+		return c
+	case *ArrayValue:
+		c := &ArrayValue{
+			Elements: make([]Value, len(n.Elements)),
+			Dangling: slices.Clone(n.Dangling),
+		}
+		for i, elem := range n.Elements {
+			c.Elements[i] = Clone(elem)
+		}
+		if n.Comments != nil {
+			c.Comments = make([]*Comments, len(n.Comments))
+			for i, comments := range n.Comments {
+				c.Comments[i] = cloneComments(comments)
+			}
+		}
+		return c
+	case *StringValue:
+		return &StringValue{Value: n.Value}
+	case *NumberValue:
+		return &NumberValue{Value: n.Value}
+	case *BooleanValue:
+		return &BooleanValue{Value: n.Value}
+	case *NullValue:
+		return &NullValue{}
+	default:
+		return v
+	}
+}
+
+func cloneComments(c *Comments) *Comments {
+	if c == nil {
+		return nil
+	}
+	return &Comments{Leading: slices.Clone(c.Leading), Trailing: slices.Clone(c.Trailing)}
+}
+
+// Immutable is a persistent document: it is never modified, so it can be
+// read from many goroutines at once without locking. With and Without
+// return new documents that share every subtree the change does not touch;
+// only the containers on the path to the change are copied.
+//
+// Values obtained from Root and Get are shared with the document and with
+// documents derived from it. They must be treated as read-only; use Clone
+// or Thaw for a copy that can be modified.
+type Immutable struct {
+	root Value
+}
+
+// Freeze returns an immutable document holding a deep copy of v, so later
+// changes to v do not affect it.
+func Freeze(v Value) *Immutable {
+	return &Immutable{root: Clone(v)}
+}
+
+// Root returns the root value of the document. It must not be modified.
+func (d *Immutable) Root() Value {
+	return d.root
+}
+
+// Get returns the value at a path, as Value.Get does. It must not be modified.
+func (d *Immutable) Get(path string) (Value, error) {
+	return d.root.Get(path)
+}
+
+// Thaw returns a deep, mutable copy of the document's root.
+func (d *Immutable) Thaw() Value {
+	return Clone(d.root)
+}
+
+// With returns a document with the value at path set to a deep copy of
+// value. An object member is added if it does not exist, and an array
+// element is appended when the index equals the array's length; every
+// container before the last segment must exist. The empty path replaces the
+// root.
+func (d *Immutable) With(path string, value Value) (*Immutable, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.NewAccessError("", err.Error())
+	}
+	root, err := d.update(p, Clone(value))
+	if err != nil {
+		return nil, err
+	}
+	return &Immutable{root: root}, nil
+}
+
+// Without returns a document with the member or element at path removed.
+// Removing an array element shifts the elements after it.
+func (d *Immutable) Without(path string) (*Immutable, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.NewAccessError("", err.Error())
+	}
+	if len(p) == 0 {
+		return nil, errors.NewAccessError("", "cannot remove the root")
+	}
+	root, err := d.update(p, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Immutable{root: root}, nil
+}
+
+// update returns a new root with the value at path replaced by value, or
+// removed if value is nil, copying only the containers along the path.
+func (d *Immutable) update(path Path, value Value) (Value, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := lookup(d.root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	at := path[:len(path)-1].String()
+	last := path[len(path)-1]
+
+	var updated Value
+	switch c := parent.(type) {
+	case *ObjectValue:
+		if last.IsIndex() {
+			return nil, errors.NewAccessError(at, fmt.Sprintf("cannot index an object with [%d]", last.Index))
+		}
+		o := shallowObject(c)
+		if value == nil {
+			if _, ok := o.Pairs[last.Key]; !ok {
+				return nil, errors.NewAccessError(at, fmt.Sprintf("no member %q", last.Key))
+			}
+			o.Delete(last.Key)
+		} else {
+			o.Set(last.Key, value)
+		}
+		updated = o
+	case *ArrayValue:
+		i := last.Index
+		if !last.IsIndex() {
+			if !isDigits(last.Key) {
+				return nil, errors.NewAccessError(at, fmt.Sprintf("cannot look up member %q in an array", last.Key))
+			}
+			i, _ = strconv.Atoi(last.Key)
+		}
+		limit := len(c.Elements)
+		if value != nil {
+			limit++ // Allow appending
+		}
+		if i >= limit {
+			return nil, errors.NewAccessError(at, fmt.Sprintf("index %d out of range for array of length %d", i, len(c.Elements)))
+		}
+		updated = updatedArray(c, i, value)
+	default:
+		return nil, errors.NewAccessError(at, fmt.Sprintf("cannot look up %s in a %s", Path{last}, TypeName(parent)))
+	}
+
+	return d.update(path[:len(path)-1], updated)
+}
+
+// shallowObject copies an object's member table, sharing the member values.
+func shallowObject(o *ObjectValue) *ObjectValue {
+	return &ObjectValue{
+		Pairs:    maps.Clone(o.Pairs),
+		KeyOrder: slices.Clone(o.KeyOrder),
+		Comments: maps.Clone(o.Comments),
+		Dangling: o.Dangling,
+	}
+}
+
+// updatedArray returns a copy of a with element i replaced, appended or,
+// when value is nil, removed. The other elements are shared.
+func updatedArray(a *ArrayValue, i int, value Value) *ArrayValue {
+	c := &ArrayValue{Elements: slices.Clone(a.Elements), Comments: slices.Clone(a.Comments), Dangling: a.Dangling}
+	switch {
+	case value == nil:
+		c.Elements = slices.Delete(c.Elements, i, i+1)
+		if i < len(c.Comments) {
+			c.Comments = slices.Delete(c.Comments, i, i+1)
+		}
+	case i == len(c.Elements):
+		c.Elements = append(c.Elements, value)
+	default:
+		c.Elements[i] = value
+	}
+	return c
+}
@@ -3,39 +3,96 @@ package parser
 import (
 	"fmt"
 	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+	"math"
 	"strconv"
+	"strings"
 )
 
 // Parser is responsible for parsing tokens into a structured format.
 type Parser struct {
-	l         *lexer.Lexer
-	curToken  lexer.Token
-	peekToken lexer.Token
-	errors    []string
+	l            *lexer.Lexer
+	curToken     lexer.Token
+	peekToken    lexer.Token
+	curComments  []lexer.Token // Comments directly preceding curToken (JSONC only)
+	peekComments []lexer.Token // Comments directly preceding peekToken (JSONC only)
+	prevLine     int           // Line of the most recently consumed token
+	dialect      lexer.Dialect
+	errors       []string
 }
 
 // New creates a new Parser instance.
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l}
+	p := &Parser{l: l, dialect: l.Dialect()}
 	// Initialize curToken and peekToken
 	p.nextToken()
 	p.nextToken()
 	return p
 }
 
-// nextToken advances the parser to the next token.
+// nextToken advances the parser to the next token, setting aside any
+// comments in front of it.
 func (p *Parser) nextToken() {
+	p.prevLine = p.curToken.Line
 	p.curToken = p.peekToken
+	p.curComments = p.peekComments
+	p.peekComments = nil
 	p.peekToken = p.l.NextToken()
+	for p.peekToken.Type == lexer.COMMENT {
+		p.peekComments = append(p.peekComments, p.peekToken)
+		p.peekToken = p.l.NextToken()
+	}
+}
+
+// Parse parses the input starting from the root and returns the root Value.
+func (p *Parser) Parse() (Value, error) {
+	doc, err := p.ParseDocument()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Root, nil
+}
+
+// ParseDocument parses the input like Parse and also returns the comments
+// before and after the root value.
+func (p *Parser) ParseDocument() (*Document, error) {
+	doc := &Document{Comments: Comments{Leading: p.takeComments(nil)}}
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.curToken.Type {
+	case lexer.EOF:
+	case lexer.ILLEGAL:
+		return nil, p.illegalTokenError()
+	default:
+		return nil, p.errorf("unexpected token %s after the root value", p.curToken.Type)
+	}
+	doc.Root = root
+	doc.Comments.Trailing = p.takeComments(nil)
+	return doc, nil
 }
 
-// Parse parses the input starting from the root and returns the root Node.
-func (p *Parser) Parse() (Node, error) {
-	if p.curToken.Type != lexer.LBRACE {
-		return nil, fmt.Errorf("expected '{', got %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+// takeComments consumes the comments preceding the current token. Comments on
+// the same line as the previous token are appended to prev.Trailing when prev
+// is not nil; the remaining ones are returned.
+func (p *Parser) takeComments(prev *Comments) []string {
+	var rest []string
+	for _, c := range p.curComments {
+		if prev != nil && c.Line == p.prevLine && len(rest) == 0 {
+			prev.Trailing = append(prev.Trailing, c.Literal)
+		} else {
+			rest = append(rest, c.Literal)
+		}
 	}
+	p.curComments = nil
+	return rest
+}
 
-	return p.parseObject()
+// allowsTrailingCommas reports whether the dialect accepts a comma before a closing bracket.
+func (p *Parser) allowsTrailingCommas() bool {
+	return p.dialect == lexer.JSON5 || p.dialect == lexer.JSONC
 }
 
 // parseObject parses an object and returns an ObjectValue node.
@@ -46,28 +103,51 @@ func (p *Parser) parseObject() (*ObjectValue, error) {
 
 	// Handle an empty object
 	if p.curToken.Type == lexer.RBRACE {
+		object.Dangling = p.takeComments(nil)
 		p.nextToken()
 		return object, nil
 	}
 
 	// Parse object contents.
+	var prev *Comments
+	var prevKey string
 	for p.curToken.Type != lexer.EOF {
+		comments := &Comments{Leading: p.takeComments(prev)}
+		if prev != nil {
+			p.setMemberComments(object, prevKey, prev)
+		}
+
 		key, err := p.parseKey()
 		if err != nil {
 			return nil, err
 		}
+		comments.Leading = append(comments.Leading, p.takeComments(nil)...)
 
 		if p.curToken.Type != lexer.COLON {
-			return nil, fmt.Errorf("expected ':', got %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+			return nil, p.errorf("expected ':', got %s", p.curToken.Type)
 		}
 		p.nextToken()
+		comments.Leading = append(comments.Leading, p.takeComments(nil)...)
 
 		value, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
 
+		if _, exists := object.Pairs[key]; !exists {
+			object.KeyOrder = append(object.KeyOrder, key)
+		}
 		object.Pairs[key] = value
+		prev, prevKey = comments, key
+
+		// Comments before a comma belong to this member; on their own lines
+		// before the closing brace they are left dangling.
+		if rest := p.takeComments(comments); p.curToken.Type == lexer.RBRACE {
+			object.Dangling = rest
+		} else {
+			comments.Trailing = append(comments.Trailing, rest...)
+		}
+		p.setMemberComments(object, key, comments)
 
 		if p.curToken.Type == lexer.RBRACE {
 			p.nextToken()
@@ -75,18 +155,41 @@ func (p *Parser) parseObject() (*ObjectValue, error) {
 		}
 
 		if p.curToken.Type != lexer.COMMA {
-			return nil, fmt.Errorf("expected ',' or '}', got %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+			return nil, p.errorf("expected ',' or '}', got %s", p.curToken.Type)
 		}
 		p.nextToken()
+
+		// JSON5 and JSONC allow a trailing comma before the closing brace.
+		if p.allowsTrailingCommas() && p.curToken.Type == lexer.RBRACE {
+			object.Dangling = p.takeComments(prev)
+			p.setMemberComments(object, key, prev)
+			p.nextToken()
+			return object, nil
+		}
 	}
 
-	return nil, fmt.Errorf("unexpected end of input")
+	return nil, p.errorf("unexpected end of input")
 }
 
-// parseKey parses a key in an object.
+// setMemberComments attaches comments to an object member, dropping empty ones.
+func (p *Parser) setMemberComments(object *ObjectValue, key string, comments *Comments) {
+	if len(comments.Leading) == 0 && len(comments.Trailing) == 0 {
+		delete(object.Comments, key)
+		return
+	}
+	if object.Comments == nil {
+		object.Comments = make(map[string]*Comments)
+	}
+	object.Comments[key] = comments
+}
+
+// parseKey parses a key in an object. JSON5 also accepts unquoted identifiers.
 func (p *Parser) parseKey() (string, error) {
-	if p.curToken.Type != lexer.STRING {
-		return "", fmt.Errorf("expected string key, got %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+	if p.curToken.Type == lexer.ILLEGAL {
+		return "", p.illegalTokenError()
+	}
+	if p.curToken.Type != lexer.STRING && !(p.dialect == lexer.JSON5 && p.curToken.Type == lexer.IDENT) {
+		return "", p.errorf("expected string key, got %s", p.curToken.Type)
 	}
 	key := p.curToken.Literal
 	p.nextToken()
@@ -101,10 +204,9 @@ func (p *Parser) parseValue() (Value, error) {
 		p.nextToken()
 		return value, nil
 	case lexer.NUMBER:
-		// Convert the string literal to a float64
-		numValue, err := strconv.ParseFloat(p.curToken.Literal, 64)
+		numValue, err := ParseNumber(p.curToken.Literal)
 		if err != nil {
-			return nil, fmt.Errorf("could not parse number: %v", err)
+			return nil, p.errorf("could not parse number: %v", err)
 		}
 		value := &NumberValue{Value: numValue}
 		p.nextToken()
@@ -125,8 +227,10 @@ func (p *Parser) parseValue() (Value, error) {
 		return p.parseObject()
 	case lexer.LBRACKET:
 		return p.parseArray()
+	case lexer.ILLEGAL:
+		return nil, p.illegalTokenError()
 	default:
-		return nil, fmt.Errorf("unexpected token %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+		return nil, p.errorf("unexpected token %s", p.curToken.Type)
 	}
 }
 
@@ -138,25 +242,101 @@ func (p *Parser) parseArray() (*ArrayValue, error) {
 
 	// Handle an empty array.
 	if p.curToken.Type == lexer.RBRACKET {
+		array.Dangling = p.takeComments(nil)
 		p.nextToken()
 		return array, nil
 	}
 
+	var prev *Comments
 	for {
+		comments := &Comments{Leading: p.takeComments(prev)}
+		if prev != nil {
+			p.setElementComments(array, len(array.Elements)-1, prev)
+		}
+		prev = comments
+
 		value, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
 		array.Elements = append(array.Elements, value)
 
+		// Comments before a comma belong to this element; on their own lines
+		// before the closing bracket they are left dangling.
+		if rest := p.takeComments(comments); p.curToken.Type == lexer.RBRACKET {
+			array.Dangling = rest
+		} else {
+			comments.Trailing = append(comments.Trailing, rest...)
+		}
+		p.setElementComments(array, len(array.Elements)-1, comments)
+
 		if p.curToken.Type == lexer.RBRACKET {
 			p.nextToken()
 			return array, nil
 		}
 
 		if p.curToken.Type != lexer.COMMA {
-			return nil, fmt.Errorf("expected ',' or ']', got %s at line %d, column %d", p.curToken.Type, p.curToken.Line, p.curToken.Column)
+			return nil, p.errorf("expected ',' or ']', got %s", p.curToken.Type)
 		}
 		p.nextToken()
+
+		// JSON5 and JSONC allow a trailing comma before the closing bracket.
+		if p.allowsTrailingCommas() && p.curToken.Type == lexer.RBRACKET {
+			array.Dangling = p.takeComments(prev)
+			p.setElementComments(array, len(array.Elements)-1, prev)
+			p.nextToken()
+			return array, nil
+		}
+	}
+}
+
+// setElementComments attaches comments to an array element, dropping empty ones.
+func (p *Parser) setElementComments(array *ArrayValue, i int, comments *Comments) {
+	if len(comments.Leading) == 0 && len(comments.Trailing) == 0 {
+		if i < len(array.Comments) {
+			array.Comments[i] = nil
+		}
+		return
+	}
+	for len(array.Comments) <= i {
+		array.Comments = append(array.Comments, nil)
+	}
+	array.Comments[i] = comments
+}
+
+// errorf creates a ParseError positioned at the current token.
+func (p *Parser) errorf(format string, args ...any) error {
+	return errors.NewParseError(p.curToken.Line, p.curToken.Column, fmt.Sprintf(format, args...))
+}
+
+// illegalTokenError reports the lexer's description of an ILLEGAL token.
+func (p *Parser) illegalTokenError() error {
+	return p.errorf("%s", p.curToken.Literal)
+}
+
+// ParseNumber converts a numeric literal to a float64. Besides the RFC 8259
+// grammar it understands the JSON5 forms produced by the lexer: hexadecimal
+// integers, an explicit sign, Infinity and NaN.
+func ParseNumber(literal string) (float64, error) {
+	digits := strings.TrimLeft(literal, "+-")
+	if digits == "NaN" {
+		return math.NaN(), nil
+	}
+	if !strings.HasPrefix(digits, "0x") && !strings.HasPrefix(digits, "0X") {
+		// Convert the string literal to a float64
+		return strconv.ParseFloat(strings.TrimPrefix(literal, "+"), 64)
+	}
+
+	var value float64
+	for _, ch := range digits[2:] {
+		d, err := strconv.ParseUint(string(ch), 16, 8)
+		if err != nil {
+			return 0, err
+		}
+		value = value*16 + float64(d)
+	}
+	if strings.HasPrefix(literal, "-") {
+		value = -value
 	}
+	return value, nil
 }
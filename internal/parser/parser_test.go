@@ -1,6 +1,10 @@
 package parser
 
 import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/letsmakecakes/jsonparser/internal/lexer"
@@ -68,6 +72,33 @@ func TestParser(t *testing.T) {
 			input:    `{"key": undefined}`,
 			hasError: true,
 		},
+		{
+			name:     "Array Root",
+			input:    `[1, "two"]`,
+			hasError: false,
+			expected: &ArrayValue{Elements: []Value{&NumberValue{Value: 1}, &StringValue{Value: "two"}}},
+		},
+		{
+			name:     "Scalar Root",
+			input:    ` "text" `,
+			hasError: false,
+			expected: &StringValue{Value: "text"},
+		},
+		{
+			name:     "Empty Input",
+			input:    "",
+			hasError: true,
+		},
+		{
+			name:     "Tokens After Root",
+			input:    `{} []`,
+			hasError: true,
+		},
+		{
+			name:     "Raw Control Character",
+			input:    "[\"a\tb\"]",
+			hasError: true,
+		},
 		{
 			name:     "Valid Key-Value Pair",
 			input:    `{"key": "value"}`,
@@ -99,6 +130,135 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestParserJSON5(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		hasError bool
+		expected Node
+	}{
+		{
+			name:     "Unquoted Keys and Trailing Commas",
+			input:    "{\n  // comment\n  key: 'value',\n  list: [1, 2,],\n}",
+			hasError: false,
+			expected: &ObjectValue{Pairs: map[string]Value{
+				"key": &StringValue{Value: "value"},
+				"list": &ArrayValue{Elements: []Value{
+					&NumberValue{Value: 1},
+					&NumberValue{Value: 2},
+				}},
+			}},
+		},
+		{
+			name:     "Number Extensions",
+			input:    `{"hex": 0x1F, "neg": -0xA, "lead": .5, "trail": 5., "plus": +3}`,
+			hasError: false,
+			expected: &ObjectValue{Pairs: map[string]Value{
+				"hex":   &NumberValue{Value: 31},
+				"neg":   &NumberValue{Value: -10},
+				"lead":  &NumberValue{Value: 0.5},
+				"trail": &NumberValue{Value: 5},
+				"plus":  &NumberValue{Value: 3},
+			}},
+		},
+		{
+			name:     "Double Trailing Comma",
+			input:    `{"key": 1,,}`,
+			hasError: true,
+		},
+		{
+			name:     "Unquoted Value",
+			input:    `{"key": value}`,
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lexer.NewWithDialect(tt.input, lexer.JSON5)
+			p := New(l)
+			node, err := p.Parse()
+
+			if tt.hasError && err == nil {
+				t.Errorf("expected error for input %q, got none", tt.input)
+			}
+			if !tt.hasError && err != nil {
+				t.Errorf("unexpected error for input %q: %v", tt.input, err)
+			}
+			if !tt.hasError && err == nil {
+				if !compareNodes(node, tt.expected) {
+					t.Errorf("expected node %v, got %v", tt.expected, node)
+				}
+			}
+
+			// The same input must be rejected in strict mode
+			if _, err := New(lexer.New(tt.input)).Parse(); err == nil {
+				t.Errorf("expected strict mode to reject %q", tt.input)
+			}
+		})
+	}
+}
+
+func TestParserJSON5SpecialNumbers(t *testing.T) {
+	l := lexer.NewWithDialect(`{"inf": Infinity, "ninf": -Infinity, "nan": NaN}`, lexer.JSON5)
+	node, err := New(l).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pairs := node.(*ObjectValue).Pairs
+	if v := pairs["inf"].(*NumberValue).Value; !math.IsInf(v, 1) {
+		t.Errorf("expected +Inf, got %v", v)
+	}
+	if v := pairs["ninf"].(*NumberValue).Value; !math.IsInf(v, -1) {
+		t.Errorf("expected -Inf, got %v", v)
+	}
+	if v := pairs["nan"].(*NumberValue).Value; !math.IsNaN(v) {
+		t.Errorf("expected NaN, got %v", v)
+	}
+}
+
+func TestParserJSONCComments(t *testing.T) {
+	input := `// header
+{
+  // about a
+  "a": 1, // after a
+  "b": [
+    "x", // after x
+    /* before y */ "y"
+  ]
+  // dangling
+}`
+
+	doc, err := New(lexer.NewWithDialect(input, lexer.JSONC)).ParseDocument()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(doc.Comments.Leading) != 1 || doc.Comments.Leading[0] != "// header" {
+		t.Errorf("unexpected document comments: %+v", doc.Comments)
+	}
+
+	root := doc.Root.(*ObjectValue)
+	if got := root.OrderedKeys(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("unexpected key order: %v", got)
+	}
+	if c := root.Comments["a"]; c == nil || c.Leading[0] != "// about a" || c.Trailing[0] != "// after a" {
+		t.Errorf("unexpected comments for a: %+v", c)
+	}
+	if len(root.Dangling) != 1 || root.Dangling[0] != "// dangling" {
+		t.Errorf("unexpected dangling comments: %v", root.Dangling)
+	}
+
+	array := root.Pairs["b"].(*ArrayValue)
+	if c := array.ElementComments(0); c == nil || c.Trailing[0] != "// after x" {
+		t.Errorf("unexpected comments for element 0: %+v", c)
+	}
+	if c := array.ElementComments(1); c == nil || c.Leading[0] != "/* before y */" {
+		t.Errorf("unexpected comments for element 1: %+v", c)
+	}
+}
+
 // compareNodes is a helper function to compare two AST nodes for equality.
 func compareNodes(node1, node2 Node) bool {
 	switch n1 := node1.(type) {
@@ -147,3 +307,578 @@ func compareNodes(node1, node2 Node) bool {
 		return false
 	}
 }
+
+func parseForWalk(t *testing.T, input string) Value {
+	t.Helper()
+	doc, err := New(lexer.NewWithDialect(input, lexer.JSONC)).ParseDocument()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return doc.Root
+}
+
+func TestWalk(t *testing.T) {
+	root := parseForWalk(t, `{"b": [1, {"x": true}], "skip": {"y": 2}, "a": null, "after": 3}`)
+
+	var events []string
+	completed := Walk(root, Funcs{
+		OnEnter: func(path Path, node Value) Action {
+			events = append(events, "enter "+path.String())
+			switch path.String() {
+			case "skip":
+				return SkipChildren
+			case "a":
+				return Stop
+			}
+			return Continue
+		},
+		OnLeave: func(path Path, node Value) Action {
+			events = append(events, "leave "+path.String())
+			return Continue
+		},
+	})
+
+	expected := []string{
+		"enter ", "enter b", "enter b[0]", "leave b[0]", "enter b[1]", "enter b[1].x", "leave b[1].x",
+		"leave b[1]", "leave b", "enter skip", "leave skip", "enter a",
+	}
+	if completed {
+		t.Errorf("expected Walk to report that it was stopped")
+	}
+	if strings.Join(events, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected events %q, got %q", expected, events)
+	}
+}
+
+func TestPath(t *testing.T) {
+	path := Path{KeySegment("servers"), IndexSegment(0), KeySegment("a.b"), KeySegment("x/~y"), KeySegment("_id2")}
+	if got, expected := path.String(), `servers[0]["a.b"]["x/~y"]._id2`; got != expected {
+		t.Errorf("expected String %s, got %s", expected, got)
+	}
+	if got, expected := path.Pointer(), `/servers/0/a.b/x~1~0y/_id2`; got != expected {
+		t.Errorf("expected Pointer %s, got %s", expected, got)
+	}
+	if got := (Path{}).Pointer(); got != "" {
+		t.Errorf("expected empty pointer for the root, got %q", got)
+	}
+	if got, expected := path.Describe(), path.String(); got != expected {
+		t.Errorf("expected Describe %s, got %s", expected, got)
+	}
+	if got := (Path{}).Describe(); got != "the root" {
+		t.Errorf("expected the root to be described as such, got %q", got)
+	}
+	if got, expected := path.At(), " at "+path.String(); got != expected {
+		t.Errorf("expected At %q, got %q", expected, got)
+	}
+	if got := (Path{}).At(); got != "" {
+		t.Errorf("expected nothing for the root, got %q", got)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	root := parseForWalk(t, "{\n  // gone\n  \"drop\": null,\n  \"n\": [1, null, 2, // two\n  null],\n  \"keep\": {\"m\": 3}\n}")
+
+	result := Rewrite(root, RewriteFuncs{
+		OnEnter: func(path Path, node Value) Action {
+			if path.String() == "keep" {
+				return SkipChildren
+			}
+			return Continue
+		},
+		OnLeave: func(path Path, node Value) (Value, Action) {
+			switch n := node.(type) {
+			case *NullValue:
+				return nil, Continue
+			case *NumberValue:
+				return &NumberValue{Value: n.Value * 10}, Continue
+			}
+			return node, Continue
+		},
+	})
+
+	object := result.(*ObjectValue)
+	if keys := object.OrderedKeys(); strings.Join(keys, ",") != "n,keep" {
+		t.Errorf("expected keys n,keep, got %v", keys)
+	}
+	if object.Comments["drop"] != nil {
+		t.Errorf("expected comments of deleted member to be removed")
+	}
+	expected := &ArrayValue{Elements: []Value{&NumberValue{Value: 10}, &NumberValue{Value: 20}}}
+	array := object.Pairs["n"].(*ArrayValue)
+	if !compareNodes(array, expected) {
+		t.Errorf("expected %v, got %v", expected, array)
+	}
+	if c := array.ElementComments(1); c == nil || c.Trailing[0] != "// two" {
+		t.Errorf("expected comment to follow its element, got %v", array.Comments)
+	}
+	if m := object.Pairs["keep"].(*ObjectValue).Pairs["m"].(*NumberValue); m.Value != 3 {
+		t.Errorf("expected skipped subtree to be unchanged, got %v", m.Value)
+	}
+
+	if got := Rewrite(&NullValue{}, RewriteFuncs{OnLeave: func(Path, Value) (Value, Action) { return nil, Continue }}); got != nil {
+		t.Errorf("expected deleted root to be nil, got %v", got)
+	}
+}
+
+func TestRewrite_Stop(t *testing.T) {
+	root := parseForWalk(t, `[1, 2, 3, 4]`)
+	result := Rewrite(root, RewriteFuncs{OnLeave: func(path Path, node Value) (Value, Action) {
+		if len(path) == 1 && path[0].Index == 1 {
+			return nil, Stop
+		}
+		return node, Continue
+	}})
+
+	expected := &ArrayValue{Elements: []Value{&NumberValue{Value: 1}, &NumberValue{Value: 3}, &NumberValue{Value: 4}}}
+	if !compareNodes(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	root, err := New(lexer.New(`{
+		"server": {"port": 8080, "host": "localhost", "ratio": 0.5, "tls": false, "proxy": null},
+		"servers": [{"name": "a"}, {"name": "b"}],
+		"tags": {"a.b": 1}
+	}`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mustGet := func(path string) Value {
+		t.Helper()
+		v, err := root.Get(path)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", path, err)
+		}
+		return v
+	}
+
+	if port, err := mustGet("server.port").Int(); err != nil || port != 8080 {
+		t.Errorf("expected port 8080, got %d (%v)", port, err)
+	}
+	if host, err := mustGet("server.host").String(); err != nil || host != "localhost" {
+		t.Errorf("expected host localhost, got %q (%v)", host, err)
+	}
+	if ratio, err := mustGet("server.ratio").Float(); err != nil || ratio != 0.5 {
+		t.Errorf("expected ratio 0.5, got %v (%v)", ratio, err)
+	}
+	if tls, err := mustGet("server.tls").Bool(); err != nil || tls {
+		t.Errorf("expected tls false, got %v (%v)", tls, err)
+	}
+	if !mustGet("server.proxy").IsNull() || mustGet("server.port").IsNull() {
+		t.Errorf("unexpected IsNull results")
+	}
+	for _, path := range []string{"servers[1].name", "servers.1.name", `tags["a.b"]`, ""} {
+		if !root.Exists(path) {
+			t.Errorf("expected %q to exist", path)
+		}
+	}
+
+	servers := mustGet("servers")
+	if n, err := servers.Len(); err != nil || n != 2 {
+		t.Errorf("expected 2 servers, got %d (%v)", n, err)
+	}
+	if second, err := servers.Index(1); err != nil || !second.Exists("name") {
+		t.Errorf("expected second server, got %v (%v)", second, err)
+	}
+	if keys, err := mustGet("server").Keys(); err != nil || strings.Join(keys, ",") != "port,host,ratio,tls,proxy" {
+		t.Errorf("unexpected keys %v (%v)", keys, err)
+	}
+
+	failures := []struct {
+		name    string
+		call    func() error
+		message string
+	}{
+		{"Missing Member", func() error { _, err := root.Get("server.missing"); return err }, `access error at server: no member "missing"`},
+		{"Index Out Of Range", func() error { _, err := root.Get("servers[5]"); return err }, "access error at servers: index 5 out of range for array of length 2"},
+		{"Lookup In Scalar", func() error { _, err := root.Get("server.port.x"); return err }, "access error at server.port: cannot look up x in a number"},
+		{"Member Of Array", func() error { _, err := root.Get("servers.name"); return err }, `access error at servers: cannot look up member "name" in an array`},
+		{"Invalid Path", func() error { _, err := root.Get("server..port"); return err }, `access error: invalid path "server..port": empty key at offset 7`},
+		{"Wrong Type", func() error { _, err := mustGet("server.host").Int(); return err }, "access error: expected number, got string"},
+		{"Not An Integer", func() error { _, err := mustGet("server.ratio").Int(); return err }, "access error: number 0.5 is not an int64"},
+		{"Len Of Scalar", func() error { _, err := mustGet("server.tls").Len(); return err }, "access error: expected array or object, got boolean"},
+		{"Keys Of Array", func() error { _, err := servers.Keys(); return err }, "access error: expected object, got array"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || err.Error() != tt.message {
+				t.Errorf("expected error %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Path
+		hasError bool
+	}{
+		{"", nil, false},
+		{"a", Path{KeySegment("a")}, false},
+		{"a.b-c[2][0]", Path{KeySegment("a"), KeySegment("b-c"), IndexSegment(2), IndexSegment(0)}, false},
+		{`[0]["x.y\"z"].w`, Path{IndexSegment(0), KeySegment(`x.y"z`), KeySegment("w")}, false},
+		{".a", nil, true},
+		{"a.", nil, true},
+		{"a[x]", nil, true},
+		{"a[-1]", nil, true},
+		{`a["b]`, nil, true},
+		{"a[0]b", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePath(tt.input)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("expected error for %q, got %v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.expected.String() || len(got) != len(tt.expected) {
+			t.Errorf("expected %v for %q, got %v", tt.expected, tt.input, got)
+		}
+		if roundTrip, err := ParsePath(got.String()); err != nil || roundTrip.Pointer() != got.Pointer() {
+			t.Errorf("path %q does not round-trip through String: %v", tt.input, err)
+		}
+	}
+}
+
+func mustParse(t *testing.T, input string) Value {
+	t.Helper()
+	v, err := New(lexer.NewWithDialect(input, lexer.JSON5)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error for %q: %v", input, err)
+	}
+	return v
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		opts     []EqualOption
+		expected bool
+	}{
+		{"Numbers By Value", `[1.0, -0, 1e2]`, `[1, 0, 100]`, nil, true},
+		{"Member Order", `{"a": 1, "b": [true]}`, `{"b": [true], "a": 1}`, nil, true},
+		{"Comments Ignored", "{/* c */ \"a\": 1}", `{"a": 1}`, nil, true},
+		{"NaN", `[NaN]`, `[NaN]`, nil, true},
+		{"Different Types", `{"a": 1}`, `{"a": "1"}`, nil, false},
+		{"Missing Member", `{"a": 1}`, `{"a": 1, "b": 2}`, nil, false},
+		{"Array Order", `[1, 2, 2]`, `[2, 1, 2]`, nil, false},
+		{"Array Order Ignored", `[1, 2, 2]`, `[2, 1, 2]`, []EqualOption{IgnoreArrayOrder()}, true},
+		{"Array Multiset", `[1, 1, 2]`, `[1, 2, 2]`, []EqualOption{IgnoreArrayOrder()}, false},
+		{"Ignored Path", `{"id": 1, "v": 2}`, `{"id": 9, "v": 2}`, []EqualOption{IgnorePaths("id")}, true},
+		{"Ignored Missing Member", `{"meta": {}, "v": 2}`, `{"v": 2}`, []EqualOption{IgnorePaths("meta")}, true},
+		{"Wildcard Path", `{"items": [{"id": 1, "n": "a"}, {"id": 2, "n": "b"}]}`, `{"items": [{"id": 7, "n": "a"}, {"n": "b"}]}`, []EqualOption{IgnorePaths("items.*.id")}, true},
+		{"Index Path", `[1, 2, 3]`, `[1, 5, 3]`, []EqualOption{IgnorePaths("[1]")}, true},
+		{"Other Path Still Compared", `{"id": 1, "v": 2}`, `{"id": 1, "v": 3}`, []EqualOption{IgnorePaths("id")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+			if got := Equal(a, b, tt.opts...); got != tt.expected {
+				t.Errorf("expected Equal=%v for %s and %s", tt.expected, tt.a, tt.b)
+			}
+			if got := Equal(b, a, tt.opts...); got != tt.expected {
+				t.Errorf("expected Equal=%v for %s and %s", tt.expected, tt.b, tt.a)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Values in ascending order
+	ordered := []string{
+		`null`, `false`, `true`, `NaN`, `-Infinity`, `-1`, `0`, `2.5`, `""`, `"a"`, `"b"`,
+		`[]`, `[1]`, `[1, 0]`, `[2]`, `{}`, `{"a": 2}`, `{"a": 2, "b": 0}`, `{"b": 0}`,
+	}
+	values := make([]Value, len(ordered))
+	for i, input := range ordered {
+		values[i] = mustParse(t, input)
+	}
+
+	for i := range values {
+		for j := range values {
+			if got, expected := Compare(values[i], values[j]), cmpInt(i, j); got != expected {
+				t.Errorf("Compare(%s, %s) = %d, expected %d", ordered[i], ordered[j], got, expected)
+			}
+		}
+	}
+
+	shuffled := []Value{values[5], values[18], values[0], values[11], values[9]}
+	SortValues(shuffled)
+	for i, expected := range []Value{values[0], values[5], values[9], values[11], values[18]} {
+		if shuffled[i] != expected {
+			t.Errorf("unexpected order after SortValues at %d", i)
+		}
+	}
+
+	if Compare(mustParse(t, `{"x": [1.0, {"y": -0}]}`), mustParse(t, `{"x": [1, {"y": 0}]}`)) != 0 {
+		t.Errorf("expected semantically equal values to compare equal")
+	}
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := mustParse(t, `{"b": [1.0, -0, "x"], "a": {"n": null}}`)
+	b := mustParse(t, `{"a": {"n": null}, "b": [1, 0, "x"]}`)
+	if Hash(a) != Hash(b) {
+		t.Errorf("expected equal values to hash the same")
+	}
+
+	// The hash is FNV-1a of a fixed encoding, so it is stable across processes
+	if got := Hash(&NullValue{}); got != 0xaf63bd4c8601b7df {
+		t.Errorf("expected null to hash to af63bd4c8601b7df, got %x", got)
+	}
+
+	distinct := []string{`null`, `false`, `0`, `""`, `[]`, `{}`, `["a", "b"]`, `["ab"]`, `[["a"], "b"]`, `{"a": "b"}`, `{"ab": ""}`, `[1, 2]`, `[2, 1]`}
+	seen := make(map[uint64]string)
+	for _, input := range distinct {
+		h := Hash(mustParse(t, input))
+		if other, ok := seen[h]; ok {
+			t.Errorf("hash collision between %s and %s", input, other)
+		}
+		seen[h] = input
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := parseForWalk(t, "{\n  // port\n  \"port\": 80,\n  \"hosts\": [\"a\", // first\n  \"b\"]\n}")
+	clone := Clone(original)
+	if !compareNodes(original, clone) || !Equal(original, clone) {
+		t.Fatalf("expected clone to equal original")
+	}
+
+	object := clone.(*ObjectValue)
+	object.Set("port", &NumberValue{Value: 8080})
+	object.Comments["port"].Leading[0] = "// changed"
+	hosts := object.Pairs["hosts"].(*ArrayValue)
+	hosts.Elements[0].(*StringValue).Value = "z"
+	hosts.Comments[0].Trailing[0] = "// changed"
+
+	if port, _ := original.Get("port"); port.(*NumberValue).Value != 80 {
+		t.Errorf("expected original port to be unchanged")
+	}
+	if host, _ := original.Get("hosts[0]"); host.(*StringValue).Value != "a" {
+		t.Errorf("expected original host to be unchanged")
+	}
+	if c := original.(*ObjectValue).Comments["port"]; c.Leading[0] != "// port" {
+		t.Errorf("expected original comments to be unchanged, got %v", c.Leading)
+	}
+	if c := original.(*ObjectValue).Pairs["hosts"].(*ArrayValue).ElementComments(0); c.Trailing[0] != "// first" {
+		t.Errorf("expected original element comments to be unchanged, got %v", c.Trailing)
+	}
+}
+
+func TestImmutable(t *testing.T) {
+	source := mustParse(t, `{"server": {"port": 80, "tls": {"on": false}}, "hosts": ["a", "b"], "other": {"x": 1}}`)
+	doc := Freeze(source)
+	source.(*ObjectValue).Set("server", &NullValue{})
+	if !doc.Root().Exists("server.port") {
+		t.Fatalf("expected frozen document to be independent of its source")
+	}
+
+	updated, err := doc.With("server.port", &NumberValue{Value: 8080})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port, _ := doc.Get("server.port"); port.(*NumberValue).Value != 80 {
+		t.Errorf("expected original document to be unchanged")
+	}
+	if port, _ := updated.Get("server.port"); port.(*NumberValue).Value != 8080 {
+		t.Errorf("expected updated port 8080")
+	}
+
+	// Untouched subtrees are shared, the path to the change is copied
+	for path, shared := range map[string]bool{"other": true, "hosts": true, "server.tls": true, "server": false, "": false} {
+		before, _ := doc.Get(path)
+		after, _ := updated.Get(path)
+		if (before == after) != shared {
+			t.Errorf("expected shared=%v for %q", shared, path)
+		}
+	}
+
+	edits := []struct {
+		name     string
+		apply    func(*Immutable) (*Immutable, error)
+		expected string
+	}{
+		{"Add Member", func(d *Immutable) (*Immutable, error) { return d.With("server.host", &StringValue{Value: "h"}) }, `{"server": {"port": 80, "tls": {"on": false}, "host": "h"}, "hosts": ["a", "b"], "other": {"x": 1}}`},
+		{"Append Element", func(d *Immutable) (*Immutable, error) { return d.With("hosts[2]", &StringValue{Value: "c"}) }, `{"server": {"port": 80, "tls": {"on": false}}, "hosts": ["a", "b", "c"], "other": {"x": 1}}`},
+		{"Replace Element", func(d *Immutable) (*Immutable, error) { return d.With("hosts.0", &NullValue{}) }, `{"server": {"port": 80, "tls": {"on": false}}, "hosts": [null, "b"], "other": {"x": 1}}`},
+		{"Remove Member", func(d *Immutable) (*Immutable, error) { return d.Without("server.tls") }, `{"server": {"port": 80}, "hosts": ["a", "b"], "other": {"x": 1}}`},
+		{"Remove Element", func(d *Immutable) (*Immutable, error) { return d.Without("hosts[0]") }, `{"server": {"port": 80, "tls": {"on": false}}, "hosts": ["b"], "other": {"x": 1}}`},
+		{"Replace Root", func(d *Immutable) (*Immutable, error) { return d.With("", &ArrayValue{Elements: []Value{}}) }, `[]`},
+	}
+	for _, tt := range edits {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.apply(doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := mustParse(t, tt.expected); !Equal(got.Root(), expected) {
+				t.Errorf("unexpected result for %s", tt.name)
+			}
+		})
+	}
+
+	for _, path := range []string{"missing.port", "hosts[3]", "server.port.x", "hosts.name", "server[0]", "a..b"} {
+		if _, err := doc.With(path, &NullValue{}); err == nil {
+			t.Errorf("expected error for With(%q)", path)
+		}
+	}
+	for _, path := range []string{"", "server.missing", "hosts[2]"} {
+		if _, err := doc.Without(path); err == nil {
+			t.Errorf("expected error for Without(%q)", path)
+		}
+	}
+}
+
+func TestImmutable_ConcurrentReaders(t *testing.T) {
+	doc := Freeze(mustParse(t, `{"counter": 0, "items": [1, 2, 3]}`))
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				if _, err := doc.Get("items[2]"); err != nil {
+					t.Error(err)
+				}
+				_ = Hash(doc.Root())
+			}
+			done <- true
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		if _, err := doc.With("counter", &NumberValue{Value: float64(j)}); err != nil {
+			t.Error(err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	built := Object().
+		Set("a", Number(1)).
+		Set("b", Array(String("x"), Bool(true), Null())).
+		Set("c", Object().Set("d", Array()))
+	built.Set("a", Number(2))
+
+	expected := mustParse(t, `{"a": 2, "b": ["x", true, null], "c": {"d": []}}`)
+	if !Equal(built, expected) {
+		t.Errorf("built value does not match %v", expected)
+	}
+	if keys, _ := built.Keys(); strings.Join(keys, ",") != "a,b,c" {
+		t.Errorf("expected insertion order a,b,c, got %v", keys)
+	}
+
+	list := Array(Number(1))
+	list.Append(Number(2), Number(3))
+	if n, _ := list.Len(); n != 3 {
+		t.Errorf("expected 3 elements, got %d", n)
+	}
+}
+
+func TestFromGo(t *testing.T) {
+	type name string
+	n := 5
+	var nilMap map[string]int
+
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{"Nil", nil, `null`},
+		{"Scalars", []any{true, "s", 1, int8(-2), uint64(3), float32(0.5), 2.25}, `[true, "s", 1, -2, 3, 0.5, 2.25]`},
+		{"Map", map[string]any{"b": []string{"x"}, "a": map[string]int{"n": 1}}, `{"a": {"n": 1}, "b": ["x"]}`},
+		{"Named Types", map[name]name{"k": "v"}, `{"k": "v"}`},
+		{"Pointers", []any{&n, (*int)(nil), nilMap, [2]bool{true, false}}, `[5, null, null, [true, false]]`},
+		{"JSON Number", json.Number("1e3"), `1000`},
+		{"AST Value", map[string]any{"v": Array(Number(1))}, `{"v": [1]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromGo(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := mustParse(t, tt.expected); !Equal(got, expected) {
+				t.Errorf("expected %s, got %#v", tt.expected, got)
+			}
+		})
+	}
+
+	// Map members are added in sorted order
+	got, _ := FromGo(map[string]int{"z": 1, "a": 2, "m": 3})
+	if keys, _ := got.Keys(); strings.Join(keys, ",") != "a,m,z" {
+		t.Errorf("expected sorted keys, got %v", keys)
+	}
+
+	failures := map[string]any{
+		"cannot convert map[int]string: map keys must be strings": map[int]string{1: "a"},
+		"cannot convert struct {} at items[1] to a JSON value":    map[string]any{"items": []any{1, struct{}{}}},
+		"cannot convert chan int to a JSON value":                 make(chan int),
+	}
+	for expected, input := range failures {
+		if _, err := FromGo(input); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+
+	// Values that contain themselves are an error; shared ones are not
+	loop := map[string]any{"a": 1}
+	loop["self"] = []any{loop}
+	list := []any{1, nil}
+	list[1] = list
+	var ptr any
+	ptr = &ptr
+	cycles := map[string]any{
+		"cannot convert a cyclic value at self[0]":  loop,
+		"cannot convert a cyclic value at [1]":      list,
+		"cannot convert a cyclic value at the root": &ptr,
+	}
+	for expected, input := range cycles {
+		if _, err := FromGo(input); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+	shared := []int{1}
+	if got, err := FromGo(map[string]any{"a": shared, "b": &shared}); err != nil || !Equal(got, mustParse(t, `{"a": [1], "b": [1]}`)) {
+		t.Errorf("expected shared values to convert, got %v, %v", got, err)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	value := mustParse(t, `{"a": [1, "x", true, null, {}], "b": 2.5}`)
+	expected := map[string]any{"a": []any{1.0, "x", true, nil, map[string]any{}}, "b": 2.5}
+	if got := ToGo(value); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %#v, got %#v", expected, got)
+	}
+
+	back, err := FromGo(ToGo(value))
+	if err != nil || !Equal(back, value) {
+		t.Errorf("expected FromGo(ToGo(v)) to equal v, got %v (%v)", back, err)
+	}
+}
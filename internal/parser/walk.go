@@ -0,0 +1,336 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is one step of a Path: an object member key or an array index.
+type Segment struct {
+	Key   string // Member key, for object members
+	Index int    // Element index, for array elements; -1 for object members
+}
+
+// KeySegment returns the segment of an object member.
+func KeySegment(key string) Segment {
+	return Segment{Key: key, Index: -1}
+}
+
+// IndexSegment returns the segment of an array element.
+func IndexSegment(index int) Segment {
+	return Segment{Index: index}
+}
+
+// IsIndex reports whether the segment is an array index.
+func (s Segment) IsIndex() bool {
+	return s.Index >= 0
+}
+
+// Path is the location of a value below the root. The root's path is empty.
+type Path []Segment
+
+// String formats the path with dots between keys and brackets around
+// indices, e.g. servers[0].port. Keys that are not identifiers are quoted in
+// brackets: tags["a.b"].
+func (p Path) String() string {
+	var sb strings.Builder
+	for _, seg := range p {
+		switch {
+		case seg.IsIndex():
+			sb.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+		case isPathIdentifier(seg.Key):
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(seg.Key)
+		default:
+			sb.WriteString("[" + strconv.Quote(seg.Key) + "]")
+		}
+	}
+	return sb.String()
+}
+
+// ParsePath parses a path in the syntax produced by Path.String. Keys may
+// contain any character other than '.' and '['; others must be quoted.
+func ParsePath(s string) (Path, error) {
+	var path Path
+	for i := 0; i < len(s); {
+		if s[i] == '[' {
+			seg, n, err := parseBracket(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %v", s, err)
+			}
+			path = append(path, seg)
+			i += n
+			continue
+		}
+
+		if len(path) > 0 {
+			if s[i] != '.' {
+				return nil, fmt.Errorf("invalid path %q: expected '.' or '[' at offset %d", s, i)
+			}
+			i++
+		}
+		j := i
+		for j < len(s) && s[j] != '.' && s[j] != '[' {
+			j++
+		}
+		if j == i {
+			return nil, fmt.Errorf("invalid path %q: empty key at offset %d", s, i)
+		}
+		path = append(path, KeySegment(s[i:j]))
+		i = j
+	}
+	return path, nil
+}
+
+// parseBracket parses a [index] or ["key"] segment at the start of s and
+// returns it with its length.
+func parseBracket(s string) (Segment, int, error) {
+	if strings.HasPrefix(s, `["`) {
+		quoted, err := strconv.QuotedPrefix(s[1:])
+		if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+			return Segment{}, 0, fmt.Errorf("unterminated quoted key")
+		}
+		key, _ := strconv.Unquote(quoted)
+		return KeySegment(key), len(quoted) + 2, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return Segment{}, 0, fmt.Errorf("missing ']'")
+	}
+	index, err := strconv.Atoi(s[1:end])
+	if err != nil || index < 0 || !isDigits(s[1:end]) {
+		return Segment{}, 0, fmt.Errorf("invalid index %q", s[1:end])
+	}
+	return IndexSegment(index), end + 1, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Describe formats the path for error messages like String, but names the
+// empty path "the root".
+func (p Path) Describe() string {
+	if len(p) == 0 {
+		return "the root"
+	}
+	return p.String()
+}
+
+// At formats the path as the end of an error message, " at items[1]", or
+// as the empty string for the root.
+func (p Path) At() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return " at " + p.String()
+}
+
+// Pointer formats the path as an RFC 6901 JSON Pointer.
+func (p Path) Pointer() string {
+	var sb strings.Builder
+	for _, seg := range p {
+		sb.WriteByte('/')
+		if seg.IsIndex() {
+			sb.WriteString(strconv.Itoa(seg.Index))
+		} else {
+			sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(seg.Key))
+		}
+	}
+	return sb.String()
+}
+
+// Clone returns a copy of the path that does not share its backing array.
+// Paths passed to visitors are reused, so they must be cloned to be kept.
+func (p Path) Clone() Path {
+	return append(Path(nil), p...)
+}
+
+// isPathIdentifier reports whether a key can appear unquoted in Path.String.
+func isPathIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r != '_' && r != '$' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && (i == 0 || !('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// Action tells Walk and Rewrite how to continue after a callback.
+type Action int
+
+const (
+	Continue     Action = iota // Visit the node's children, then carry on
+	SkipChildren               // Do not visit the node's children (Enter only)
+	Stop                       // End the traversal
+)
+
+// Visitor is called by Walk for every value in the tree. Enter is called
+// before a value's children are visited and Leave after them; Leave is not
+// called for values whose Enter returned Stop.
+type Visitor interface {
+	Enter(path Path, node Value) Action
+	Leave(path Path, node Value) Action
+}
+
+// Funcs adapts a pair of functions to a Visitor. Either may be nil.
+type Funcs struct {
+	OnEnter func(path Path, node Value) Action
+	OnLeave func(path Path, node Value) Action
+}
+
+func (f Funcs) Enter(path Path, node Value) Action {
+	if f.OnEnter == nil {
+		return Continue
+	}
+	return f.OnEnter(path, node)
+}
+
+func (f Funcs) Leave(path Path, node Value) Action {
+	if f.OnLeave == nil {
+		return Continue
+	}
+	return f.OnLeave(path, node)
+}
+
+// Walk traverses the tree below root depth-first, visiting object members in
+// source order. It returns false if a callback stopped the traversal.
+func Walk(root Value, v Visitor) bool {
+	return walk(nil, root, v)
+}
+
+func walk(path Path, node Value, v Visitor) bool {
+	switch v.Enter(path, node) {
+	case Stop:
+		return false
+	case SkipChildren:
+		return v.Leave(path, node) != Stop
+	}
+
+	switch n := node.(type) {
+	case *ObjectValue:
+		for _, key := range n.OrderedKeys() {
+			if !walk(append(path, KeySegment(key)), n.Pairs[key], v) {
+				return false
+			}
+		}
+	case *ArrayValue:
+		for i, elem := range n.Elements {
+			if !walk(append(path, IndexSegment(i)), elem, v) {
+				return false
+			}
+		}
+	}
+	return v.Leave(path, node) != Stop
+}
+
+// Rewriter is called by Rewrite for every value in the tree. Enter works as
+// for a Visitor. Leave is called after the value's children have been
+// rewritten and returns the value to put in its place, or nil to delete it.
+type Rewriter interface {
+	Enter(path Path, node Value) Action
+	Leave(path Path, node Value) (Value, Action)
+}
+
+// RewriteFuncs adapts a pair of functions to a Rewriter. Either may be nil.
+type RewriteFuncs struct {
+	OnEnter func(path Path, node Value) Action
+	OnLeave func(path Path, node Value) (Value, Action)
+}
+
+func (f RewriteFuncs) Enter(path Path, node Value) Action {
+	if f.OnEnter == nil {
+		return Continue
+	}
+	return f.OnEnter(path, node)
+}
+
+func (f RewriteFuncs) Leave(path Path, node Value) (Value, Action) {
+	if f.OnLeave == nil {
+		return node, Continue
+	}
+	return f.OnLeave(path, node)
+}
+
+// Rewrite traverses the tree below root like Walk, replacing or deleting
+// values as r asks. Objects and arrays are modified in place; deleted members
+// and elements take their comments with them. Rewrite returns the new root,
+// which is nil if the root itself was deleted. Once a callback returns Stop,
+// the rest of the tree is left as it is.
+func Rewrite(root Value, r Rewriter) Value {
+	value, _ := rewrite(nil, root, r)
+	return value
+}
+
+func rewrite(path Path, node Value, r Rewriter) (Value, bool) {
+	switch r.Enter(path, node) {
+	case Stop:
+		return node, false
+	case SkipChildren:
+		value, action := r.Leave(path, node)
+		return value, action != Stop
+	}
+
+	switch n := node.(type) {
+	case *ObjectValue:
+		for _, key := range n.OrderedKeys() {
+			value, ok := rewrite(append(path, KeySegment(key)), n.Pairs[key], r)
+			if value == nil {
+				n.Delete(key)
+			} else {
+				n.Pairs[key] = value
+			}
+			if !ok {
+				return node, false
+			}
+		}
+	case *ArrayValue:
+		elements, comments := n.Elements[:0], n.Comments[:0:0]
+		for i, elem := range n.Elements {
+			value, ok := rewrite(append(path, IndexSegment(i)), elem, r)
+			if value != nil {
+				elements = append(elements, value)
+				comments = append(comments, n.ElementComments(i))
+			}
+			if !ok {
+				// Keep the elements that were not visited.
+				for j := i + 1; j < len(n.Elements); j++ {
+					elements = append(elements, n.Elements[j])
+					comments = append(comments, n.ElementComments(j))
+				}
+				setElements(n, elements, comments)
+				return node, false
+			}
+		}
+		setElements(n, elements, comments)
+	}
+
+	value, action := r.Leave(path, node)
+	return value, action != Stop
+}
+
+// setElements stores rewritten elements and their comments in an array,
+// dropping the comments slice if no element has comments.
+func setElements(a *ArrayValue, elements []Value, comments []*Comments) {
+	a.Elements = elements
+	a.Comments = nil
+	for _, c := range comments {
+		if c != nil {
+			a.Comments = comments
+			break
+		}
+	}
+}
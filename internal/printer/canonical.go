@@ -0,0 +1,124 @@
+package printer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Canonical serializes a value with the JSON Canonicalization Scheme of
+// RFC 8785: no whitespace, object members sorted by the UTF-16 code units of
+// their keys, numbers in ECMAScript form and strings with minimal escaping.
+// Equal data always produces the same bytes, so the output can be hashed or
+// signed. NaN and infinite numbers have no canonical form and are an error.
+func Canonical(v parser.Value) (string, error) {
+	var sb strings.Builder
+	if err := writeCanonical(&sb, v); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writeCanonical(sb *strings.Builder, v parser.Value) error {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		keys := make([]string, 0, len(n.Pairs))
+		for key := range n.Pairs {
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, compareUTF16)
+
+		sb.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeString(sb, key)
+			sb.WriteByte(':')
+			if err := writeCanonical(sb, n.Pairs[key]); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	case *parser.ArrayValue:
+		sb.WriteByte('[')
+		for i, elem := range n.Elements {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeCanonical(sb, elem); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case *parser.NumberValue:
+		s, err := FormatECMAScript(n.Value)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(s)
+	case *parser.StringValue:
+		writeString(sb, n.Value)
+	case *parser.BooleanValue:
+		sb.WriteString(strconv.FormatBool(n.Value))
+	case *parser.NullValue:
+		sb.WriteString("null")
+	default:
+		return fmt.Errorf("cannot canonicalize %T", v)
+	}
+	return nil
+}
+
+// compareUTF16 orders strings by their UTF-16 code units, as RFC 8785
+// requires. This differs from byte order for characters above U+FFFF, whose
+// surrogates sort before U+E000–U+FFFF.
+func compareUTF16(a, b string) int {
+	return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+}
+
+// FormatECMAScript formats a number like ECMAScript's Number.prototype.toString:
+// the shortest digits that round-trip, in plain notation for exponents from
+// -7 to 20 and in exponential notation with an explicit sign otherwise.
+func FormatECMAScript(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("cannot canonicalize %v: not a finite number", f)
+	}
+	if f == 0 {
+		return "0", nil // Also for -0
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// Shortest round-trip digits and their decimal exponent: d.ddd e±x
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	k, n := len(digits), x+1 // n is the position of the decimal point
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	exponent := strconv.Itoa(n - 1)
+	if n-1 >= 0 {
+		exponent = "+" + exponent
+	}
+	return sign + s + "e" + exponent, nil
+}
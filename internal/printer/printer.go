@@ -0,0 +1,220 @@
+package printer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Printer serializes AST nodes back to JSON text.
+type Printer struct {
+	indent string // Indentation per nesting level; empty for compact output
+}
+
+// New creates a new Printer. An empty indent produces compact output on a
+// single line; any other indent pretty-prints one member per line.
+func New(indent string) *Printer {
+	return &Printer{
+		indent: indent,
+	}
+}
+
+// Print serializes a node. Object members are written in source order.
+// Comments are only written when pretty-printing, since a // comment cannot
+// be expressed on a single line.
+func (p *Printer) Print(node parser.Node) string {
+	var sb strings.Builder
+	p.writeNode(&sb, node, 0)
+	return sb.String()
+}
+
+// PrintDocument serializes a document together with the comments before and after its root.
+func (p *Printer) PrintDocument(doc *parser.Document) string {
+	var sb strings.Builder
+	if p.pretty() {
+		for _, c := range doc.Comments.Leading {
+			sb.WriteString(c)
+			sb.WriteByte('\n')
+		}
+	}
+	p.writeNode(&sb, doc.Root, 0)
+	if p.pretty() {
+		for _, c := range doc.Comments.Trailing {
+			sb.WriteByte('\n')
+			sb.WriteString(c)
+		}
+	}
+	return sb.String()
+}
+
+// pretty reports whether the printer writes one member per line.
+func (p *Printer) pretty() bool {
+	return p.indent != ""
+}
+
+// writeNode writes any AST node at the given nesting depth.
+func (p *Printer) writeNode(sb *strings.Builder, node parser.Node, depth int) {
+	switch n := node.(type) {
+	case *parser.ObjectValue:
+		p.writeObject(sb, n, depth)
+	case *parser.ArrayValue:
+		p.writeArray(sb, n, depth)
+	case *parser.StringValue:
+		writeString(sb, n.Value)
+	case *parser.NumberValue:
+		sb.WriteString(FormatNumber(n.Value))
+	case *parser.BooleanValue:
+		sb.WriteString(strconv.FormatBool(n.Value))
+	case *parser.NullValue, nil:
+		sb.WriteString("null")
+	}
+}
+
+// writeObject writes an object with its members in source order.
+func (p *Printer) writeObject(sb *strings.Builder, o *parser.ObjectValue, depth int) {
+	keys := o.OrderedKeys()
+	if len(keys) == 0 && (!p.pretty() || len(o.Dangling) == 0) {
+		sb.WriteString("{}")
+		return
+	}
+
+	sb.WriteByte('{')
+	for i, key := range keys {
+		comments := o.Comments[key]
+		p.writeLeading(sb, comments, depth+1)
+		writeString(sb, key)
+		sb.WriteByte(':')
+		if p.pretty() {
+			sb.WriteByte(' ')
+		}
+		p.writeNode(sb, o.Pairs[key], depth+1)
+		if i < len(keys)-1 {
+			sb.WriteByte(',')
+		}
+		p.writeTrailing(sb, comments)
+	}
+	p.writeDangling(sb, o.Dangling, depth+1)
+	p.newline(sb, depth)
+	sb.WriteByte('}')
+}
+
+// writeArray writes an array with one element per line when pretty-printing.
+func (p *Printer) writeArray(sb *strings.Builder, a *parser.ArrayValue, depth int) {
+	if len(a.Elements) == 0 && (!p.pretty() || len(a.Dangling) == 0) {
+		sb.WriteString("[]")
+		return
+	}
+
+	sb.WriteByte('[')
+	for i, elem := range a.Elements {
+		comments := a.ElementComments(i)
+		p.writeLeading(sb, comments, depth+1)
+		p.writeNode(sb, elem, depth+1)
+		if i < len(a.Elements)-1 {
+			sb.WriteByte(',')
+		}
+		p.writeTrailing(sb, comments)
+	}
+	p.writeDangling(sb, a.Dangling, depth+1)
+	p.newline(sb, depth)
+	sb.WriteByte(']')
+}
+
+// writeLeading starts a new member line, preceded by its leading comments.
+func (p *Printer) writeLeading(sb *strings.Builder, comments *parser.Comments, depth int) {
+	p.newline(sb, depth)
+	if comments == nil || !p.pretty() {
+		return
+	}
+	for _, c := range comments.Leading {
+		sb.WriteString(c)
+		p.newline(sb, depth)
+	}
+}
+
+// writeTrailing writes the comments that follow a member on its line.
+func (p *Printer) writeTrailing(sb *strings.Builder, comments *parser.Comments) {
+	if comments == nil || !p.pretty() {
+		return
+	}
+	for _, c := range comments.Trailing {
+		sb.WriteByte(' ')
+		sb.WriteString(c)
+	}
+}
+
+// writeDangling writes the comments before a closing bracket, one per line.
+func (p *Printer) writeDangling(sb *strings.Builder, comments []string, depth int) {
+	if !p.pretty() {
+		return
+	}
+	for _, c := range comments {
+		p.newline(sb, depth)
+		sb.WriteString(c)
+	}
+}
+
+// newline starts a new line indented to depth when pretty-printing.
+func (p *Printer) newline(sb *strings.Builder, depth int) {
+	if !p.pretty() {
+		return
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(strings.Repeat(p.indent, depth))
+}
+
+// writeString writes s as a quoted JSON string, escaping quotes, backslashes
+// and control characters.
+func writeString(sb *strings.Builder, s string) {
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\b':
+			sb.WriteString(`\b`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20:
+			sb.WriteString(`\u00`)
+			sb.WriteByte("0123456789abcdef"[r>>4])
+			sb.WriteByte("0123456789abcdef"[r&0xF])
+		case r == utf8.RuneError && size == 1:
+			sb.WriteRune(utf8.RuneError)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('"')
+}
+
+// FormatNumber formats a number in the shortest form that parses back to the
+// same value. Integers are written without an exponent up to 1e21, like
+// JavaScript. Infinity and NaN, which JSON cannot express, use JSON5 spelling.
+// Every codec that writes numbers as text uses it, so they spell them alike.
+func FormatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == math.Trunc(f) && math.Abs(f) < 1e21:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,231 @@
+package printer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		indent   string
+		expected string
+	}{
+		{
+			name:     "Compact Keeps Source Order",
+			input:    `{"b": 1, "a": [true, null, "x\"y"], "c": {}}`,
+			indent:   "",
+			expected: `{"b":1,"a":[true,null,"x\"y"],"c":{}}`,
+		},
+		{
+			name:     "Pretty",
+			input:    `{"a": [1.5, -2e-7], "b": {"c": 100000000000000000000}}`,
+			indent:   "  ",
+			expected: "{\n  \"a\": [\n    1.5,\n    -2e-07\n  ],\n  \"b\": {\n    \"c\": 100000000000000000000\n  }\n}",
+		},
+		{
+			name:     "Control Characters",
+			input:    `{"s": "tab\there\u0001"}`,
+			indent:   "",
+			expected: `{"s":"tab\there\u0001"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parser.New(lexer.New(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := New(tt.indent).Print(node); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrintDocument_JSONCRoundTrip(t *testing.T) {
+	input := `// settings
+{
+  // editor options
+  "editor.fontSize": 14, // points
+  "files.exclude": [
+    "node_modules", /* deps */
+    // build output
+    "dist"
+  ],
+  "empty": {
+    // nothing yet
+  }
+  // the end
+}
+// trailer`
+
+	doc, err := parser.New(lexer.NewWithDialect(input, lexer.JSONC)).ParseDocument()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := New("  ")
+	if got := p.PrintDocument(doc); got != input {
+		t.Errorf("round trip changed the document:\n%s", got)
+	}
+
+	// Modify the document and check the comments stay with their members
+	root := doc.Root.(*parser.ObjectValue)
+	root.Set("editor.fontSize", &parser.NumberValue{Value: 16})
+	root.Set("editor.tabSize", &parser.NumberValue{Value: 2})
+	root.Delete("empty")
+
+	expected := `// settings
+{
+  // editor options
+  "editor.fontSize": 16, // points
+  "files.exclude": [
+    "node_modules", /* deps */
+    // build output
+    "dist"
+  ],
+  "editor.tabSize": 2
+  // the end
+}
+// trailer`
+	if got := p.PrintDocument(doc); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{0, "0"},
+		{-42, "-42"},
+		{0.5, "0.5"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{1.5e-7, "1.5e-07"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tt := range tests {
+		if got := FormatNumber(tt.value); got != tt.expected {
+			t.Errorf("expected %s for %v, got %s", tt.expected, tt.value, got)
+		}
+	}
+}
+
+func TestFormatECMAScript(t *testing.T) {
+	// Number serialization samples from RFC 8785, Appendix B
+	tests := []struct {
+		bits     uint64
+		expected string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+
+	for _, tt := range tests {
+		f := math.Float64frombits(tt.bits)
+		got, err := FormatECMAScript(f)
+		if err != nil {
+			t.Errorf("unexpected error for %016x: %v", tt.bits, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("expected %s for %016x, got %s", tt.expected, tt.bits, got)
+		}
+	}
+
+	for _, bits := range []uint64{0x7fffffffffffffff, 0x7ff0000000000000} {
+		if _, err := FormatECMAScript(math.Float64frombits(bits)); err == nil {
+			t.Errorf("expected error for %016x, got none", bits)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	// Examples from RFC 8785, sections 3.2.2 and 3.2.3
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Sample Input",
+			input: `{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+				"literals": [null, true, false]
+			}`,
+			expected: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			name: "Sorting",
+			input: `{
+				"\u20ac": "Euro Sign",
+				"\r": "Carriage Return",
+				"\ufb33": "Hebrew Letter Dalet With Dagesh",
+				"1": "One",
+				"\ud83d\ude00": "Emoji: Grinning Face",
+				"\u0080": "Control",
+				"\u00f6": "Latin Small Letter O With Diaeresis"
+			}`,
+			expected: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\"," +
+				"\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name:     "Nested",
+			input:    `[{"b": [], "a": {"d": 1, "c": -0}}, "\u2028"]`,
+			expected: "[{\"a\":{\"c\":0,\"d\":1},\"b\":[]},\"\u2028\"]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parser.New(lexer.New(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := Canonical(root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := Canonical(&parser.ArrayValue{Elements: []parser.Value{&parser.NumberValue{Value: math.NaN()}}}); err == nil {
+		t.Error("expected error for NaN, got none")
+	}
+}
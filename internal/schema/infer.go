@@ -0,0 +1,276 @@
+// Package schema builds JSON Schema (draft 2020-12) documents, inferring
+// them from sample documents.
+package schema
+
+import (
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Draft is the $schema URI of the schemas this package writes.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Options configure inference.
+type Options struct {
+	// MaxEnum is the most distinct values a string property may take to be
+	// written as an enum; 0 disables enums. Values must also repeat: there
+	// must be at least twice as many samples as distinct values.
+	MaxEnum int
+}
+
+// DefaultOptions returns the options Infer uses.
+func DefaultOptions() Options {
+	return Options{MaxEnum: 10}
+}
+
+// Infer returns the schema of samples, merged with the default options.
+func Infer(samples ...parser.Value) *parser.ObjectValue {
+	in := NewInferrer(DefaultOptions())
+	for _, v := range samples {
+		in.Add(v)
+	}
+	return in.Schema()
+}
+
+// An Inferrer merges samples, such as the documents of an NDJSON stream,
+// into one schema as they arrive.
+type Inferrer struct {
+	opts Options
+	root *node
+}
+
+// NewInferrer returns an inferrer with no samples.
+func NewInferrer(opts Options) *Inferrer {
+	return &Inferrer{opts: opts, root: &node{}}
+}
+
+// Add merges a sample.
+func (in *Inferrer) Add(v parser.Value) {
+	in.root.add(v, in.opts)
+}
+
+// Schema returns the schema of the samples added so far. Every type seen
+// at a path is listed; objects list their properties and require those
+// present in every sample; arrays describe their items; integers and
+// numbers give their range; and strings give a format when every sample
+// has one, or else an enum when they take few values.
+func (in *Inferrer) Schema() *parser.ObjectValue {
+	s := parser.Object().Set("$schema", parser.String(Draft))
+	in.root.write(s)
+	return s
+}
+
+// Kinds of values seen at a path
+const (
+	kindNull = 1 << iota
+	kindBool
+	kindInteger
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// String formats recognised in samples, in order of preference
+var formats = []struct {
+	name  string
+	match func(string) bool
+}{
+	{"uuid", regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString},
+	{"email", regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`).MatchString},
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}},
+	{"date", func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	}},
+}
+
+// A node merges the values seen at one path.
+type node struct {
+	kinds int
+
+	objects    int
+	properties []*property
+	byName     map[string]*property
+
+	items *node // Elements of every array; nil if all were empty
+
+	numbers  int
+	min, max float64
+
+	strings  int
+	values   []string // Distinct strings, up to MaxEnum
+	overflow bool     // More distinct strings than values holds
+	formats  int      // Bit i is set while every string matches formats[i]
+}
+
+type property struct {
+	name  string
+	count int
+	node  *node
+}
+
+func (n *node) add(v parser.Value, opts Options) {
+	switch v := v.(type) {
+	case *parser.ObjectValue:
+		n.kinds |= kindObject
+		n.objects++
+		if n.byName == nil {
+			n.byName = make(map[string]*property)
+		}
+		for _, key := range v.OrderedKeys() {
+			p, ok := n.byName[key]
+			if !ok {
+				p = &property{name: key, node: &node{}}
+				n.byName[key] = p
+				n.properties = append(n.properties, p)
+			}
+			p.count++
+			p.node.add(v.Pairs[key], opts)
+		}
+	case *parser.ArrayValue:
+		n.kinds |= kindArray
+		for _, elem := range v.Elements {
+			if n.items == nil {
+				n.items = &node{}
+			}
+			n.items.add(elem, opts)
+		}
+	case *parser.NumberValue:
+		f := v.Value
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			n.kinds |= kindInteger
+		} else {
+			n.kinds |= kindNumber
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return
+		}
+		if n.numbers == 0 || f < n.min {
+			n.min = f
+		}
+		if n.numbers == 0 || f > n.max {
+			n.max = f
+		}
+		n.numbers++
+	case *parser.StringValue:
+		n.kinds |= kindString
+		if n.strings == 0 {
+			n.formats = 1<<len(formats) - 1
+		}
+		n.strings++
+		for i, format := range formats {
+			if n.formats&(1<<i) != 0 && !format.match(v.Value) {
+				n.formats &^= 1 << i
+			}
+		}
+		if n.overflow {
+			return
+		}
+		for _, s := range n.values {
+			if s == v.Value {
+				return
+			}
+		}
+		if len(n.values) < opts.MaxEnum {
+			n.values = append(n.values, v.Value)
+		} else {
+			n.overflow = true
+		}
+	case *parser.BooleanValue:
+		n.kinds |= kindBool
+	case *parser.NullValue:
+		n.kinds |= kindNull
+	}
+}
+
+// write adds the keywords of the node to a schema object.
+func (n *node) write(s *parser.ObjectValue) {
+	var types []parser.Value
+	for _, t := range []struct {
+		kinds int
+		name  string
+	}{
+		{kindObject, "object"},
+		{kindArray, "array"},
+		{kindString, "string"},
+		{kindInteger, "integer"},
+		{kindNumber, "number"},
+		{kindBool, "boolean"},
+		{kindNull, "null"},
+	} {
+		// Integers are numbers, so a path holding both is a number
+		if n.kinds&t.kinds != 0 && !(t.kinds == kindInteger && n.kinds&kindNumber != 0) {
+			types = append(types, parser.String(t.name))
+		}
+	}
+	switch len(types) {
+	case 0:
+		// Nothing seen, so anything goes
+		return
+	case 1:
+		s.Set("type", types[0])
+	default:
+		s.Set("type", parser.Array(types...))
+	}
+
+	if n.kinds&kindString != 0 {
+		n.writeString(s)
+	}
+	if n.numbers > 0 {
+		s.Set("minimum", parser.Number(n.min))
+		s.Set("maximum", parser.Number(n.max))
+	}
+	if n.kinds&kindObject != 0 {
+		properties := parser.Object()
+		var required []parser.Value
+		for _, p := range n.properties {
+			child := parser.Object()
+			p.node.write(child)
+			properties.Set(p.name, child)
+			if p.count == n.objects {
+				required = append(required, parser.String(p.name))
+			}
+		}
+		if len(n.properties) > 0 {
+			s.Set("properties", properties)
+		}
+		if len(required) > 0 {
+			s.Set("required", parser.Array(required...))
+		}
+	}
+	if n.items != nil {
+		items := parser.Object()
+		n.items.write(items)
+		s.Set("items", items)
+	}
+}
+
+// writeString adds the format or enum of the strings seen.
+func (n *node) writeString(s *parser.ObjectValue) {
+	for i, format := range formats {
+		if n.formats&(1<<i) != 0 {
+			s.Set("format", parser.String(format.name))
+			return
+		}
+	}
+	// An enum constrains every value, so it only fits a path holding
+	// nothing but strings, and null
+	if n.kinds&^(kindString|kindNull) != 0 || n.overflow || len(n.values) == 0 || n.strings < 2*len(n.values) {
+		return
+	}
+	values := make([]parser.Value, 0, len(n.values)+1)
+	for _, v := range n.values {
+		values = append(values, parser.String(v))
+	}
+	if n.kinds&kindNull != 0 {
+		values = append(values, parser.Null())
+	}
+	s.Set("enum", parser.Array(values...))
+}
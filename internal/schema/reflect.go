@@ -0,0 +1,545 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// FromGo returns the schema of the values encoding/json writes for the type
+// of v, such as FromGo(Order{}) or FromGo((*Order)(nil)). See FromType.
+func FromGo(v any) (*parser.ObjectValue, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot describe nil: it has no type")
+	}
+	return FromType(reflect.TypeOf(v))
+}
+
+// FromType returns the schema of the values encoding/json writes for a Go
+// type, and reads back into it. Pointers at the root are ignored.
+//
+// Struct fields are named, skipped and promoted from embedded structs as
+// encoding/json does. A field is required unless its json tag has
+// omitempty or omitzero. Pointers allow null, and so do slices and maps,
+// which are null when nil, unless their field has omitempty or omitzero
+// and so leaves nil out. Named structs are written once under $defs and
+// referenced with $ref, so recursive types work; anonymous structs are
+// written in place. Maps are objects whose additionalProperties describe
+// the values, slices and arrays describe their items, []byte is a base64
+// string, time.Time is a date-time string, and interfaces and
+// json.Marshaler types allow any value. Channels, functions and complex
+// numbers are an error.
+//
+// A jsonschema tag adds keywords to a field as comma-separated key=value
+// pairs, with "\\," in the tag for a literal comma:
+//
+//	Status string `json:"status" jsonschema:"description=Order state,enum=open,enum=closed"`
+//
+// title, description and default annotate the field; enum (repeated once
+// per value), minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// multipleOf, minLength, maxLength, pattern and format constrain its
+// value, or each item of a slice or array; minItems and maxItems bound a
+// slice or array; and a bare required makes an omitempty field required.
+// Enum and default values are read as the type of the field.
+func FromType(t reflect.Type) (*parser.ObjectValue, error) {
+	t = deref(t)
+	r := &reflector{root: t, defs: parser.Object(), names: make(map[reflect.Type]string), taken: make(map[string]bool)}
+	root, err := r.schemaOf(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := parser.Object().Set("$schema", parser.String(Draft))
+	for _, key := range root.KeyOrder {
+		s.Set(key, root.Pairs[key])
+	}
+	if len(r.defs.KeyOrder) > 0 {
+		s.Set("$defs", r.defs)
+	}
+	return s, nil
+}
+
+// Types with a fixed schema
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	numberType        = reflect.TypeOf(json.Number(""))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type reflector struct {
+	root  reflect.Type            // Referenced as "#" from within itself
+	defs  *parser.ObjectValue     // Schemas of named structs
+	names map[reflect.Type]string // $defs names of named structs
+	taken map[string]bool         // $defs names in use
+}
+
+// schemaOf returns the schema of a type found at path, the member holding
+// it. Pointers, slices and maps are nullable, since encoding/json writes
+// nil as null.
+func (r *reflector) schemaOf(t reflect.Type, path parser.Path) (*parser.ObjectValue, error) {
+	if t.Kind() == reflect.Pointer {
+		s, err := r.schemaOf(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	}
+	s, err := r.valueSchema(t, path)
+	if err != nil {
+		return nil, err
+	}
+	if nilable(t) {
+		return nullable(s), nil
+	}
+	return s, nil
+}
+
+// nilable reports whether encoding/json writes the nil value of a
+// non-pointer type as null: a slice or map without its own marshaling.
+func nilable(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) && !implements(t, marshalerType) && !implements(t, textMarshalerType)
+}
+
+// valueSchema returns the schema of the non-nil values of a type that is
+// not a pointer.
+func (r *reflector) valueSchema(t reflect.Type, path parser.Path) (*parser.ObjectValue, error) {
+	switch {
+	case t == timeType:
+		return typed("string").Set("format", parser.String("date-time")), nil
+	case t == numberType:
+		return typed("number"), nil
+	case implements(t, marshalerType):
+		// Its output is only known at run time
+		return parser.Object(), nil
+	case implements(t, textMarshalerType):
+		return typed("string"), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return typed("boolean"), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typed("integer"), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return typed("integer").Set("minimum", parser.Number(0)), nil
+	case reflect.Float32, reflect.Float64:
+		return typed("number"), nil
+	case reflect.String:
+		return typed("string"), nil
+	case reflect.Interface:
+		return parser.Object(), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && !implements(t.Elem(), marshalerType) && !implements(t.Elem(), textMarshalerType) {
+			return typed("string").Set("contentEncoding", parser.String("base64")), nil
+		}
+		items, err := r.schemaOf(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		s := typed("array").Set("items", items)
+		if t.Kind() == reflect.Array {
+			s.Set("minItems", parser.Number(float64(t.Len())))
+			s.Set("maxItems", parser.Number(float64(t.Len())))
+		}
+		return s, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !implements(t.Key(), textMarshalerType) {
+				return nil, fmt.Errorf("cannot describe %s%s: map keys must be strings, integers or encoding.TextMarshaler", t, path.At())
+			}
+		}
+		values, err := r.schemaOf(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		return typed("object").Set("additionalProperties", values), nil
+	case reflect.Struct:
+		if t == r.root && len(path) > 0 {
+			return parser.Object().Set("$ref", parser.String("#")), nil
+		}
+		if t == r.root || t.Name() == "" {
+			return r.structSchema(t, path)
+		}
+		name, err := r.define(t, path)
+		if err != nil {
+			return nil, err
+		}
+		return parser.Object().Set("$ref", parser.String("#/$defs/"+name)), nil
+	default:
+		return nil, fmt.Errorf("cannot describe %s%s: it has no JSON form", t, path.At())
+	}
+}
+
+// define adds the schema of a named struct to $defs the first time, and
+// returns its name there.
+func (r *reflector) define(t reflect.Type, path parser.Path) (string, error) {
+	if name, ok := r.names[t]; ok {
+		return name, nil
+	}
+	base := defName(t)
+	name := base
+	for i := 2; r.taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	r.names[t] = name
+	r.taken[name] = true
+	// Reserve the slot first so definitions stay in order of first use
+	r.defs.Set(name, parser.Object())
+	s, err := r.structSchema(t, path)
+	if err != nil {
+		return "", err
+	}
+	r.defs.Set(name, s)
+	return name, nil
+}
+
+// defName returns the name of a type without its package, keeping only
+// the characters that need no escaping in a JSON Pointer or URI fragment.
+func defName(t reflect.Type) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	return strings.Trim(name, "_")
+}
+
+// structSchema returns the object schema of a struct's fields.
+func (r *reflector) structSchema(t reflect.Type, path parser.Path) (*parser.ObjectValue, error) {
+	s := typed("object")
+	properties := parser.Object()
+	var required []parser.Value
+	for _, f := range fields(t) {
+		at := append(path.Clone(), parser.KeySegment(f.name))
+		schemaOf := r.schemaOf
+		if f.omitEmpty && nilable(f.typ) {
+			// omitempty and omitzero leave out nil slices and maps
+			schemaOf = r.valueSchema
+		}
+		fs, err := schemaOf(f.typ, at)
+		if err != nil {
+			return nil, err
+		}
+		if f.quoted {
+			fs = quoted(f.typ, fs)
+		}
+		force := false
+		if f.keywords != "" {
+			if force, err = applyTag(fs, f.typ, f.keywords); err != nil {
+				return nil, fmt.Errorf("invalid jsonschema tag on %s.%s: %v", t, f.field, err)
+			}
+		}
+		properties.Set(f.name, fs)
+		if !f.omitEmpty || force {
+			required = append(required, parser.String(f.name))
+		}
+	}
+	if len(properties.KeyOrder) > 0 {
+		s.Set("properties", properties)
+	}
+	if len(required) > 0 {
+		s.Set("required", parser.Array(required...))
+	}
+	return s, nil
+}
+
+// A field is a struct member as encoding/json sees it.
+type field struct {
+	name      string // JSON member name
+	field     string // Go field name, for error messages
+	typ       reflect.Type
+	depth     int  // Number of embedded structs it was promoted through
+	tagged    bool // Named by a json tag
+	omitEmpty bool // omitempty or omitzero
+	quoted    bool // The ",string" option
+	keywords  string
+}
+
+// fields returns the JSON members of a struct in encoding order, applying
+// the encoding/json rules for promoted fields: the shallowest wins, then
+// the only tagged one, and fields that still clash are dropped.
+func fields(t reflect.Type) []field {
+	var all []field
+	collect(t, 0, map[reflect.Type]bool{t: true}, &all)
+
+	var out []field
+	for i, f := range all {
+		winner, clash := -1, false
+		for j, g := range all {
+			if g.name != f.name {
+				continue
+			}
+			switch {
+			case winner < 0 || g.depth < all[winner].depth:
+				winner, clash = j, false
+			case g.depth == all[winner].depth && g.tagged && !all[winner].tagged:
+				winner, clash = j, false
+			case g.depth == all[winner].depth && g.tagged == all[winner].tagged:
+				clash = true
+			}
+		}
+		if winner == i && !clash {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// collect appends the fields of a struct, descending into embedded
+// structs that have no json name. embedding holds the structs being
+// descended, which stops cycles through embedded pointers.
+func collect(t reflect.Type, depth int, embedding map[reflect.Type]bool, out *[]field) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if !sf.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+			if name == "" && ft.Kind() == reflect.Struct {
+				if !embedding[ft] {
+					embedding[ft] = true
+					collect(ft, depth+1, embedding, out)
+					delete(embedding, ft)
+				}
+				continue
+			}
+		} else if !sf.IsExported() {
+			continue
+		}
+
+		f := field{name: name, field: sf.Name, typ: sf.Type, depth: depth, tagged: name != "", keywords: sf.Tag.Get("jsonschema")}
+		if name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty", "omitzero":
+				f.omitEmpty = true
+			case "string":
+				f.quoted = true
+			}
+		}
+		*out = append(*out, f)
+	}
+}
+
+// quoted returns the schema of a field with the ",string" option, which
+// only applies to strings, numbers and booleans.
+func quoted(t reflect.Type, s *parser.ObjectValue) *parser.ObjectValue {
+	pointer := false
+	if t.Kind() == reflect.Pointer {
+		t, pointer = t.Elem(), true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if pointer {
+			return nullable(typed("string"))
+		}
+		return typed("string")
+	}
+	return s
+}
+
+// applyTag adds the keywords of a jsonschema tag to the schema of a field
+// of type t. It reports whether the tag makes the field required.
+func applyTag(s *parser.ObjectValue, t reflect.Type, tag string) (bool, error) {
+	// Value keywords describe the items of slices and arrays
+	target, elem := s, deref(t)
+	if items, ok := s.Pairs["items"].(*parser.ObjectValue); ok {
+		target, elem = items, deref(elem.Elem())
+	}
+
+	required := false
+	var enum []parser.Value
+	for _, part := range splitTag(tag) {
+		key, value, ok := strings.Cut(part, "=")
+		if key == "required" {
+			if ok {
+				return false, fmt.Errorf("required takes no value")
+			}
+			required = true
+			continue
+		}
+		if !ok {
+			return false, fmt.Errorf("%s needs a value", key)
+		}
+		switch key {
+		case "title", "description":
+			s.Set(key, parser.String(value))
+		case "default":
+			v, err := literal(value, deref(t))
+			if err != nil {
+				return false, fmt.Errorf("default: %v", err)
+			}
+			s.Set(key, v)
+		case "enum":
+			v, err := literal(value, elem)
+			if err != nil {
+				return false, fmt.Errorf("enum: %v", err)
+			}
+			enum = append(enum, v)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("%s: %q is not a number", key, value)
+			}
+			target.Set(key, parser.Number(f))
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return false, fmt.Errorf("%s: %q is not a non-negative integer", key, value)
+			}
+			if key == "minItems" || key == "maxItems" {
+				s.Set(key, parser.Number(float64(n)))
+			} else {
+				target.Set(key, parser.Number(float64(n)))
+			}
+		case "pattern":
+			if _, err := regexp.Compile(value); err != nil {
+				return false, fmt.Errorf("pattern: %v", err)
+			}
+			target.Set(key, parser.String(value))
+		case "format":
+			target.Set(key, parser.String(value))
+		default:
+			return false, fmt.Errorf("unknown keyword %q", key)
+		}
+	}
+	if len(enum) > 0 {
+		if allowsNull(target) {
+			enum = append(enum, parser.Null())
+		}
+		target.Set("enum", parser.Array(enum...))
+	}
+	return required, nil
+}
+
+// splitTag splits a jsonschema tag at the commas not escaped as "\,".
+func splitTag(tag string) []string {
+	var parts []string
+	var sb strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(tag[i])
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// literal reads an enum or default value from a tag as a value of type t:
+// booleans and numbers as such, strings and types written as strings
+// verbatim, and anything else as JSON text.
+func literal(value string, t reflect.Type) (parser.Value, error) {
+	if t == timeType || (!implements(t, marshalerType) && implements(t, textMarshalerType)) {
+		return parser.String(value), nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return parser.Bool(b), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return parser.Number(f), nil
+	case reflect.String:
+		return parser.String(value), nil
+	}
+	v, err := parser.New(lexer.New(value)).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%q is not JSON: %v", value, err)
+	}
+	return v, nil
+}
+
+// typed returns a schema of a single type.
+func typed(name string) *parser.ObjectValue {
+	return parser.Object().Set("type", parser.String(name))
+}
+
+// nullable returns a schema that also allows null: its type gains "null"
+// when it has one, and it is wrapped in an anyOf otherwise.
+func nullable(s *parser.ObjectValue) *parser.ObjectValue {
+	if len(s.KeyOrder) == 0 || allowsNull(s) {
+		return s
+	}
+	switch t := s.Pairs["type"].(type) {
+	case *parser.StringValue:
+		s.Set("type", parser.Array(t, parser.String("null")))
+	case *parser.ArrayValue:
+		t.Append(parser.String("null"))
+	default:
+		return parser.Object().Set("anyOf", parser.Array(s, typed("null")))
+	}
+	if enum, ok := s.Pairs["enum"].(*parser.ArrayValue); ok {
+		enum.Append(parser.Null())
+	}
+	return s
+}
+
+// allowsNull reports whether the type of a schema lists null.
+func allowsNull(s *parser.ObjectValue) bool {
+	switch t := s.Pairs["type"].(type) {
+	case *parser.StringValue:
+		return t.Value == "null"
+	case *parser.ArrayValue:
+		for _, elem := range t.Elements {
+			if name, ok := elem.(*parser.StringValue); ok && name.Value == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// implements reports whether t or a pointer to it implements an interface,
+// as encoding/json checks for addressable values.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || (t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(iface))
+}
+
+// deref returns the type a chain of pointers points to.
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
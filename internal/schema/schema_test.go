@@ -0,0 +1,264 @@
+package schema
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+// expectSchema reports an error unless got is the schema of a JSON5
+// literal with $schema added.
+func expectSchema(t *testing.T, got *parser.ObjectValue, expected string) {
+	t.Helper()
+	schema := testutil.JSON(t, expected).(*parser.ObjectValue)
+	schema.Set("$schema", parser.String(Draft))
+	testutil.Expect(t, got, printer.New("").Print(schema))
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []string
+		expected string // The schema without $schema
+	}{
+		{"scalars", []string{`true`}, `{"type": "boolean"}`},
+		{"integers", []string{`3`, `-1`, `10`}, `{"type": "integer", "minimum": -1, "maximum": 10}`},
+		{"integers and numbers", []string{`3`, `0.5`}, `{"type": "number", "minimum": 0.5, "maximum": 3}`},
+		{"several types", []string{`"x"`, `1`, `null`}, `{"type": ["string", "integer", "null"], "minimum": 1, "maximum": 1}`},
+		{"required and optional", []string{`{"a": 1, "b": "x"}`, `{"a": 2, "c": true}`},
+			`{"type": "object", "properties": {"a": {"type": "integer", "minimum": 1, "maximum": 2}, "b": {"type": "string"}, "c": {"type": "boolean"}}, "required": ["a"]}`},
+		{"nullable property", []string{`{"a": null}`, `{"a": {"b": 1}}`},
+			`{"type": "object", "properties": {"a": {"type": ["object", "null"], "properties": {"b": {"type": "integer", "minimum": 1, "maximum": 1}}, "required": ["b"]}}, "required": ["a"]}`},
+		{"array items", []string{`[1, "x"]`, `[]`, `[2.5]`},
+			`{"type": "array", "items": {"type": ["string", "number"], "minimum": 1, "maximum": 2.5}}`},
+		{"empty arrays", []string{`[]`}, `{"type": "array"}`},
+		{"empty objects", []string{`{}`}, `{"type": "object"}`},
+		{"non-finite numbers", []string{`Infinity`, `2`}, `{"type": "number", "minimum": 2, "maximum": 2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]parser.Value, len(tt.samples))
+			for i, s := range tt.samples {
+				samples[i] = testutil.JSON(t, s)
+			}
+			got := Infer(samples...)
+			expectSchema(t, got, tt.expected)
+			if got.KeyOrder[0] != "$schema" {
+				t.Errorf("expected $schema first, got %v", got.KeyOrder)
+			}
+		})
+	}
+}
+
+func TestInfer_Strings(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []string
+		expected string
+	}{
+		{"uuid", []string{`"7c9e6679-7425-40de-944b-e07fc1f90ae7"`, `"7C9E6679-7425-40DE-944B-E07FC1F90AE8"`}, `{"type": "string", "format": "uuid"}`},
+		{"email", []string{`"ada@example.com"`, `"a.b+c@mail.example.org"`}, `{"type": "string", "format": "email"}`},
+		{"not an email", []string{`"ada@example.com"`, `"ada@localhost"`}, `{"type": "string"}`},
+		{"date-time", []string{`"2024-01-02T03:04:05Z"`, `"2024-01-02T03:04:05.123+02:00"`}, `{"type": "string", "format": "date-time"}`},
+		{"date", []string{`"2024-01-02"`, `"1999-12-31"`}, `{"type": "string", "format": "date"}`},
+		{"mixed formats", []string{`"2024-01-02"`, `"2024-01-02T03:04:05Z"`}, `{"type": "string"}`},
+		{"enum", []string{`"open"`, `"closed"`, `"open"`, `"open"`}, `{"type": "string", "enum": ["open", "closed"]}`},
+		{"nullable enum", []string{`"a"`, `null`, `"a"`}, `{"type": ["string", "null"], "enum": ["a", null]}`},
+		{"values do not repeat", []string{`"a"`, `"b"`, `"c"`}, `{"type": "string"}`},
+		{"enum of mixed types", []string{`"a"`, `"a"`, `1`}, `{"type": ["string", "integer"], "minimum": 1, "maximum": 1}`},
+		{"too many values", []string{`"a"`, `"b"`, `"c"`, `"a"`, `"b"`, `"c"`}, `{"type": "string"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInferrer(Options{MaxEnum: 2})
+			for _, s := range tt.samples {
+				in.Add(testutil.JSON(t, s))
+			}
+			expectSchema(t, in.Schema(), tt.expected)
+		})
+	}
+
+	in := NewInferrer(Options{})
+	for range 3 {
+		in.Add(parser.String("a"))
+	}
+	if _, ok := in.Schema().Pairs["enum"]; ok {
+		t.Errorf("expected MaxEnum 0 to disable enums")
+	}
+}
+
+type address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty" jsonschema:"pattern=^[0-9]{5}$"`
+}
+
+type base struct {
+	ID      int64     `json:"id" jsonschema:"minimum=1"`
+	Created time.Time `json:"created"`
+}
+
+type customer struct {
+	base
+	Name     string            `json:"name" jsonschema:"title=Name,description=Full name\\, as printed"`
+	Email    *string           `json:"email"`
+	Status   string            `json:"status,omitempty" jsonschema:"enum=active,enum=closed,required"`
+	Tags     []string          `json:"tags,omitempty" jsonschema:"maxItems=5,maxLength=10"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Home     address           `json:"home"`
+	Work     *address          `json:"work,omitempty"`
+	Referrer *customer         `json:"referrer,omitempty"`
+	Avatar   []byte            `json:"avatar,omitempty"`
+	Extra    any               `json:"extra,omitempty"`
+	Count    uint8             `json:"count,string"`
+	Score    float64           `json:"-"`
+	internal int
+}
+
+func TestFromType(t *testing.T) {
+	got, err := FromGo(&customer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.Expect(t, got, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"created": {"type": "string", "format": "date-time"},
+			"name": {"type": "string", "title": "Name", "description": "Full name, as printed"},
+			"email": {"type": ["string", "null"]},
+			"status": {"type": "string", "enum": ["active", "closed"]},
+			"tags": {"type": "array", "items": {"type": "string", "maxLength": 10}, "maxItems": 5},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"home": {"$ref": "#/$defs/address"},
+			"work": {"anyOf": [{"$ref": "#/$defs/address"}, {"type": "null"}]},
+			"referrer": {"anyOf": [{"$ref": "#"}, {"type": "null"}]},
+			"avatar": {"type": "string", "contentEncoding": "base64"},
+			"extra": {},
+			"count": {"type": "string"}
+		},
+		"required": ["id", "created", "name", "email", "status", "home", "count"],
+		"$defs": {
+			"address": {
+				"type": "object",
+				"properties": {"street": {"type": "string"}, "zip": {"type": "string", "pattern": "^[0-9]{5}$"}},
+				"required": ["street"]
+			}
+		}
+	}`)
+	if got.KeyOrder[0] != "$schema" {
+		t.Errorf("expected $schema first, got %v", got.KeyOrder)
+	}
+}
+
+type inner struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+type outer struct {
+	inner
+	*other
+	more
+	B int `json:"b"`
+}
+
+type other struct {
+	C string `json:"c"`
+	D bool   `json:"d"`
+	Y string `json:"Y"`
+}
+
+type more struct {
+	C string `json:"c"`
+	E string
+	Y string
+}
+
+func TestFromType_Types(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string // The schema without $schema
+	}{
+		{"integer", int8(0), `{"type": "integer"}`},
+		{"unsigned", uint(0), `{"type": "integer", "minimum": 0}`},
+		{"pointer", new(*float32), `{"type": "number"}`},
+		{"nullable items", []*bool{}, `{"type": ["array", "null"], "items": {"type": ["boolean", "null"]}}`},
+		{"fixed array", [2]string{}, `{"type": "array", "items": {"type": "string"}, "minItems": 2, "maxItems": 2}`},
+		{"integer keys", map[int]bool{}, `{"type": ["object", "null"], "additionalProperties": {"type": "boolean"}}`},
+		{"json.Number", json.Number("1"), `{"type": "number"}`},
+		{"marshaler", json.RawMessage{}, `{}`},
+		{"text marshaler", net.IP{}, `{"type": "string"}`},
+		{"anonymous struct", struct {
+			N *struct{ X int } `json:"n"`
+		}{}, `{"type": "object", "properties": {"n": {"type": ["object", "null"], "properties": {"X": {"type": "integer"}}, "required": ["X"]}}, "required": ["n"]}`},
+		// outer.b hides the deeper inner.b, the tagged other.Y hides
+		// more.Y, and other.c and more.c clash, so neither is written
+		{"embedded", outer{}, `{"type": "object", "properties": {"a": {"type": "string"}, "d": {"type": "boolean"}, "Y": {"type": "string"}, "E": {"type": "string"}, "b": {"type": "integer"}}, "required": ["a", "d", "Y", "E", "b"]}`},
+		// encoding/json writes {"s":null,"m":null,"b":null,"r":null} for
+		// the zero value; only the RawMessage itself decides what it is
+		{"nil slices and maps", struct {
+			S []int           `json:"s"`
+			M map[string]bool `json:"m"`
+			B []byte          `json:"b"`
+			R json.RawMessage `json:"r"`
+			O []int           `json:"o,omitempty"`
+		}{}, `{"type": "object", "properties": {"s": {"type": ["array", "null"], "items": {"type": "integer"}}, "m": {"type": ["object", "null"], "additionalProperties": {"type": "boolean"}}, "b": {"type": ["string", "null"], "contentEncoding": "base64"}, "r": {}, "o": {"type": "array", "items": {"type": "integer"}}}, "required": ["s", "m", "b", "r"]}`},
+		{"nullable enum", struct {
+			Level *int `json:"level" jsonschema:"enum=1,enum=2,default=1"`
+		}{}, `{"type": "object", "properties": {"level": {"type": ["integer", "null"], "enum": [1, 2, null], "default": 1}}, "required": ["level"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromGo(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectSchema(t, got, tt.expected)
+		})
+	}
+}
+
+func TestFromType_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "cannot describe nil"},
+		{"channel", struct {
+			C chan int `json:"c"`
+		}{}, "cannot describe chan int at c"},
+		{"complex item", map[string][]complex64{}, "cannot describe complex64"},
+		{"struct keys", map[address]int{}, "map keys must be strings"},
+		{"unknown keyword", struct {
+			A int `jsonschema:"minimun=1"`
+		}{}, `unknown keyword "minimun"`},
+		{"bad bound", struct {
+			A int `jsonschema:"maximum=ten"`
+		}{}, `maximum: "ten" is not a number`},
+		{"bad enum", struct {
+			A bool `jsonschema:"enum=yes"`
+		}{}, `enum: "yes" is not a boolean`},
+		{"missing value", struct {
+			A string `jsonschema:"description"`
+		}{}, "description needs a value"},
+		{"bad pattern", struct {
+			A string `jsonschema:"pattern=("`
+		}{}, "pattern: error parsing regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromGo(tt.value); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Package signature signs and verifies JSON documents. Signatures are
+// computed over the RFC 8785 canonical form of the document, so they survive
+// reformatting and member reordering. A signature is either detached, or
+// embedded in the document under a configurable member that is excluded
+// from the canonical form before signing and verifying.
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// DefaultField is the member that holds an embedded signature by default.
+const DefaultField = "signature"
+
+// ErrInvalidSignature is returned when a signature does not match the document.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Algorithm names a signature algorithm, as recorded in embedded signatures.
+type Algorithm string
+
+const (
+	HS256   Algorithm = "HS256"   // HMAC with SHA-256
+	Ed25519 Algorithm = "Ed25519" // Ed25519 (RFC 8032)
+)
+
+// ParseAlgorithm parses an algorithm name, ignoring case. "hmac-sha256" is
+// accepted for HS256.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "hs256", "hmac-sha256":
+		return HS256, nil
+	case "ed25519":
+		return Ed25519, nil
+	default:
+		return "", fmt.Errorf("unknown signature algorithm %q (want hs256 or ed25519)", name)
+	}
+}
+
+// Key signs and verifies messages with one algorithm.
+type Key interface {
+	Algorithm() Algorithm
+	Sign(message []byte) ([]byte, error)
+	Verify(message, sig []byte) error
+}
+
+// HMACKey is a shared secret for HS256.
+type HMACKey []byte
+
+func (k HMACKey) Algorithm() Algorithm {
+	return HS256
+}
+
+func (k HMACKey) Sign(message []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+func (k HMACKey) Verify(message, sig []byte) error {
+	expected, _ := k.Sign(message)
+	if !hmac.Equal(expected, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Ed25519Key is an Ed25519 key pair. Private may be nil for a key that only verifies.
+type Ed25519Key struct {
+	Private ed25519.PrivateKey
+	Public  ed25519.PublicKey
+}
+
+func (k Ed25519Key) Algorithm() Algorithm {
+	return Ed25519
+}
+
+func (k Ed25519Key) Sign(message []byte) ([]byte, error) {
+	if k.Private == nil {
+		return nil, errors.New("signing with Ed25519 requires a private key")
+	}
+	if len(k.Private) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("Ed25519 private key is %d bytes, not %d", len(k.Private), ed25519.PrivateKeySize)
+	}
+	return ed25519.Sign(k.Private, message), nil
+}
+
+func (k Ed25519Key) Verify(message, sig []byte) error {
+	public := k.Public
+	if public == nil && len(k.Private) == ed25519.PrivateKeySize {
+		public = k.Private.Public().(ed25519.PublicKey)
+	}
+	if len(public) != ed25519.PublicKeySize {
+		return fmt.Errorf("Ed25519 public key is %d bytes, not %d", len(public), ed25519.PublicKeySize)
+	}
+	if !ed25519.Verify(public, message, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// LoadKey builds a key for an algorithm from the contents of a key file. For
+// HS256 the contents are the secret itself. For Ed25519 they are a PEM
+// encoded PKCS #8 private key or PKIX public key.
+func LoadKey(alg Algorithm, data []byte) (Key, error) {
+	switch alg {
+	case HS256:
+		if len(data) == 0 {
+			return nil, errors.New("HMAC secret is empty")
+		}
+		return HMACKey(data), nil
+	case Ed25519:
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("Ed25519 key is not PEM encoded")
+		}
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Ed25519 private key: %w", err)
+			}
+			private, ok := key.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("private key is %T, not Ed25519", key)
+			}
+			return Ed25519Key{Private: private, Public: private.Public().(ed25519.PublicKey)}, nil
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Ed25519 public key: %w", err)
+			}
+			public, ok := key.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("public key is %T, not Ed25519", key)
+			}
+			return Ed25519Key{Public: public}, nil
+		default:
+			return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+	default:
+		return nil, fmt.Errorf("unknown signature algorithm %q", alg)
+	}
+}
+
+// Sign returns a detached signature of v: the base64url encoded (unpadded)
+// signature of its canonical form.
+func Sign(v parser.Value, key Key) (string, error) {
+	canonical, err := printer.Canonical(v)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.Sign([]byte(canonical))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks a detached signature made by Sign.
+func Verify(v parser.Value, sig string, key Key) error {
+	raw, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	canonical, err := printer.Canonical(v)
+	if err != nil {
+		return err
+	}
+	return key.Verify([]byte(canonical), raw)
+}
+
+// Embed signs an object and returns a copy with the signature stored under
+// field as {"alg": ..., "sig": ...}. An existing member named field is
+// replaced and not signed. The input is not modified.
+func Embed(v parser.Value, field string, key Key) (*parser.ObjectValue, error) {
+	object, ok := v.(*parser.ObjectValue)
+	if !ok {
+		return nil, fmt.Errorf("an embedded signature needs an object, got %s", parser.TypeName(v))
+	}
+
+	signed := without(object, field)
+	sig, err := Sign(signed, key)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded := &parser.ObjectValue{}
+	embedded.Set("alg", &parser.StringValue{Value: string(key.Algorithm())})
+	embedded.Set("sig", &parser.StringValue{Value: sig})
+	signed.Set(field, embedded)
+	return signed, nil
+}
+
+// VerifyEmbedded checks the signature stored under field by Embed.
+func VerifyEmbedded(v parser.Value, field string, key Key) error {
+	object, ok := v.(*parser.ObjectValue)
+	if !ok {
+		return fmt.Errorf("an embedded signature needs an object, got %s", parser.TypeName(v))
+	}
+	embedded, ok := object.Pairs[field]
+	if !ok {
+		return fmt.Errorf("document has no %q member", field)
+	}
+
+	alg, err := accessString(embedded, "alg")
+	if err != nil {
+		return fmt.Errorf("malformed %q member: %w", field, err)
+	}
+	sig, err := accessString(embedded, "sig")
+	if err != nil {
+		return fmt.Errorf("malformed %q member: %w", field, err)
+	}
+	if Algorithm(alg) != key.Algorithm() {
+		return fmt.Errorf("document is signed with %s, but the key is for %s", alg, key.Algorithm())
+	}
+	return Verify(without(object, field), sig, key)
+}
+
+// accessString returns the string member key of v.
+func accessString(v parser.Value, key string) (string, error) {
+	member, err := v.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return member.String()
+}
+
+// without returns a shallow copy of an object without the given member.
+func without(o *parser.ObjectValue, field string) *parser.ObjectValue {
+	c := &parser.ObjectValue{Dangling: o.Dangling}
+	for _, key := range o.OrderedKeys() {
+		if key == field {
+			continue
+		}
+		c.Set(key, o.Pairs[key])
+		if comments, ok := o.Comments[key]; ok {
+			if c.Comments == nil {
+				c.Comments = make(map[string]*parser.Comments)
+			}
+			c.Comments[key] = comments
+		}
+	}
+	return c
+}
@@ -0,0 +1,182 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+func parse(t *testing.T, input string) parser.Value {
+	t.Helper()
+	v, err := parser.New(lexer.New(input)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return v
+}
+
+func testKeys(t *testing.T) []Key {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	private := ed25519.NewKeyFromSeed(seed)
+	return []Key{
+		HMACKey("secret"),
+		Ed25519Key{Private: private, Public: private.Public().(ed25519.PublicKey)},
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	for _, key := range testKeys(t) {
+		t.Run(string(key.Algorithm()), func(t *testing.T) {
+			doc := parse(t, `{"amount": 10, "to": "alice", "signature": "stale"}`)
+			signed, err := Embed(doc, DefaultField, key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s, _ := doc.Get("signature"); s.(*parser.StringValue).Value != "stale" {
+				t.Errorf("expected input to be left unchanged")
+			}
+			if alg, err := signed.Get("signature.alg"); err != nil || alg.(*parser.StringValue).Value != string(key.Algorithm()) {
+				t.Errorf("expected alg %s, got %v (%v)", key.Algorithm(), alg, err)
+			}
+
+			// Reformatting and reordering members keeps the signature valid
+			sig, _ := signed.Get("signature.sig")
+			text := `{
+				"signature": {"sig": ` + printer.New("").Print(sig) + `, "alg": "` + string(key.Algorithm()) + `"},
+				"to": "alice",
+				"amount": 10.0
+			}`
+			if err := VerifyEmbedded(parse(t, text), DefaultField, key); err != nil {
+				t.Errorf("unexpected error verifying reformatted document: %v", err)
+			}
+
+			tampered := parse(t, strings.Replace(text, `"alice"`, `"mallory"`, 1))
+			if err := VerifyEmbedded(tampered, DefaultField, key); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature for tampered document, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEmbed_Errors(t *testing.T) {
+	key := HMACKey("secret")
+	if _, err := Embed(parse(t, `[1]`), DefaultField, key); err == nil {
+		t.Error("expected error embedding a signature in an array")
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Missing", `{"a": 1}`, `document has no "signature" member`},
+		{"Malformed", `{"a": 1, "signature": "abc"}`, `malformed "signature" member`},
+		{"Other Algorithm", `{"a": 1, "signature": {"alg": "Ed25519", "sig": "AA"}}`, "document is signed with Ed25519, but the key is for HS256"},
+		{"Bad Encoding", `{"a": 1, "signature": {"alg": "HS256", "sig": "!!"}}`, "invalid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyEmbedded(parse(t, tt.input), DefaultField, key)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestDetached(t *testing.T) {
+	keys := testKeys(t)
+	for _, key := range keys {
+		t.Run(string(key.Algorithm()), func(t *testing.T) {
+			sig, err := Sign(parse(t, `{"b": [1, 2], "a": null}`), key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := Verify(parse(t, `{"a": null, "b": [1.0, 2e0]}`), sig, key); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err := Verify(parse(t, `{"a": null, "b": [2, 1]}`), sig, key); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+
+	// HS256 is deterministic: HMAC-SHA256("secret", `{"a":1}`)
+	sig, err := Sign(parse(t, `{ "a" : 1 }`), HMACKey("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "qp4uNXX11wmLbKzNeQiIw21f22M0KnO62i1qUXR6hJQ"; sig != expected {
+		t.Errorf("expected signature %s, got %s", expected, sig)
+	}
+
+	// A verify-only key cannot sign
+	public := Ed25519Key{Public: keys[1].(Ed25519Key).Public}
+	if _, err := Sign(parse(t, `{}`), public); err == nil {
+		t.Error("expected error signing with a public key")
+	}
+
+	// Keys of the wrong size are an error rather than a panic
+	for _, key := range []Ed25519Key{{}, {Public: ed25519.PublicKey("short")}, {Private: ed25519.PrivateKey("short")}} {
+		if err := Verify(parse(t, `{}`), "AA", key); err == nil || !strings.Contains(err.Error(), "Ed25519 public key is") {
+			t.Errorf("expected a key size error verifying with %d and %d byte keys, got %v", len(key.Public), len(key.Private), err)
+		}
+	}
+	if _, err := Sign(parse(t, `{}`), Ed25519Key{Private: ed25519.PrivateKey("short")}); err == nil || !strings.Contains(err.Error(), "Ed25519 private key is 5 bytes, not 64") {
+		t.Errorf("expected a key size error signing with a short key, got %v", err)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	private := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	signer, err := LoadKey(Ed25519, privatePEM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifier, err := LoadKey(Ed25519, publicPEM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sig, err := Sign(parse(t, `[1]`), signer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Verify(parse(t, `[1]`), sig, verifier); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		alg  Algorithm
+		data []byte
+	}{{HS256, nil}, {Ed25519, []byte("not pem")}, {Ed25519, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE"})}} {
+		if _, err := LoadKey(tt.alg, tt.data); err == nil {
+			t.Errorf("expected error loading %s key %q", tt.alg, tt.data)
+		}
+	}
+
+	if alg, err := ParseAlgorithm("HMAC-SHA256"); err != nil || alg != HS256 {
+		t.Errorf("expected HS256, got %v (%v)", alg, err)
+	}
+	if _, err := ParseAlgorithm("rs256"); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// histogramWidth is the length of the longest bar of the depth histogram.
+const histogramWidth = 40
+
+// WriteText writes the report for people to read: the totals, the depth
+// histogram, the rankings and the size breakdown as an indented tree.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Size:\t%s\n", formatBytes(r.Bytes))
+	fmt.Fprintf(tw, "Values:\t%d\n", r.Counts.Total())
+	for _, c := range r.Counts.named() {
+		fmt.Fprintf(tw, "  %s\t%d\n", c.name, c.count)
+	}
+	fmt.Fprintf(tw, "Depth:\tmax %d, average %.2f\n", r.MaxDepth, r.AverageDepth)
+
+	fmt.Fprintln(tw, "\nDepth histogram:")
+	widest := 0
+	for _, n := range r.Depths {
+		widest = max(widest, n)
+	}
+	for depth, n := range r.Depths {
+		bar := max(1, n*histogramWidth/widest)
+		fmt.Fprintf(tw, "  %d\t%d\t%s\n", depth, n, strings.Repeat("#", bar))
+	}
+
+	if len(r.Keys) > 0 {
+		fmt.Fprintln(tw, "\nMost frequent keys:")
+		for _, k := range r.Keys {
+			fmt.Fprintf(tw, "  %d\t%s\n", k.Count, quote(k.Name))
+		}
+	}
+	if len(r.Strings) > 0 {
+		fmt.Fprintln(tw, "\nLongest strings:")
+		for _, e := range r.Strings {
+			fmt.Fprintf(tw, "  %s\t%s\n", formatBytes(e.Length), pointer(e.Pointer))
+		}
+	}
+	if len(r.Arrays) > 0 {
+		fmt.Fprintln(tw, "\nLongest arrays:")
+		for _, e := range r.Arrays {
+			fmt.Fprintf(tw, "  %d elements\t%s\t%s\n", e.Length, formatBytes(e.Bytes), pointer(e.Pointer))
+		}
+	}
+
+	fmt.Fprintln(tw, "\nSize breakdown:")
+	r.writeSubtree(tw, r.Sizes, 1)
+	return tw.Flush()
+}
+
+// writeSubtree writes a line for a subtree and, indented below it, its
+// children.
+func (r *Report) writeSubtree(w io.Writer, s *Subtree, level int) {
+	indent := strings.Repeat("  ", level)
+	fmt.Fprintf(w, "%s%s\t%s\t%s\n", indent, pointer(s.Pointer), formatBytes(s.Bytes), r.share(s.Bytes))
+	for _, child := range s.Children {
+		r.writeSubtree(w, child, level+1)
+	}
+	if s.Omitted > 0 {
+		fmt.Fprintf(w, "%s  (%d more)\t%s\t%s\n", indent, s.Omitted, formatBytes(s.OmittedBytes), r.share(s.OmittedBytes))
+	}
+}
+
+// share formats a size as a percentage of the document.
+func (r *Report) share(bytes int) string {
+	if r.Bytes == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(bytes)/float64(r.Bytes))
+}
+
+// Value returns the report as a JSON object with the members bytes,
+// values, counts, depth, keys, strings, arrays and sizes.
+func (r *Report) Value() *parser.ObjectValue {
+	counts := parser.Object()
+	for _, c := range r.Counts.named() {
+		counts.Set(c.name, parser.Number(float64(c.count)))
+	}
+	histogram := parser.Array()
+	for _, n := range r.Depths {
+		histogram.Append(parser.Number(float64(n)))
+	}
+	keys := parser.Array()
+	for _, k := range r.Keys {
+		keys.Append(parser.Object().Set("key", parser.String(k.Name)).Set("count", parser.Number(float64(k.Count))))
+	}
+
+	return parser.Object().
+		Set("bytes", parser.Number(float64(r.Bytes))).
+		Set("values", parser.Number(float64(r.Counts.Total()))).
+		Set("counts", counts).
+		Set("depth", parser.Object().
+			Set("max", parser.Number(float64(r.MaxDepth))).
+			Set("average", parser.Number(r.AverageDepth)).
+			Set("histogram", histogram)).
+		Set("keys", keys).
+		Set("strings", entries(r.Strings)).
+		Set("arrays", entries(r.Arrays)).
+		Set("sizes", r.Sizes.value())
+}
+
+func entries(list []Entry) *parser.ArrayValue {
+	a := parser.Array()
+	for _, e := range list {
+		a.Append(parser.Object().
+			Set("pointer", parser.String(e.Pointer)).
+			Set("length", parser.Number(float64(e.Length))).
+			Set("bytes", parser.Number(float64(e.Bytes))))
+	}
+	return a
+}
+
+func (s *Subtree) value() *parser.ObjectValue {
+	o := parser.Object().
+		Set("pointer", parser.String(s.Pointer)).
+		Set("bytes", parser.Number(float64(s.Bytes)))
+	if len(s.Children) > 0 {
+		children := parser.Array()
+		for _, child := range s.Children {
+			children.Append(child.value())
+		}
+		o.Set("children", children)
+	}
+	if s.Omitted > 0 {
+		o.Set("omitted", parser.Number(float64(s.Omitted)))
+		o.Set("omittedBytes", parser.Number(float64(s.OmittedBytes)))
+	}
+	return o
+}
+
+type namedCount struct {
+	name  string
+	count int
+}
+
+// named returns the counts under the type names of parser.TypeName.
+func (c Counts) named() []namedCount {
+	return []namedCount{
+		{"object", c.Objects},
+		{"array", c.Arrays},
+		{"string", c.Strings},
+		{"number", c.Numbers},
+		{"boolean", c.Booleans},
+		{"null", c.Nulls},
+	}
+}
+
+// pointer shows a JSON Pointer, naming the empty pointer of the root.
+func pointer(p string) string {
+	if p == "" {
+		return "(root)"
+	}
+	return p
+}
+
+// quote shows a key in double quotes when it is empty or has spaces.
+func quote(key string) string {
+	if key == "" || strings.ContainsAny(key, " \t\n") {
+		return fmt.Sprintf("%q", key)
+	}
+	return key
+}
+
+// formatBytes formats a size in bytes with a binary unit.
+func formatBytes(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		size /= 1024
+		if size < 1024 || unit == "GiB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,230 @@
+// Package stats profiles JSON documents: how many values of each type they
+// hold, how deeply those nest, which keys repeat, and which strings,
+// arrays and subtrees take up their bytes.
+package stats
+
+import (
+	"sort"
+
+	"github.com/letsmakecakes/jsonparser/internal/cst"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Options configure a profile.
+type Options struct {
+	Top   int // Entries in each ranking, and children per subtree
+	Depth int // Levels of the size breakdown below the root
+}
+
+// DefaultOptions returns the options Profile uses.
+func DefaultOptions() Options {
+	return Options{Top: 10, Depth: 2}
+}
+
+// Report is the profile of one document. Sizes are measured in the source
+// text, so they include the whitespace and comments inside a value.
+type Report struct {
+	Bytes        int     // Size of the root value
+	Counts       Counts  // Values of each type
+	MaxDepth     int     // Depth of the deepest value; the root is at depth 0
+	AverageDepth float64 // Mean depth of all values
+	Depths       []int   // Depths[d] is the number of values at depth d
+	Keys         []Key   // Most frequent member names, most frequent first
+	Strings      []Entry // Longest strings, by decoded length in bytes
+	Arrays       []Entry // Longest arrays, by number of elements
+	Sizes        *Subtree
+}
+
+// Counts holds the number of values of each type.
+type Counts struct {
+	Objects, Arrays, Strings, Numbers, Booleans, Nulls int
+}
+
+// Total returns the number of values.
+func (c Counts) Total() int {
+	return c.Objects + c.Arrays + c.Strings + c.Numbers + c.Booleans + c.Nulls
+}
+
+// Key is a member name and how many objects use it.
+type Key struct {
+	Name  string
+	Count int
+}
+
+// Entry locates a string or array by its RFC 6901 JSON Pointer.
+type Entry struct {
+	Pointer string
+	Length  int // Bytes of a string, elements of an array
+	Bytes   int // Size in the source
+}
+
+// Subtree is the size of a value and of its largest children. Children
+// are listed largest first, up to Options.Top; the rest are summed in
+// Omitted and OmittedBytes.
+type Subtree struct {
+	Pointer      string
+	Bytes        int
+	Children     []*Subtree
+	Omitted      int
+	OmittedBytes int
+}
+
+// Profile parses input in the given dialect and profiles it with the
+// default options.
+func Profile(input string, dialect lexer.Dialect) (*Report, error) {
+	root, err := cst.Parse(input, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return Collect(root, DefaultOptions()), nil
+}
+
+// Collect profiles the value of a concrete syntax tree, which may be a
+// DocumentNode or any value node. Negative options count as 0.
+func Collect(root *cst.Node, opts Options) *Report {
+	opts.Top, opts.Depth = max(0, opts.Top), max(0, opts.Depth)
+	if root.Kind == cst.DocumentNode {
+		root = root.Value()
+	}
+	c := &collector{
+		opts:    opts,
+		keys:    make(map[string]int),
+		strings: ranking{n: opts.Top},
+		arrays:  ranking{n: opts.Top},
+	}
+	c.visit(root, 0, nil)
+
+	r := &Report{
+		Bytes:    size(root),
+		Counts:   c.counts,
+		MaxDepth: len(c.depths) - 1,
+		Depths:   c.depths,
+		Strings:  c.strings.entries,
+		Arrays:   c.arrays.entries,
+		Sizes:    breakdown(root, nil, opts),
+	}
+	total := 0
+	for depth, n := range c.depths {
+		total += depth * n
+	}
+	r.AverageDepth = float64(total) / float64(c.counts.Total())
+
+	for name, count := range c.keys {
+		r.Keys = append(r.Keys, Key{Name: name, Count: count})
+	}
+	sort.Slice(r.Keys, func(i, j int) bool {
+		if r.Keys[i].Count != r.Keys[j].Count {
+			return r.Keys[i].Count > r.Keys[j].Count
+		}
+		return r.Keys[i].Name < r.Keys[j].Name
+	})
+	if len(r.Keys) > opts.Top {
+		r.Keys = r.Keys[:opts.Top]
+	}
+	return r
+}
+
+type collector struct {
+	opts    Options
+	counts  Counts
+	depths  []int
+	keys    map[string]int
+	strings ranking
+	arrays  ranking
+}
+
+// visit counts a value node at path and everything below it.
+func (c *collector) visit(n *cst.Node, depth int, path parser.Path) {
+	if depth == len(c.depths) {
+		c.depths = append(c.depths, 0)
+	}
+	c.depths[depth]++
+
+	switch n.Kind {
+	case cst.ObjectNode:
+		c.counts.Objects++
+		for _, member := range n.Nodes() {
+			key := member.Key().Value
+			c.keys[key]++
+			c.visit(member.Value(), depth+1, append(path, parser.KeySegment(key)))
+		}
+	case cst.ArrayNode:
+		c.counts.Arrays++
+		elements := n.Nodes()
+		c.arrays.add(len(elements), n, path)
+		for i, elem := range elements {
+			c.visit(elem, depth+1, append(path, parser.IndexSegment(i)))
+		}
+	case cst.ScalarNode:
+		tok := n.Children[0].(*cst.Token)
+		switch tok.Type {
+		case lexer.STRING:
+			c.counts.Strings++
+			c.strings.add(len(tok.Value), n, path)
+		case lexer.NUMBER:
+			c.counts.Numbers++
+		case lexer.TRUE, lexer.FALSE:
+			c.counts.Booleans++
+		default:
+			c.counts.Nulls++
+		}
+	}
+}
+
+// ranking keeps the n longest values added, longest first. Of values of
+// equal length, the first added ranks higher.
+type ranking struct {
+	n       int
+	entries []Entry
+}
+
+func (r *ranking) add(length int, n *cst.Node, path parser.Path) {
+	i := sort.Search(len(r.entries), func(i int) bool { return r.entries[i].Length < length })
+	if i >= r.n {
+		return
+	}
+	r.entries = append(r.entries, Entry{})
+	copy(r.entries[i+1:], r.entries[i:])
+	r.entries[i] = Entry{Pointer: path.Pointer(), Length: length, Bytes: size(n)}
+	if len(r.entries) > r.n {
+		r.entries = r.entries[:r.n]
+	}
+}
+
+// breakdown returns the size of a value node at path and, down to
+// opts.Depth levels, of its largest children.
+func breakdown(n *cst.Node, path parser.Path, opts Options) *Subtree {
+	s := &Subtree{Pointer: path.Pointer(), Bytes: size(n)}
+	if len(path) >= opts.Depth {
+		return s
+	}
+
+	var children []*Subtree
+	switch n.Kind {
+	case cst.ObjectNode:
+		for _, member := range n.Nodes() {
+			children = append(children, breakdown(member.Value(), append(path.Clone(), parser.KeySegment(member.Key().Value)), opts))
+		}
+	case cst.ArrayNode:
+		for i, elem := range n.Nodes() {
+			children = append(children, breakdown(elem, append(path.Clone(), parser.IndexSegment(i)), opts))
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool { return children[i].Bytes > children[j].Bytes })
+	if len(children) > opts.Top {
+		for _, child := range children[opts.Top:] {
+			s.Omitted++
+			s.OmittedBytes += child.Bytes
+		}
+		children = children[:opts.Top]
+	}
+	s.Children = children
+	return s
+}
+
+// size returns the number of source bytes a value node spans.
+func size(n *cst.Node) int {
+	start, end := n.Span()
+	return end - start
+}
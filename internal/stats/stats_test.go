@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/cst"
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+const document = `{
+  "id": 1,
+  "name": "a long name",
+  "tags": ["x", "yy", null, true],
+  "items": [{"id": 2, "note": "abc"}, {"id": 3}],
+  "a/b": []
+}`
+
+func collect(t *testing.T, input string, opts Options) *Report {
+	t.Helper()
+	root, err := cst.Parse(input, lexer.JSON5)
+	if err != nil {
+		t.Fatalf("invalid JSON %q: %v", input, err)
+	}
+	return Collect(root, opts)
+}
+
+func TestCollect(t *testing.T) {
+	r := collect(t, document, Options{Top: 2, Depth: 2})
+
+	if r.Bytes != len(document) {
+		t.Errorf("expected %d bytes, got %d", len(document), r.Bytes)
+	}
+	expected := Counts{Objects: 3, Arrays: 3, Strings: 4, Numbers: 3, Booleans: 1, Nulls: 1}
+	if r.Counts != expected {
+		t.Errorf("expected counts %+v, got %+v", expected, r.Counts)
+	}
+	if r.MaxDepth != 3 || len(r.Depths) != 4 || r.Depths[0] != 1 || r.Depths[1] != 5 || r.Depths[2] != 6 || r.Depths[3] != 3 {
+		t.Errorf("expected max depth 3 and histogram [1 5 6 3], got %d and %v", r.MaxDepth, r.Depths)
+	}
+	if average := float64(5+12+9) / 15; r.AverageDepth != average {
+		t.Errorf("expected average depth %v, got %v", average, r.AverageDepth)
+	}
+
+	if len(r.Keys) != 2 || r.Keys[0] != (Key{"id", 3}) || r.Keys[1] != (Key{"a/b", 1}) {
+		t.Errorf("expected keys id (3) and a/b (1), got %v", r.Keys)
+	}
+	if len(r.Strings) != 2 || r.Strings[0] != (Entry{"/name", 11, 13}) || r.Strings[1] != (Entry{"/items/0/note", 3, 5}) {
+		t.Errorf("unexpected longest strings %v", r.Strings)
+	}
+	if len(r.Arrays) != 2 || r.Arrays[0] != (Entry{"/tags", 4, 23}) || r.Arrays[1] != (Entry{"/items", 2, 37}) {
+		t.Errorf("unexpected longest arrays %v", r.Arrays)
+	}
+
+	s := r.Sizes
+	if s.Pointer != "" || s.Bytes != len(document) || len(s.Children) != 2 || s.Omitted != 3 {
+		t.Fatalf("unexpected root subtree %+v", s)
+	}
+	items, tags := s.Children[0], s.Children[1]
+	if items.Pointer != "/items" || items.Bytes != 37 || tags.Pointer != "/tags" || tags.Bytes != 23 {
+		t.Errorf("expected /items and /tags to be largest, got %+v and %+v", items, tags)
+	}
+	if s.OmittedBytes != 1+13+2 {
+		t.Errorf("expected 16 omitted bytes, got %d", s.OmittedBytes)
+	}
+	if len(items.Children) != 2 || items.Children[0].Pointer != "/items/0" || len(items.Children[0].Children) != 0 {
+		t.Errorf("expected the breakdown to stop at depth 2, got %+v", items.Children)
+	}
+	if s.Children[1].Children[0].Pointer != "/tags/1" {
+		t.Errorf("expected /tags/1 to be the largest tag, got %s", s.Children[1].Children[0].Pointer)
+	}
+}
+
+func TestCollect_Scalar(t *testing.T) {
+	r := collect(t, ` "x" `, DefaultOptions())
+	if r.Bytes != 3 || r.Counts.Total() != 1 || r.MaxDepth != 0 || r.AverageDepth != 0 {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if len(r.Keys) != 0 || len(r.Arrays) != 0 || len(r.Strings) != 1 || r.Strings[0].Pointer != "" {
+		t.Errorf("unexpected rankings %v %v %v", r.Keys, r.Strings, r.Arrays)
+	}
+}
+
+func TestCollect_NegativeOptions(t *testing.T) {
+	r := collect(t, document, Options{Top: -1, Depth: -1})
+	if len(r.Keys) != 0 || len(r.Strings) != 0 || len(r.Arrays) != 0 {
+		t.Errorf("expected empty rankings, got %v %v %v", r.Keys, r.Strings, r.Arrays)
+	}
+	if len(r.Sizes.Children) != 0 || r.Sizes.Bytes != len(document) {
+		t.Errorf("expected only the root size, got %+v", r.Sizes)
+	}
+}
+
+func TestProfile_Errors(t *testing.T) {
+	if _, err := Profile(`{"a": }`, lexer.Strict); err == nil {
+		t.Errorf("expected invalid JSON to fail")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var sb strings.Builder
+	if err := collect(t, document, Options{Top: 2, Depth: 1}).WriteText(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := sb.String()
+	for _, want := range []string{
+		"Size:      136 B\n",
+		"Values:    15\n",
+		"  string   4\n",
+		"Depth:     max 3, average 1.73\n",
+		"  0  1  ######\n",
+		"  2  6  " + strings.Repeat("#", 40) + "\n",
+		"  3  id\n",
+		"  11 B  /name\n",
+		"  4 elements  23 B  /tags\n",
+		"  (root)      136 B  100.0%\n",
+		"    /items    37 B   27.2%\n",
+		"    (3 more)  16 B   11.8%\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the report to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	got := printer.New("").Print(collect(t, `[{"k": "v"}]`, DefaultOptions()).Value())
+	expected := `{"bytes":12,"values":3,"counts":{"object":1,"array":1,"string":1,"number":0,"boolean":0,"null":0},` +
+		`"depth":{"max":2,"average":1,"histogram":[1,1,1]},"keys":[{"key":"k","count":1}],` +
+		`"strings":[{"pointer":"/0/k","length":1,"bytes":3}],"arrays":[{"pointer":"","length":1,"bytes":12}],` +
+		`"sizes":{"pointer":"","bytes":12,"children":[{"pointer":"/0","bytes":10,"children":[{"pointer":"/0/k","bytes":3}]}]}}`
+	if got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,120 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// jsonNumber matches the JSON number syntax, so values such as "007" or
+// "1e" stay strings.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// ReadCSV reads delimiter-separated values with a header row into an array
+// of objects, one per row. Types are inferred per column: a column whose
+// non-empty cells are all JSON numbers holds numbers, one whose cells are
+// all true or false (in any case) holds booleans, and any other column holds
+// strings. Empty cells are null. Dotted column names are expanded into
+// nested objects, reversing the flattening of FromValue.
+func ReadCSV(r io.Reader, comma rune) (*parser.ArrayValue, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.LazyQuotes = comma == '\t' // TSV rarely quotes, so quotes are literal
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return parser.Array(), nil
+	}
+
+	header, rows := records[0], records[1:]
+	if err := checkColumns(header); err != nil {
+		return nil, err
+	}
+	kinds := make([]func(string) parser.Value, len(header))
+	for i := range header {
+		kinds[i] = inferColumn(rows, i)
+	}
+
+	result := parser.Array()
+	for _, row := range rows {
+		record := parser.Object()
+		for i, column := range header {
+			var value parser.Value = &parser.NullValue{}
+			if row[i] != "" {
+				value = kinds[i](row[i])
+			}
+			setPath(record, strings.Split(column, "."), value)
+		}
+		result.Append(record)
+	}
+	return result, nil
+}
+
+// checkColumns rejects duplicate columns, and columns that would be both a
+// value and an object once dotted names are expanded.
+func checkColumns(header []string) error {
+	seen := make(map[string]bool, len(header))
+	for _, column := range header {
+		if seen[column] {
+			return fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = true
+	}
+	for _, column := range header {
+		parts := strings.Split(column, ".")
+		for i := 1; i < len(parts); i++ {
+			if prefix := strings.Join(parts[:i], "."); seen[prefix] {
+				return fmt.Errorf("column %q conflicts with column %q", column, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// inferColumn returns the conversion for the cells of a column.
+func inferColumn(rows [][]string, column int) func(string) parser.Value {
+	numbers, booleans := true, true
+	for _, row := range rows {
+		cell := row[column]
+		if cell == "" {
+			continue
+		}
+		numbers = numbers && jsonNumber.MatchString(cell)
+		booleans = booleans && (strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false"))
+	}
+	switch {
+	case numbers:
+		return func(cell string) parser.Value {
+			f, _ := strconv.ParseFloat(cell, 64)
+			return parser.Number(f)
+		}
+	case booleans:
+		return func(cell string) parser.Value {
+			return parser.Bool(strings.EqualFold(cell, "true"))
+		}
+	default:
+		return func(cell string) parser.Value {
+			return parser.String(cell)
+		}
+	}
+}
+
+// setPath stores value under a dotted path, creating nested objects.
+func setPath(o *parser.ObjectValue, path []string, value parser.Value) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := o.Pairs[key].(*parser.ObjectValue)
+		if !ok {
+			child = parser.Object()
+			o.Set(key, child)
+		}
+		o = child
+	}
+	o.Set(path[len(path)-1], value)
+}
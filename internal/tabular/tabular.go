@@ -0,0 +1,179 @@
+// Package tabular converts arrays of JSON objects to tables and back. A
+// table's columns are the union of the records' members, with nested
+// objects flattened into dotted column names; tables can be written as
+// CSV, TSV, Markdown, an aligned text table or SQL, and CSV can be read
+// back into records.
+package tabular
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// Table holds records as rows of cells under named columns. A nil cell
+// means the record has no such member.
+type Table struct {
+	Columns []string
+	Rows    [][]parser.Value
+}
+
+// FromValue builds a table from an array of objects. Columns appear in the
+// order they are first seen. Nested objects are flattened, so {"a": {"b": 1}}
+// fills column "a.b"; arrays and empty objects are kept whole in one cell.
+// A member that is an object in some records and null or missing in others
+// only gets the columns of its object members, which are empty in the
+// other records. A column that holds values and is also the prefix of
+// another, as with {"a": 2, "a.b": 1} or a member that is a string in one
+// record and an object in another, is an error, since it cannot be read
+// back.
+func FromValue(v parser.Value) (*Table, error) {
+	records, ok := v.(*parser.ArrayValue)
+	if !ok {
+		return nil, fmt.Errorf("a table needs an array of objects, got %s", parser.TypeName(v))
+	}
+
+	t := &Table{}
+	index := make(map[string]int) // Column name to position
+	for i, elem := range records.Elements {
+		record, ok := elem.(*parser.ObjectValue)
+		if !ok {
+			return nil, fmt.Errorf("a table needs an array of objects, but element %d is %s", i, parser.TypeName(elem))
+		}
+
+		cells := make(map[string]parser.Value)
+		err := flatten("", record, cells, func(column string) {
+			if _, ok := index[column]; !ok {
+				index[column] = len(t.Columns)
+				t.Columns = append(t.Columns, column)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		row := make([]parser.Value, 0, len(t.Columns))
+		for _, column := range t.Columns {
+			row = append(row, cells[column])
+		}
+		t.Rows = append(t.Rows, row)
+	}
+
+	// Rows read before a column appeared are shorter
+	for i, row := range t.Rows {
+		for len(row) < len(t.Columns) {
+			row = append(row, nil)
+		}
+		t.Rows[i] = row
+	}
+	if err := t.dropShadowed(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// dropShadowed removes the columns of members that other records hold as
+// objects: with {"meta": null} and {"meta": {"x": 1}}, column "meta"
+// would conflict with "meta.x" when the table is read back, so only
+// "meta.x" is kept, and it is empty in the first record. A shadowed column
+// with a value that is not null would lose it, so that is an error.
+func (t *Table) dropShadowed() error {
+	objects := make(map[string]string) // Prefix to the first column below it
+	for _, column := range t.Columns {
+		for i := range column {
+			if _, ok := objects[column[:i]]; column[i] == '.' && !ok {
+				objects[column[:i]] = column
+			}
+		}
+	}
+	keep := make([]int, 0, len(t.Columns))
+	for i, column := range t.Columns {
+		nested, ok := objects[column]
+		if !ok {
+			keep = append(keep, i)
+			continue
+		}
+		for _, row := range t.Rows {
+			switch row[i].(type) {
+			case nil, *parser.NullValue:
+			default:
+				return fmt.Errorf("column %q conflicts with column %q: reading the table back would nest one in the other", column, nested)
+			}
+		}
+	}
+	if len(keep) == len(t.Columns) {
+		return nil
+	}
+
+	columns := make([]string, len(keep))
+	for i, k := range keep {
+		columns[i] = t.Columns[k]
+	}
+	t.Columns = columns
+	for r, row := range t.Rows {
+		cells := make([]parser.Value, len(keep))
+		for i, k := range keep {
+			cells[i] = row[k]
+		}
+		t.Rows[r] = cells
+	}
+	return nil
+}
+
+// flatten stores the leaves of an object under dotted column names,
+// reporting each column in member order. Two members that fill the same
+// column, such as {"a.b": 1, "a": {"b": 2}}, are an error.
+func flatten(prefix string, o *parser.ObjectValue, cells map[string]parser.Value, column func(string)) error {
+	for _, key := range o.OrderedKeys() {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		if child, ok := o.Pairs[key].(*parser.ObjectValue); ok && len(child.Pairs) > 0 {
+			if err := flatten(name, child, cells, column); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := cells[name]; ok {
+			return fmt.Errorf("two members fill column %q", name)
+		}
+		column(name)
+		cells[name] = o.Pairs[key]
+	}
+	return nil
+}
+
+// formatCell formats a cell as text: strings as they are, missing and null
+// cells as the empty string, and arrays and objects as compact JSON.
+func formatCell(v parser.Value) string {
+	switch n := v.(type) {
+	case nil, *parser.NullValue:
+		return ""
+	case *parser.StringValue:
+		return n.Value
+	case *parser.NumberValue:
+		return printer.FormatNumber(n.Value)
+	case *parser.BooleanValue:
+		return strconv.FormatBool(n.Value)
+	default:
+		return printer.New("").Print(v)
+	}
+}
+
+// isNumeric reports whether every present cell of a column is a number, and
+// at least one is.
+func (t *Table) isNumeric(column int) bool {
+	found := false
+	for _, row := range t.Rows {
+		switch row[column].(type) {
+		case nil, *parser.NullValue:
+		case *parser.NumberValue:
+			found = true
+		default:
+			return false
+		}
+	}
+	return found
+}
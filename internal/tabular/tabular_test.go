@@ -0,0 +1,242 @@
+package tabular
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+const records = `[
+	{"id": 1, "name": "Ada", "address": {"city": "London", "zip": "N1"}, "tags": ["math", "code"], "active": true},
+	{"id": 2, "name": "Grace | Hopper", "score": 9.5, "address": {"city": "New York"}, "active": false},
+	{"id": 3, "name": "Linus\n\"T\"", "profile": {}, "score": null}
+]`
+
+func table(t *testing.T, input string) *Table {
+	t.Helper()
+	tab, err := FromValue(testutil.JSON(t, input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return tab
+}
+
+func TestFromValue(t *testing.T) {
+	tab := table(t, records)
+	expected := []string{"id", "name", "address.city", "address.zip", "tags", "active", "score", "profile"}
+	if strings.Join(tab.Columns, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected columns %v, got %v", expected, tab.Columns)
+	}
+	if len(tab.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(tab.Rows))
+	}
+	for i, row := range tab.Rows {
+		if len(row) != len(tab.Columns) {
+			t.Errorf("row %d has %d cells, expected %d", i, len(row), len(tab.Columns))
+		}
+	}
+	if tab.Rows[0][6] != nil {
+		t.Errorf("expected a missing cell to be nil, got %v", tab.Rows[0][6])
+	}
+	if got := formatCell(tab.Rows[0][4]); got != `["math","code"]` {
+		t.Errorf("expected arrays as compact JSON, got %s", got)
+	}
+}
+
+func TestFromValue_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`{"a": 1}`, "needs an array of objects, got object"},
+		{`[{"a": 1}, 2]`, "element 1 is number"},
+		{`[{"a.b": 1, "a": 2}]`, `column "a" conflicts with column "a.b"`},
+		{`[{"meta": "hello"}, {"meta": {"x": 1}}]`, `column "meta" conflicts with column "meta.x"`},
+		{`[{"meta": {}}, {"meta": {"x": 1}}]`, `column "meta" conflicts with column "meta.x"`},
+		{`[{"id": 1}, {"a.b": 1, "a": {"b": 2}}]`, `element 1: two members fill column "a.b"`},
+	}
+	for _, tt := range tests {
+		_, err := FromValue(testutil.JSON(t, tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.message, err)
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	tests := []struct {
+		name     string
+		write    func(*Table, *strings.Builder) error
+		expected string
+	}{
+		{"csv", func(tab *Table, sb *strings.Builder) error { return tab.WriteCSV(sb, ',') },
+			"id,name,address.city,address.zip,tags,active,score,profile\n" +
+				"1,Ada,London,N1,\"[\"\"math\"\",\"\"code\"\"]\",true,,\n" +
+				"2,Grace | Hopper,New York,,,false,9.5,\n" +
+				"3,\"Linus\n\"\"T\"\"\",,,,,,{}\n"},
+		{"tsv", func(tab *Table, sb *strings.Builder) error { return tab.WriteCSV(sb, '\t') },
+			"id\tname\taddress.city\taddress.zip\ttags\tactive\tscore\tprofile\n" +
+				"1\tAda\tLondon\tN1\t\"[\"\"math\"\",\"\"code\"\"]\"\ttrue\t\t\n" +
+				"2\tGrace | Hopper\tNew York\t\t\tfalse\t9.5\t\n" +
+				"3\t\"Linus\n\"\"T\"\"\"\t\t\t\t\t\t{}\n"},
+		{"markdown", func(tab *Table, sb *strings.Builder) error { return tab.WriteMarkdown(sb) },
+			"| id | name | address.city | address.zip | tags | active | score | profile |\n" +
+				"|---:|---|---|---|---|---|---:|---|\n" +
+				"| 1 | Ada | London | N1 | [\"math\",\"code\"] | true |  |  |\n" +
+				"| 2 | Grace \\| Hopper | New York |  |  | false | 9.5 |  |\n" +
+				"| 3 | Linus<br>\"T\" |  |  |  |  |  | {} |\n"},
+		{"aligned", func(tab *Table, sb *strings.Builder) error { return tab.WriteAligned(sb) },
+			"id  name            address.city  address.zip  tags             active  score  profile\n" +
+				"--  --------------  ------------  -----------  ---------------  ------  -----  -------\n" +
+				" 1  Ada             London        N1           [\"math\",\"code\"]  true\n" +
+				" 2  Grace | Hopper  New York                                    false     9.5\n" +
+				" 3  Linus\\n\"T\"                                                                 {}\n"},
+		{"sql", func(tab *Table, sb *strings.Builder) error { return tab.WriteSQL(sb, "people") },
+			"CREATE TABLE \"people\" (\n" +
+				"  \"id\" INTEGER,\n  \"name\" TEXT,\n  \"address.city\" TEXT,\n  \"address.zip\" TEXT,\n" +
+				"  \"tags\" TEXT,\n  \"active\" BOOLEAN,\n  \"score\" DOUBLE PRECISION,\n  \"profile\" TEXT\n);\n" +
+				"INSERT INTO \"people\" (\"id\", \"name\", \"address.city\", \"address.zip\", \"tags\", \"active\", \"score\", \"profile\") " +
+				"VALUES (1, 'Ada', 'London', 'N1', '[\"math\",\"code\"]', TRUE, NULL, NULL);\n" +
+				"INSERT INTO \"people\" (\"id\", \"name\", \"address.city\", \"address.zip\", \"tags\", \"active\", \"score\", \"profile\") " +
+				"VALUES (2, 'Grace | Hopper', 'New York', NULL, NULL, FALSE, 9.5, NULL);\n" +
+				"INSERT INTO \"people\" (\"id\", \"name\", \"address.city\", \"address.zip\", \"tags\", \"active\", \"score\", \"profile\") " +
+				"VALUES (3, 'Linus\n\"T\"', NULL, NULL, NULL, NULL, NULL, '{}');\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := tt.write(table(t, records), &sb); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sb.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, sb.String())
+			}
+		})
+	}
+}
+
+func TestSQLType(t *testing.T) {
+	tests := []struct {
+		values   string
+		expected string
+	}{
+		{`[1, 2, null]`, "INTEGER"},
+		{`[1, 2.5]`, "DOUBLE PRECISION"},
+		{`[true, false]`, "BOOLEAN"},
+		{`[true, 1]`, "TEXT"},
+		{`["a", 1]`, "TEXT"},
+		{`[null]`, "TEXT"},
+		{`[[1]]`, "TEXT"},
+	}
+	for _, tt := range tests {
+		values := testutil.JSON(t, tt.values).(*parser.ArrayValue)
+		records := parser.Array()
+		for _, v := range values.Elements {
+			records.Append(parser.Object().Set("c", v))
+		}
+		tab, err := FromValue(records)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := tab.SQLType(0); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.values, tt.expected, got)
+		}
+	}
+
+	// Strings in SQL escape single quotes
+	tab := table(t, `[{"s": "it's"}]`)
+	var sb strings.Builder
+	tab.WriteSQL(&sb, `odd"name`)
+	if !strings.Contains(sb.String(), `CREATE TABLE "odd""name"`) || !strings.Contains(sb.String(), `VALUES ('it''s');`) {
+		t.Errorf("expected quoted identifiers and literals, got:\n%s", sb.String())
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		comma    rune
+		expected string // JSON5
+	}{
+		{"type inference", "id,name,price,active,zip\n1,Ada,9.5,true,007\n2,Bob,-1e3,FALSE,10001\n",
+			',', `[{"id": 1, "name": "Ada", "price": 9.5, "active": true, "zip": "007"}, {"id": 2, "name": "Bob", "price": -1000, "active": false, "zip": "10001"}]`},
+		{"empty cells are null", "a,b\n1,\n,x\n", ',', `[{"a": 1, "b": null}, {"a": null, "b": "x"}]`},
+		{"mixed column stays text", "v\n1\ntrue\nx\n", ',', `[{"v": "1"}, {"v": "true"}, {"v": "x"}]`},
+		{"quoted fields", "a,b\n\"x, y\",\"line\nbreak \"\"q\"\"\"\n", ',', `[{"a": "x, y", "b": "line\nbreak \"q\""}]`},
+		{"dotted columns nest", "id,address.city,address.geo.lat\n1,London,51.5\n", ',', `[{"id": 1, "address": {"city": "London", "geo": {"lat": 51.5}}}]`},
+		{"tsv", "a\tb\n1\tsay \"hi\"\n", '\t', `[{"a": 1, "b": "say \"hi\""}]`},
+		{"header only", "a,b\n", ',', `[]`},
+		{"empty", "", ',', `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.input), tt.comma)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"duplicate column", "a,a\n1,2\n", `duplicate column "a"`},
+		{"conflicting columns", "a,a.b\n1,2\n", `column "a.b" conflicts with column "a"`},
+		{"ragged row", "a,b\n1\n", "wrong number of fields"},
+		{"bad quotes", "a\n\"x\n", "extraneous or missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tt.input), ',')
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+// toCSV and fromCSV convert records to CSV and back.
+func toCSV(v parser.Value) (string, error) {
+	tab, err := FromValue(v)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = tab.WriteCSV(&sb, ',')
+	return sb.String(), err
+}
+
+func fromCSV(text string) (parser.Value, error) {
+	return ReadCSV(strings.NewReader(text), ',')
+}
+
+func TestRoundTrip(t *testing.T) {
+	testutil.RoundTrip(t, `[{"id": 1, "user": {"name": "Ada", "admin": true}}, {"id": 2, "user": {"name": "Bob", "admin": false}}]`, toCSV, fromCSV)
+}
+
+func TestRoundTrip_MixedObjects(t *testing.T) {
+	// meta is an object in one record and null or missing in the others
+	input := `[{"meta": null, "id": 1}, {"meta": {"x": 1, "y": {"z": true}}, "id": 2}, {"id": 3}]`
+	if got := strings.Join(table(t, input).Columns, ","); got != "id,meta.x,meta.y.z" {
+		t.Errorf("expected columns id,meta.x,meta.y.z, got %s", got)
+	}
+	text, err := toCSV(testutil.JSON(t, input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := fromCSV(text)
+	if err != nil {
+		t.Fatalf("expected the CSV to read back, got %v:\n%s", err, text)
+	}
+	testutil.Expect(t, got, `[{"id": 1, "meta": {"x": null, "y": {"z": null}}}, {"id": 2, "meta": {"x": 1, "y": {"z": true}}}, {"id": 3, "meta": {"x": null, "y": {"z": null}}}]`)
+}
@@ -0,0 +1,217 @@
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// WriteCSV writes the table as delimiter-separated values with a header
+// row, quoting fields as RFC 4180 requires. Use ',' for CSV and '\t' for TSV.
+func (t *Table) WriteCSV(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the table as a GitHub Flavored Markdown table.
+// Numeric columns are right-aligned; pipes are escaped and line breaks
+// become <br>.
+func (t *Table) WriteMarkdown(w io.Writer) error {
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	bw := bufio.NewWriter(w)
+
+	writeRow := func(cells []string) {
+		bw.WriteString("|")
+		for _, cell := range cells {
+			bw.WriteString(" " + escape.Replace(cell) + " |")
+		}
+		bw.WriteString("\n")
+	}
+
+	writeRow(t.Columns)
+	separators := make([]string, len(t.Columns))
+	for i := range t.Columns {
+		separators[i] = "---"
+		if t.isNumeric(i) {
+			separators[i] = "---:"
+		}
+	}
+	bw.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	for _, row := range t.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = formatCell(cell)
+		}
+		writeRow(cells)
+	}
+	return bw.Flush()
+}
+
+// WriteAligned writes the table for a terminal: columns padded to equal
+// width with numbers right-aligned, and a rule under the header. Line breaks
+// and tabs in cells are shown escaped.
+func (t *Table) WriteAligned(w io.Writer) error {
+	escape := strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`)
+	cells := make([][]string, len(t.Rows))
+	widths := make([]int, len(t.Columns))
+	for i, column := range t.Columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	for r, row := range t.Rows {
+		cells[r] = make([]string, len(row))
+		for i, cell := range row {
+			cells[r][i] = escape.Replace(formatCell(cell))
+			widths[i] = max(widths[i], utf8.RuneCountInString(cells[r][i]))
+		}
+	}
+
+	numeric := make([]bool, len(t.Columns))
+	for i := range t.Columns {
+		numeric[i] = t.isNumeric(i)
+	}
+	bw := bufio.NewWriter(w)
+	writeRow := func(row []string) {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if numeric[i] {
+				line.WriteString(pad + cell)
+			} else {
+				line.WriteString(cell + pad)
+			}
+		}
+		bw.WriteString(strings.TrimRight(line.String(), " ") + "\n") // No trailing blanks
+	}
+
+	writeRow(t.Columns)
+	rules := make([]string, len(t.Columns))
+	for i, width := range widths {
+		rules[i] = strings.Repeat("-", width)
+	}
+	bw.WriteString(strings.Join(rules, "  ") + "\n")
+	for _, row := range cells {
+		writeRow(row)
+	}
+	return bw.Flush()
+}
+
+// SQLType returns the column type WriteSQL declares for a column:
+// INTEGER, DOUBLE PRECISION, BOOLEAN or TEXT. Columns with mixed types, or
+// with arrays and objects, are TEXT.
+func (t *Table) SQLType(column int) string {
+	integer, number, boolean, text := false, false, false, false
+	for _, row := range t.Rows {
+		switch n := row[column].(type) {
+		case nil, *parser.NullValue:
+		case *parser.NumberValue:
+			if n.Value == math.Trunc(n.Value) && math.Abs(n.Value) < 1<<53 {
+				integer = true
+			} else {
+				number = true
+			}
+		case *parser.BooleanValue:
+			boolean = true
+		default:
+			text = true
+		}
+	}
+	switch {
+	case text || (boolean && (integer || number)):
+		return "TEXT"
+	case boolean:
+		return "BOOLEAN"
+	case number:
+		return "DOUBLE PRECISION"
+	case integer:
+		return "INTEGER"
+	default:
+		return "TEXT"
+	}
+}
+
+// WriteSQL writes a CREATE TABLE statement for the table followed by one
+// INSERT per row. Identifiers are always quoted, since flattened column
+// names contain dots. Missing and null cells, NaN and infinities are NULL;
+// arrays and objects are stored as JSON text.
+func (t *Table) WriteSQL(w io.Writer, name string) error {
+	bw := bufio.NewWriter(w)
+	columns := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		columns[i] = quoteIdentifier(column)
+	}
+
+	fmt.Fprintf(bw, "CREATE TABLE %s (\n", quoteIdentifier(name))
+	for i, column := range columns {
+		fmt.Fprintf(bw, "  %s %s", column, t.SQLType(i))
+		if i < len(columns)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString(");\n")
+
+	types := make([]string, len(t.Columns))
+	for i := range t.Columns {
+		types[i] = t.SQLType(i)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", quoteIdentifier(name), strings.Join(columns, ", "))
+	for _, row := range t.Rows {
+		values := make([]string, len(row))
+		for i, cell := range row {
+			values[i] = sqlLiteral(cell, types[i])
+		}
+		bw.WriteString(prefix + strings.Join(values, ", ") + ");\n")
+	}
+	return bw.Flush()
+}
+
+// quoteIdentifier quotes an SQL identifier, doubling embedded quotes.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlLiteral formats a cell for a column of the given type. In TEXT
+// columns every value is a string literal.
+func sqlLiteral(v parser.Value, columnType string) string {
+	switch n := v.(type) {
+	case nil, *parser.NullValue:
+		return "NULL"
+	case *parser.NumberValue:
+		if math.IsNaN(n.Value) || math.IsInf(n.Value, 0) {
+			return "NULL"
+		}
+		if columnType != "TEXT" {
+			return printer.FormatNumber(n.Value)
+		}
+	case *parser.BooleanValue:
+		if columnType != "TEXT" {
+			return strings.ToUpper(strconv.FormatBool(n.Value))
+		}
+	}
+	return "'" + strings.ReplaceAll(formatCell(v), "'", "''") + "'"
+}
@@ -0,0 +1,62 @@
+// Package testutil holds the fixtures shared by the tests of the codecs
+// and generators: expected values written as JSON5, binary inputs written
+// as hex, and the comparisons every codec makes.
+package testutil
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/lexer"
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// JSON parses a JSON5 literal, so expected values can hold NaN and the
+// infinities, failing the test if it is invalid.
+func JSON(t testing.TB, input string) parser.Value {
+	t.Helper()
+	v, err := parser.New(lexer.NewWithDialect(input, lexer.JSON5)).Parse()
+	if err != nil {
+		t.Fatalf("invalid JSON %q: %v", input, err)
+	}
+	return v
+}
+
+// Hex decodes hex digits, ignoring spaces, failing the test if they are
+// invalid.
+func Hex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+// Expect reports an error unless got equals the value of a JSON5 literal.
+func Expect(t testing.TB, got parser.Value, expected string) {
+	t.Helper()
+	if want := JSON(t, expected); !parser.Equal(got, want) {
+		t.Errorf("expected %s, got %s", printer.New("").Print(want), printer.New("").Print(got))
+	}
+}
+
+// RoundTrip encodes the value of a JSON5 literal and decodes the result,
+// reporting an error unless that gives the value back.
+func RoundTrip[T ~string | ~[]byte](t testing.TB, input string, encode func(parser.Value) (T, error), decode func(T) (parser.Value, error)) {
+	t.Helper()
+	v := JSON(t, input)
+	data, err := encode(v)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", input, err)
+	}
+	got, err := decode(data)
+	if err != nil {
+		t.Fatalf("%s: unexpected error reading back %q: %v", input, data, err)
+	}
+	if !parser.Equal(got, v) {
+		t.Errorf("round trip of %s through %q gave %s", input, data, printer.New("").Print(got))
+	}
+}
@@ -0,0 +1,701 @@
+package toml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// tableKind records how a table was created, which decides whether it may
+// be extended later.
+type tableKind int
+
+const (
+	implicitTable tableKind = iota // Parent of a [header], not yet defined itself
+	headerTable                    // Defined by a [header]
+	dottedTable                    // Created by a dotted key
+	inlineTable                    // An inline table, which is closed
+)
+
+// Parse reads a TOML v1.0 document. Integers and floats become numbers;
+// an integer beyond int64, or beyond 2^53 and not exactly a float64, is an
+// error, since TOML forbids losing it. Dates and times become strings
+// in RFC 3339 form, with a 'T' between date and time.
+func Parse(input string) (parser.Value, error) {
+	input = strings.TrimPrefix(input, "\uFEFF")
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	d := &decoder{
+		src:         input,
+		line:        1,
+		root:        parser.Object(),
+		tables:      map[*parser.ObjectValue]tableKind{},
+		tableArrays: map[*parser.ArrayValue]bool{},
+	}
+	d.tables[d.root] = headerTable
+	d.current = d.root
+	if err := d.parse(); err != nil {
+		return nil, err
+	}
+	return d.root, nil
+}
+
+type decoder struct {
+	src       string
+	pos       int
+	line      int // 1-based line of pos
+	lineStart int // Offset of the start of the current line
+
+	root        *parser.ObjectValue
+	current     *parser.ObjectValue // Table receiving key/value pairs
+	tables      map[*parser.ObjectValue]tableKind
+	tableArrays map[*parser.ArrayValue]bool // Arrays defined by [[headers]]
+}
+
+func (d *decoder) eof() bool {
+	return d.pos >= len(d.src)
+}
+
+func (d *decoder) ch() byte {
+	return d.at(d.pos)
+}
+
+func (d *decoder) at(i int) byte {
+	if i >= len(d.src) {
+		return 0
+	}
+	return d.src[i]
+}
+
+func (d *decoder) advance(n int) {
+	for ; n > 0 && d.pos < len(d.src); n-- {
+		if d.src[d.pos] == '\n' {
+			d.line++
+			d.lineStart = d.pos + 1
+		}
+		d.pos++
+	}
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return errors.NewParseError(d.line, d.pos-d.lineStart+1, fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) skipBlanks() {
+	for d.ch() == ' ' || d.ch() == '\t' {
+		d.advance(1)
+	}
+}
+
+// skipComment skips a comment, which may not contain control characters.
+func (d *decoder) skipComment() error {
+	if d.ch() != '#' {
+		return nil
+	}
+	for !d.eof() && d.ch() != '\n' {
+		if c := d.ch(); (c < 0x20 && c != '\t') || c == 0x7F {
+			return d.errorf("control character %U in comment", c)
+		}
+		d.advance(1)
+	}
+	return nil
+}
+
+// endLine requires the rest of the line to be blank or a comment, and
+// moves past the line break.
+func (d *decoder) endLine() error {
+	d.skipBlanks()
+	if err := d.skipComment(); err != nil {
+		return err
+	}
+	if !d.eof() && d.ch() != '\n' {
+		return d.errorf("expected the end of the line, found %q", d.ch())
+	}
+	d.advance(1)
+	return nil
+}
+
+func (d *decoder) parse() error {
+	for {
+		d.skipBlanks()
+		switch {
+		case d.eof():
+			return nil
+		case d.ch() == '\n' || d.ch() == '#':
+		case d.ch() == '[':
+			if err := d.parseHeader(); err != nil {
+				return err
+			}
+		default:
+			if err := d.parseKeyValue(d.current); err != nil {
+				return err
+			}
+		}
+		if err := d.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseHeader parses a [table] or [[array of tables]] header.
+func (d *decoder) parseHeader() error {
+	array := strings.HasPrefix(d.src[d.pos:], "[[")
+	if array {
+		d.advance(2)
+	} else {
+		d.advance(1)
+	}
+	line, col := d.line, d.pos-d.lineStart+1
+	keys, err := d.parseKey()
+	if err != nil {
+		return err
+	}
+	d.skipBlanks()
+	if array {
+		if !strings.HasPrefix(d.src[d.pos:], "]]") {
+			return d.errorf("expected ']]' to close the array of tables header")
+		}
+		d.advance(2)
+	} else {
+		if d.ch() != ']' {
+			return d.errorf("expected ']' to close the table header")
+		}
+		d.advance(1)
+	}
+
+	fail := func(format string, args ...any) error {
+		return errors.NewParseError(line, col, fmt.Sprintf(format, args...))
+	}
+
+	// Walk to the parent table, creating implicit tables on the way
+	table := d.root
+	for i, key := range keys[:len(keys)-1] {
+		switch v := table.Pairs[key].(type) {
+		case nil:
+			child := parser.Object()
+			table.Set(key, child)
+			d.tables[child] = implicitTable
+			table = child
+		case *parser.ObjectValue:
+			if d.tables[v] == inlineTable {
+				return fail("cannot extend inline table %s", joinKeys(keys[:i+1]))
+			}
+			table = v
+		case *parser.ArrayValue:
+			if !d.tableArrays[v] {
+				return fail("cannot extend array %s, which is not an array of tables", joinKeys(keys[:i+1]))
+			}
+			table = v.Elements[len(v.Elements)-1].(*parser.ObjectValue)
+		default:
+			return fail("key %s is already defined as a %s", joinKeys(keys[:i+1]), parser.TypeName(v))
+		}
+	}
+
+	last := keys[len(keys)-1]
+	name := joinKeys(keys)
+	existing := table.Pairs[last]
+	if array {
+		elem := parser.Object()
+		d.tables[elem] = headerTable
+		switch v := existing.(type) {
+		case nil:
+			a := parser.Array(elem)
+			d.tableArrays[a] = true
+			table.Set(last, a)
+		case *parser.ArrayValue:
+			if !d.tableArrays[v] {
+				return fail("cannot append to array %s, which is not an array of tables", name)
+			}
+			v.Append(elem)
+		default:
+			return fail("key %s is already defined as a %s", name, parser.TypeName(existing))
+		}
+		d.current = elem
+		return nil
+	}
+
+	switch v := existing.(type) {
+	case nil:
+		child := parser.Object()
+		table.Set(last, child)
+		d.tables[child] = headerTable
+		d.current = child
+	case *parser.ObjectValue:
+		if d.tables[v] != implicitTable {
+			return fail("table %s is already defined", name)
+		}
+		d.tables[v] = headerTable
+		d.current = v
+	default:
+		return fail("key %s is already defined as a %s", name, parser.TypeName(existing))
+	}
+	return nil
+}
+
+// joinKeys formats a dotted key for error messages.
+func joinKeys(keys []string) string {
+	formatted := make([]string, len(keys))
+	for i, key := range keys {
+		formatted[i] = formatKey(key)
+	}
+	return strings.Join(formatted, ".")
+}
+
+// parseKeyValue parses "key = value" into table. Dotted keys create
+// tables, which may only be extended by other dotted keys.
+func (d *decoder) parseKeyValue(table *parser.ObjectValue) error {
+	line, col := d.line, d.pos-d.lineStart+1
+	keys, err := d.parseKey()
+	if err != nil {
+		return err
+	}
+	d.skipBlanks()
+	if d.ch() != '=' {
+		return d.errorf("expected '=' after key %s", joinKeys(keys))
+	}
+	d.advance(1)
+	d.skipBlanks()
+
+	fail := func(format string, args ...any) error {
+		return errors.NewParseError(line, col, fmt.Sprintf(format, args...))
+	}
+	for i, key := range keys[:len(keys)-1] {
+		switch v := table.Pairs[key].(type) {
+		case nil:
+			child := parser.Object()
+			table.Set(key, child)
+			d.tables[child] = dottedTable
+			table = child
+		case *parser.ObjectValue:
+			if d.tables[v] != dottedTable {
+				return fail("cannot add keys to table %s with a dotted key", joinKeys(keys[:i+1]))
+			}
+			table = v
+		default:
+			return fail("key %s is already defined as a %s", joinKeys(keys[:i+1]), parser.TypeName(v))
+		}
+	}
+	last := keys[len(keys)-1]
+	if _, ok := table.Pairs[last]; ok {
+		return fail("duplicate key %s", joinKeys(keys))
+	}
+
+	value, err := d.parseValue()
+	if err != nil {
+		return err
+	}
+	table.Set(last, value)
+	return nil
+}
+
+// parseKey parses a possibly dotted key into its parts.
+func (d *decoder) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		d.skipBlanks()
+		var key string
+		switch c := d.ch(); {
+		case c == '"':
+			if strings.HasPrefix(d.src[d.pos:], `"""`) {
+				return nil, d.errorf("multi-line strings cannot be keys")
+			}
+			s, err := d.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case c == '\'':
+			if strings.HasPrefix(d.src[d.pos:], "'''") {
+				return nil, d.errorf("multi-line strings cannot be keys")
+			}
+			s, err := d.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := d.pos
+			for isBareKeyChar(d.ch()) {
+				d.advance(1)
+			}
+			if d.pos == start {
+				if d.eof() || d.ch() == '\n' {
+					return nil, d.errorf("expected a key")
+				}
+				return nil, d.errorf("invalid character %q in key", d.ch())
+			}
+			key = d.src[start:d.pos]
+		}
+		keys = append(keys, key)
+
+		d.skipBlanks()
+		if d.ch() != '.' {
+			return keys, nil
+		}
+		d.advance(1)
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue parses a value starting at the reader.
+func (d *decoder) parseValue() (parser.Value, error) {
+	rest := d.src[d.pos:]
+	switch c := d.ch(); {
+	case strings.HasPrefix(rest, `"""`):
+		s, err := d.parseMultilineString(`"""`)
+		return parser.String(s), err
+	case c == '"':
+		s, err := d.parseBasicString()
+		return parser.String(s), err
+	case strings.HasPrefix(rest, "'''"):
+		s, err := d.parseMultilineString("'''")
+		return parser.String(s), err
+	case c == '\'':
+		s, err := d.parseLiteralString()
+		return parser.String(s), err
+	case c == '[':
+		return d.parseArray()
+	case c == '{':
+		return d.parseInlineTable()
+	case d.eof() || c == '\n' || c == '#':
+		return nil, d.errorf("expected a value")
+	}
+
+	// Bare values: booleans, numbers and dates
+	start := d.pos
+	if m := dateTimePattern.FindString(rest); m != "" {
+		d.advance(len(m))
+		s, err := normalizeDateTime(m)
+		if err != nil {
+			return nil, errors.NewParseError(d.line, start-d.lineStart+1, err.Error())
+		}
+		return parser.String(s), nil
+	}
+	for isBareKeyChar(d.ch()) || d.ch() == '+' || d.ch() == '.' {
+		d.advance(1)
+	}
+	token := d.src[start:d.pos]
+	switch token {
+	case "true":
+		return parser.Bool(true), nil
+	case "false":
+		return parser.Bool(false), nil
+	}
+	if f, ok, err := parseNumber(token); err != nil {
+		return nil, errors.NewParseError(d.line, start-d.lineStart+1, err.Error())
+	} else if ok {
+		return parser.Number(f), nil
+	}
+	if token == "" {
+		return nil, d.errorf("unexpected %q, expected a value", d.ch())
+	}
+	return nil, errors.NewParseError(d.line, start-d.lineStart+1, fmt.Sprintf("invalid value %q", token))
+}
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	hexPattern     = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+	octalPattern   = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	binaryPattern  = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	floatPattern   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+
+	dateTimePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}:\d{2}(\.\d+)?)`)
+)
+
+// parseNumber parses TOML integers and floats. It reports false for a
+// token that is not a number, and an error for an integer that a float64
+// cannot hold exactly.
+func parseNumber(s string) (float64, bool, error) {
+	switch {
+	case decimalPattern.MatchString(s):
+		return parseInteger(s, 10)
+	case hexPattern.MatchString(s), octalPattern.MatchString(s), binaryPattern.MatchString(s):
+		return parseInteger(s, 0)
+	case floatPattern.MatchString(s):
+		f, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+		return f, err == nil || math.IsInf(f, 0), nil
+	}
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(1), true, nil
+	case "-inf":
+		return math.Inf(-1), true, nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), true, nil
+	}
+	return 0, false, nil
+}
+
+// parseInteger parses an integer token in the given base, or by its prefix
+// when base is 0.
+func parseInteger(s string, base int) (float64, bool, error) {
+	n, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), base, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("integer out of range: %s", s)
+	}
+	f := float64(n)
+	if f >= 1<<63 || int64(f) != n {
+		return 0, false, fmt.Errorf("integer %s cannot be represented exactly", s)
+	}
+	return f, true, nil
+}
+
+// normalizeDateTime checks a date, time or date-time and returns it with a
+// 'T' separator and an upper-case 'Z'.
+func normalizeDateTime(s string) (string, error) {
+	s = strings.ToUpper(s)
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+
+	var layout string
+	switch {
+	case s[2] == ':':
+		layout = "15:04:05.999999999"
+	case len(s) == 10:
+		layout = "2006-01-02"
+	case strings.HasSuffix(s, "Z") || strings.LastIndexAny(s, "+-") > 10:
+		layout = time.RFC3339Nano
+	default:
+		layout = "2006-01-02T15:04:05.999999999"
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return "", fmt.Errorf("invalid date or time %q", s)
+	}
+	return s, nil
+}
+
+// parseBasicString parses a "basic string" on one line.
+func (d *decoder) parseBasicString() (string, error) {
+	d.advance(1)
+	var sb strings.Builder
+	for {
+		switch c := d.ch(); {
+		case d.eof() || c == '\n':
+			return "", d.errorf("unterminated string")
+		case c == '"':
+			d.advance(1)
+			return sb.String(), nil
+		case c == '\\':
+			if err := d.readEscape(&sb); err != nil {
+				return "", err
+			}
+		case (c < 0x20 && c != '\t') || c == 0x7F:
+			return "", d.errorf("control character %U must be escaped", c)
+		default:
+			sb.WriteByte(c)
+			d.advance(1)
+		}
+	}
+}
+
+// parseLiteralString parses a 'literal string' on one line.
+func (d *decoder) parseLiteralString() (string, error) {
+	d.advance(1)
+	start := d.pos
+	for {
+		switch c := d.ch(); {
+		case d.eof() || c == '\n':
+			return "", d.errorf("unterminated string")
+		case c == '\'':
+			s := d.src[start:d.pos]
+			d.advance(1)
+			return s, nil
+		case (c < 0x20 && c != '\t') || c == 0x7F:
+			return "", d.errorf("control character %U in literal string", c)
+		default:
+			d.advance(1)
+		}
+	}
+}
+
+// parseMultilineString parses a multi-line basic or literal string, whose
+// delimiter is given. A line break right after the opening delimiter is
+// dropped.
+func (d *decoder) parseMultilineString(delim string) (string, error) {
+	literal := delim == "'''"
+	d.advance(3)
+	if d.ch() == '\n' {
+		d.advance(1)
+	}
+
+	var sb strings.Builder
+	for {
+		c := d.ch()
+		switch {
+		case d.eof():
+			return "", d.errorf("unterminated multi-line string")
+		case strings.HasPrefix(d.src[d.pos:], delim):
+			// Up to two quotes may precede the closing delimiter
+			n := 3
+			for n < 5 && d.at(d.pos+n) == delim[0] {
+				n++
+			}
+			sb.WriteString(strings.Repeat(delim[:1], n-3))
+			d.advance(n)
+			return sb.String(), nil
+		case c == '\\' && !literal:
+			if d.lineEndingBackslash() {
+				continue
+			}
+			if err := d.readEscape(&sb); err != nil {
+				return "", err
+			}
+		case (c < 0x20 && c != '\t' && c != '\n') || c == 0x7F:
+			return "", d.errorf("control character %U in string", c)
+		default:
+			sb.WriteByte(c)
+			d.advance(1)
+		}
+	}
+}
+
+// lineEndingBackslash skips a backslash at the end of a line together with
+// all whitespace and line breaks after it.
+func (d *decoder) lineEndingBackslash() bool {
+	i := d.pos + 1
+	for d.at(i) == ' ' || d.at(i) == '\t' {
+		i++
+	}
+	if d.at(i) != '\n' {
+		return false
+	}
+	d.advance(i - d.pos)
+	for d.ch() == ' ' || d.ch() == '\t' || d.ch() == '\n' {
+		d.advance(1)
+	}
+	return true
+}
+
+// readEscape decodes the escape sequence at the reader.
+func (d *decoder) readEscape(sb *strings.Builder) error {
+	c := d.at(d.pos + 1)
+	simple := map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}
+	if r, ok := simple[c]; ok {
+		sb.WriteByte(r)
+		d.advance(2)
+		return nil
+	}
+
+	digits := map[byte]int{'u': 4, 'U': 8}[c]
+	if digits == 0 {
+		return d.errorf("invalid escape sequence \\%c", c)
+	}
+	hex := d.src[min(d.pos+2, len(d.src)):min(d.pos+2+digits, len(d.src))]
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != digits || !utf8.ValidRune(rune(code)) {
+		return d.errorf("invalid escape sequence \\%c%s", c, hex)
+	}
+	sb.WriteRune(rune(code))
+	d.advance(2 + digits)
+	return nil
+}
+
+// skipArraySpace skips whitespace, line breaks and comments inside an array.
+func (d *decoder) skipArraySpace() error {
+	for {
+		d.skipBlanks()
+		switch d.ch() {
+		case '#':
+			if err := d.skipComment(); err != nil {
+				return err
+			}
+		case '\n':
+			d.advance(1)
+		default:
+			return nil
+		}
+	}
+}
+
+func (d *decoder) parseArray() (parser.Value, error) {
+	d.advance(1)
+	array := parser.Array()
+	for {
+		if err := d.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		if d.ch() == ']' {
+			d.advance(1)
+			return array, nil
+		}
+		value, err := d.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array.Append(value)
+
+		if err := d.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		switch d.ch() {
+		case ',':
+			d.advance(1)
+		case ']':
+		default:
+			if d.eof() {
+				return nil, d.errorf("unterminated array")
+			}
+			return nil, d.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable parses { key = value, ... } on a single line. The table
+// and every table inside it are closed to later headers and dotted keys.
+func (d *decoder) parseInlineTable() (parser.Value, error) {
+	d.advance(1)
+	table := parser.Object()
+	d.tables[table] = dottedTable
+	d.skipBlanks()
+	if d.ch() == '}' {
+		d.advance(1)
+		d.closeInline(table)
+		return table, nil
+	}
+	for {
+		if err := d.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		d.skipBlanks()
+		switch d.ch() {
+		case ',':
+			d.advance(1)
+			d.skipBlanks()
+			switch {
+			case d.ch() == '}':
+				return nil, d.errorf("trailing comma in inline table")
+			case d.eof() || d.ch() == '\n':
+				return nil, d.errorf("inline tables must end on the line they start")
+			}
+		case '}':
+			d.advance(1)
+			d.closeInline(table)
+			return table, nil
+		default:
+			if d.eof() || d.ch() == '\n' {
+				return nil, d.errorf("inline tables must end on the line they start")
+			}
+			return nil, d.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// closeInline marks an inline table and the tables inside it as closed.
+func (d *decoder) closeInline(table *parser.ObjectValue) {
+	parser.Walk(table, parser.Funcs{OnEnter: func(_ parser.Path, node parser.Value) parser.Action {
+		if o, ok := node.(*parser.ObjectValue); ok {
+			d.tables[o] = inlineTable
+		}
+		return parser.Continue
+	}})
+}
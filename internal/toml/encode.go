@@ -0,0 +1,227 @@
+// Package toml converts between the parser's AST and TOML v1.0. Marshal
+// writes tables, arrays of tables and inline tables; Parse reads a TOML
+// document into the same AST the JSON parser produces.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+)
+
+// Marshal encodes an object as a TOML document. Nested objects become
+// [tables] and arrays whose elements are all objects become [[arrays of
+// tables]]; objects inside other arrays are written as inline tables.
+// Integral numbers that fit in 64 bits are written as integers and other
+// numbers as floats. TOML has no null and its documents are tables, so a
+// null anywhere or a root that is not an object is an error.
+func Marshal(v parser.Value) (string, error) {
+	root, ok := v.(*parser.ObjectValue)
+	if !ok {
+		return "", fmt.Errorf("cannot encode a top-level %s as TOML: the document root must be an object", parser.TypeName(v))
+	}
+	e := &encoder{}
+	if err := e.writeTable(nil, root, false); err != nil {
+		return "", err
+	}
+	return e.sb.String(), nil
+}
+
+type encoder struct {
+	sb strings.Builder
+}
+
+// writeTable writes the key/value pairs of a table under its header,
+// followed by its sub-tables. TOML requires the pairs to come first, so
+// members are regrouped; each group keeps source order.
+func (e *encoder) writeTable(path parser.Path, t *parser.ObjectValue, arrayElement bool) error {
+	var pairs, tables []string
+	for _, key := range t.OrderedKeys() {
+		if isTable(t.Pairs[key]) || isTableArray(t.Pairs[key]) {
+			tables = append(tables, key)
+		} else {
+			pairs = append(pairs, key)
+		}
+	}
+
+	// A table holding only sub-tables is defined implicitly by their headers
+	if len(path) > 0 && (arrayElement || len(pairs) > 0 || len(tables) == 0) {
+		if e.sb.Len() > 0 {
+			e.sb.WriteByte('\n')
+		}
+		if arrayElement {
+			e.sb.WriteString("[[" + formatHeader(path) + "]]\n")
+		} else {
+			e.sb.WriteString("[" + formatHeader(path) + "]\n")
+		}
+	}
+
+	for _, key := range pairs {
+		e.sb.WriteString(formatKey(key) + " = ")
+		if err := e.writeValue(append(path, parser.KeySegment(key)), t.Pairs[key]); err != nil {
+			return err
+		}
+		e.sb.WriteByte('\n')
+	}
+
+	for _, key := range tables {
+		child := append(path.Clone(), parser.KeySegment(key))
+		switch n := t.Pairs[key].(type) {
+		case *parser.ObjectValue:
+			if err := e.writeTable(child, n, false); err != nil {
+				return err
+			}
+		case *parser.ArrayValue:
+			for i, elem := range n.Elements {
+				if err := e.writeTable(append(child.Clone(), parser.IndexSegment(i)), elem.(*parser.ObjectValue), true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeValue writes a value in inline form.
+func (e *encoder) writeValue(path parser.Path, v parser.Value) error {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		if len(n.Pairs) == 0 {
+			e.sb.WriteString("{}")
+			return nil
+		}
+		e.sb.WriteString("{ ")
+		for i, key := range n.OrderedKeys() {
+			if i > 0 {
+				e.sb.WriteString(", ")
+			}
+			e.sb.WriteString(formatKey(key) + " = ")
+			if err := e.writeValue(append(path, parser.KeySegment(key)), n.Pairs[key]); err != nil {
+				return err
+			}
+		}
+		e.sb.WriteString(" }")
+	case *parser.ArrayValue:
+		e.sb.WriteByte('[')
+		for i, elem := range n.Elements {
+			if i > 0 {
+				e.sb.WriteString(", ")
+			}
+			if err := e.writeValue(append(path, parser.IndexSegment(i)), elem); err != nil {
+				return err
+			}
+		}
+		e.sb.WriteByte(']')
+	case *parser.StringValue:
+		e.sb.WriteString(quote(n.Value))
+	case *parser.NumberValue:
+		e.sb.WriteString(formatNumber(n.Value))
+	case *parser.BooleanValue:
+		e.sb.WriteString(strconv.FormatBool(n.Value))
+	case *parser.NullValue:
+		return fmt.Errorf("cannot encode null at %s: TOML has no null value", path.Describe())
+	default:
+		return fmt.Errorf("cannot encode %T at %s", v, path.Describe())
+	}
+	return nil
+}
+
+func isTable(v parser.Value) bool {
+	_, ok := v.(*parser.ObjectValue)
+	return ok
+}
+
+// isTableArray reports whether v can be written as an array of tables: a
+// non-empty array of objects only.
+func isTableArray(v parser.Value) bool {
+	a, ok := v.(*parser.ArrayValue)
+	if !ok || len(a.Elements) == 0 {
+		return false
+	}
+	for _, elem := range a.Elements {
+		if !isTable(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatHeader formats the keys of a path as a table header, skipping the
+// indices of array-of-tables elements.
+func formatHeader(path parser.Path) string {
+	var keys []string
+	for _, seg := range path {
+		if !seg.IsIndex() {
+			keys = append(keys, formatKey(seg.Key))
+		}
+	}
+	return strings.Join(keys, ".")
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// formatKey writes a key bare when TOML allows it, and quoted otherwise.
+func formatKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return quote(key)
+}
+
+// formatNumber writes integral values up to 2^63 as integers and everything
+// else as a float, which always has a '.' or an exponent.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f == math.Trunc(f) && math.Abs(f) < 1<<63:
+		return strconv.FormatInt(int64(f), 10)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote writes s as a basic string.
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\b':
+			sb.WriteString(`\b`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r < 0x20 || r == 0x7F:
+			fmt.Fprintf(&sb, `\u%04X`, r)
+		case r == utf8.RuneError && size == 1:
+			sb.WriteRune(utf8.RuneError)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
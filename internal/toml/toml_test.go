@@ -0,0 +1,177 @@
+package toml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // JSON5
+	}{
+		{"key/value pairs", "title = \"TOML\" # comment\nenabled = true\ncount = 3\n", `{"title": "TOML", "enabled": true, "count": 3}`},
+		{"keys", "bare_key-1 = 1\n\"quoted key\" = 2\n'literal.key' = 3\n1234 = 4\n", `{"bare_key-1": 1, "quoted key": 2, "literal.key": 3, "1234": 4}`},
+		{"dotted keys", "a.b.c = 1\na . b . d = 2\nsite.\"google.com\" = true\n", `{"a": {"b": {"c": 1, "d": 2}}, "site": {"google.com": true}}`},
+		{"integers", "a = +99\nb = -17\nc = 1_000\nd = 0xDEAD_beef\ne = 0o755\nf = 0b1101\ng = 0\n", `{"a": 99, "b": -17, "c": 1000, "d": 3735928559, "e": 493, "f": 13, "g": 0}`},
+		{"large integers", "a = 9007199254740992\nb = -9223372036854775808\nc = 0x4000_0000_0000_0000\n", `{"a": 9007199254740992, "b": -9223372036854775808, "c": 4611686018427387904}`},
+		{"floats", "a = 3.1415\nb = -0.01\nc = 5e+22\nd = 6.626e-34\ne = 224_617.445_991\nf = inf\ng = -inf\nh = -nan\n", `{"a": 3.1415, "b": -0.01, "c": 5e22, "d": 6.626e-34, "e": 224617.445991, "f": Infinity, "g": -Infinity, "h": NaN}`},
+		{"basic strings", `s = "tab\there \"q\" \\ \u00E9 \U0001F600"`, `{"s": "tab\there \"q\" \\ é 😀"}`},
+		{"literal strings", `s = 'C:\Users\nodejs'`, `{"s": "C:\\Users\\nodejs"}`},
+		{"multi-line basic", "s = \"\"\"\nRoses are red\nViolets are blue\"\"\"\n", `{"s": "Roses are red\nViolets are blue"}`},
+		{"line ending backslash", "s = \"\"\"\nThe quick \\\n\n   brown fox.\\\n   \"\"\"\n", `{"s": "The quick brown fox."}`},
+		{"quotes before closing", "s = \"\"\"Here are two quotes: \"\".\"\"\"\nt = '''It's \"\"'''''\n", `{"s": "Here are two quotes: \"\".", "t": "It's \"\"''"}`},
+		{"multi-line literal", "s = '''\nThe first newline is\ntrimmed in raw strings.\n   \\n stays'''\n", `{"s": "The first newline is\ntrimmed in raw strings.\n   \\n stays"}`},
+		{"dates and times", "a = 1979-05-27T07:32:00Z\nb = 1979-05-27 00:32:00.999-07:00\nc = 1979-05-27t07:32:00\nd = 1979-05-27\ne = 07:32:00.5\n",
+			`{"a": "1979-05-27T07:32:00Z", "b": "1979-05-27T00:32:00.999-07:00", "c": "1979-05-27T07:32:00", "d": "1979-05-27", "e": "07:32:00.5"}`},
+		{"arrays", "a = [1, 2, 3]\nb = [\"x\", 'y']\nc = [[1, 2], [\"a\"], []]\nd = [\n  1, # one\n  2,\n]\ne = [1, \"mixed\", {x = 1}]\n",
+			`{"a": [1, 2, 3], "b": ["x", "y"], "c": [[1, 2], ["a"], []], "d": [1, 2], "e": [1, "mixed", {"x": 1}]}`},
+		{"inline tables", "name = { first = \"Tom\", last = \"Preston-Werner\" }\npoint = {x=1,y=2}\nempty = {}\nnested = { a.b = 1, c = { d = 2 } }\n",
+			`{"name": {"first": "Tom", "last": "Preston-Werner"}, "point": {"x": 1, "y": 2}, "empty": {}, "nested": {"a": {"b": 1}, "c": {"d": 2}}}`},
+		{"tables", "[server]\nhost = \"localhost\"\n\n[server.tls]\nenabled = false\n\n[ \"quoted\" . key ]\nx = 1\n",
+			`{"server": {"host": "localhost", "tls": {"enabled": false}}, "quoted": {"key": {"x": 1}}}`},
+		{"implicit super-table", "[x.y.z]\na = 1\n[x]\nb = 2\n", `{"x": {"y": {"z": {"a": 1}}, "b": 2}}`},
+		{"sub-table of dotted table", "[fruit]\napple.color = \"red\"\n[fruit.apple.texture]\nsmooth = true\n", `{"fruit": {"apple": {"color": "red", "texture": {"smooth": true}}}}`},
+		{"arrays of tables", "[[products]]\nname = \"Hammer\"\n\n[[products]]\n\n[[products]]\nname = \"Nail\"\n[products.dims]\nlength = 1\n",
+			`{"products": [{"name": "Hammer"}, {}, {"name": "Nail", "dims": {"length": 1}}]}`},
+		{"nested arrays of tables", "[[fruits]]\nname = \"apple\"\n[[fruits.varieties]]\nname = \"red\"\n[[fruits.varieties]]\nname = \"green\"\n[[fruits]]\nname = \"banana\"\n",
+			`{"fruits": [{"name": "apple", "varieties": [{"name": "red"}, {"name": "green"}]}, {"name": "banana"}]}`},
+		{"crlf", "a = 1\r\n[b]\r\nc = \"\"\"x\r\ny\"\"\"\r\n", `{"a": 1, "b": {"c": "x\ny"}}`},
+		{"empty", "# nothing\n\n", `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"duplicate key", "a = 1\na = 2\n", "duplicate key a"},
+		{"duplicate table", "[a]\n[a]\n", "table a is already defined"},
+		{"table redefines key", "a = 1\n[a]\n", "already defined as a number"},
+		{"dotted key into header table", "[a.b]\nx = 1\n[a]\nb.y = 2\n", "cannot add keys to table b"},
+		{"header over dotted table", "[a]\nb.c = 1\n[a.b]\n", "table a.b is already defined"},
+		{"extend inline table", "a = {x = 1}\n[a.b]\n", "cannot extend inline table a"},
+		{"redefine inline table", "a = {x = 1}\n[a]\n", "table a is already defined"},
+		{"dotted key into inline table", "a = {x = 1}\na.y = 2\n", "cannot add keys to table a"},
+		{"append to static array", "a = []\n[[a]]\n", "not an array of tables"},
+		{"missing value", "a = \n", "expected a value"},
+		{"missing equals", "a 1\n", "expected '=' after key a"},
+		{"two values on a line", "a = 1 b = 2\n", "expected the end of the line"},
+		{"leading zero", "a = 012\n", `invalid value "012"`},
+		{"bad underscore", "a = 1__0\n", `invalid value "1__0"`},
+		{"bad float", "a = 1.\n", `invalid value "1."`},
+		{"integer too large", "a = 9223372036854775808\n", "integer out of range: 9223372036854775808"},
+		{"integer too small", "a = -9_223_372_036_854_775_809\n", "integer out of range"},
+		{"hex integer too large", "a = 0x1_0000_0000_0000_0000\n", "integer out of range: 0x1_0000_0000_0000_0000"},
+		{"inexact integer", "a = 9007199254740993\n", "integer 9007199254740993 cannot be represented exactly"},
+		{"inexact hex integer", "a = 0x7fffffffffffffff\n", "cannot be represented exactly"},
+		{"bad date", "a = 2024-02-30\n", "invalid date or time"},
+		{"unterminated string", "a = \"abc\n", "unterminated string"},
+		{"invalid escape", `a = "\x41"`, `invalid escape sequence \x`},
+		{"control character", "a = \"\x01\"\n", "must be escaped"},
+		{"multi-line inline table", "a = { x = 1,\n y = 2 }\n", "must end on the line"},
+		{"trailing comma in inline table", "a = { x = 1, }\n", "trailing comma"},
+		{"unterminated array", "a = [1, 2\n", "unterminated array"},
+		{"unclosed header", "[a\n", "expected ']'"},
+		{"empty key", "= 1\n", "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.message)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // JSON5
+		expected string
+	}{
+		{"scalars", `{"s": "x\ty\"", "i": 42, "neg": -7, "f": 2.5, "big": 1e300, "whole": 1e21, "b": true, "inf": Infinity, "nan": NaN}`,
+			"s = \"x\\ty\\\"\"\ni = 42\nneg = -7\nf = 2.5\nbig = 1e+300\nwhole = 1e+21\nb = true\ninf = inf\nnan = nan\n"},
+		{"keys", `{"bare-key_1": 1, "with space": 2, "a.b": 3, "": 4}`, "bare-key_1 = 1\n\"with space\" = 2\n\"a.b\" = 3\n\"\" = 4\n"},
+		{"tables after pairs", `{"server": {"host": "h", "tls": {"on": true}}, "name": "x"}`,
+			"name = \"x\"\n\n[server]\nhost = \"h\"\n\n[server.tls]\non = true\n"},
+		{"implicit tables", `{"a": {"b": {"c": 1}}, "empty": {}}`, "[a.b]\nc = 1\n\n[empty]\n"},
+		{"arrays of tables", `{"items": [{"id": 1, "dims": {"w": 2}}, {}, {"id": 3}]}`,
+			"[[items]]\nid = 1\n\n[items.dims]\nw = 2\n\n[[items]]\n\n[[items]]\nid = 3\n"},
+		{"inline values", `{"list": [1, "two", [3], {"k": "v", "n": {}}], "empty": [], "objs": [[{"a": 1}]]}`,
+			"list = [1, \"two\", [3], { k = \"v\", n = {} }]\nempty = []\nobjs = [[{ a = 1 }]]\n"},
+		{"quoted header", `{"a b": {"c.d": {"x": 1}}}`, "[\"a b\".\"c.d\"]\nx = 1\n"},
+		{"control characters", `{"s": "\u0001\u007f"}`, "s = \"\\u0001\\u007F\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(testutil.JSON(t, tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"array root", `[1, "a", {"b": 2}]`, "cannot encode a top-level array"},
+		{"scalar root", `"text"`, "cannot encode a top-level string"},
+		{"null member", `{"a": {"b": null}}`, "cannot encode null at a.b"},
+		{"null element", `{"a": [1, null]}`, "cannot encode null at a[1]"},
+		{"null in array of tables", `{"t": [{"x": 1}, {"y": null}]}`, "cannot encode null at t[1].y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(testutil.JSON(t, tt.input))
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.message)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{"title": "example", "owner": {"name": "Tom", "dob": "1979-05-27"}, "database": {"ports": [8000, 8001], "enabled": true, "temp": {"cpu": 79.5}}}`,
+		`{"servers": [{"name": "alpha", "ip": "10.0.0.1", "roles": {"web": true}}, {"name": "beta", "tags": [[1, 2], ["x"]]}]}`,
+		`{"mixed": [1, "a", {"b": [{"c": 1}]}], "unicode": "日本語 😀", "escapes": "\"\\\n\u0000", "weird keys": {"": {"a.b": {"c d": 1}}}}`,
+		`{"a": {"b": {"c": {}}}, "d": [{"e": [{"f": 1}]}], "g": -0.5, "h": 123456789012}`,
+	}
+
+	for _, input := range inputs {
+		testutil.RoundTrip(t, input, Marshal, Parse)
+	}
+}
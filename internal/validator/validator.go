@@ -22,31 +22,45 @@ func New(maxDepth int) *Validator {
 
 // Validate traverses the AST and validates the JSON structure.
 func (v *Validator) Validate(node parser.Node) error {
-	return v.validateNode(node, 0)
+	_, err := v.ValidatePath(node)
+	return err
 }
 
-// validateNode recursively validates a node and its children, checking depth and other constraints.
-func (v *Validator) validateNode(node parser.Node, depth int) error {
-	if depth > v.maxDepth {
-		return errors.NewValidationError("exceeded maximum nesting depth")
+// ValidatePath is like Validate, but also returns the path of the value
+// that failed validation, or of the member whose key did.
+func (v *Validator) ValidatePath(node parser.Node) (parser.Path, error) {
+	value, ok := node.(parser.Value)
+	if !ok {
+		return nil, errors.NewValidationError("unknown node type")
 	}
 
-	switch n := node.(type) {
-	case *parser.ObjectValue:
-		for key, value := range n.Pairs {
-			if err := v.ValidateString(key); err != nil {
-				return err
-			}
-			if err := v.validateNode(value, depth+1); err != nil {
-				return err
-			}
+	var failed parser.Path
+	var err error
+	parser.Walk(value, parser.Funcs{OnEnter: func(path parser.Path, value parser.Value) parser.Action {
+		if err = v.validateNode(path, value); err != nil {
+			failed = path.Clone()
+			return parser.Stop
 		}
-	case *parser.ArrayValue:
-		for _, elem := range n.Elements {
-			if err := v.validateNode(elem, depth+1); err != nil {
-				return err
-			}
+		return parser.Continue
+	}})
+	return failed, err
+}
+
+// validateNode validates a single node and the key it is stored under,
+// checking depth and other constraints. Children are visited by Validate.
+func (v *Validator) validateNode(path parser.Path, node parser.Value) error {
+	if len(path) > v.maxDepth {
+		return errors.NewValidationError("exceeded maximum nesting depth")
+	}
+	if len(path) > 0 && !path[len(path)-1].IsIndex() {
+		if err := v.ValidateString(path[len(path)-1].Key); err != nil {
+			return err
 		}
+	}
+
+	switch n := node.(type) {
+	case *parser.ObjectValue, *parser.ArrayValue:
+	// Members and elements are validated as they are visited
 	case *parser.StringValue:
 		return v.ValidateString(n.Value)
 	case *parser.NumberValue:
@@ -65,4 +65,32 @@ func TestValidator(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("Test Key And Element Validation", func(t *testing.T) {
+		v := New(10)
+
+		badKey := &parser.ObjectValue{Pairs: map[string]parser.Value{
+			"ok":        &parser.NullValue{},
+			"bad\u0001": &parser.NullValue{},
+		}}
+		if err := v.Validate(badKey); err == nil {
+			t.Error("expected error for control character in key")
+		}
+
+		badElement := &parser.ArrayValue{Elements: []parser.Value{
+			&parser.StringValue{Value: "fine"},
+			&parser.ArrayValue{Elements: []parser.Value{&parser.StringValue{Value: "\u0002"}}},
+		}}
+		if err := v.Validate(badElement); err == nil {
+			t.Error("expected error for control character in nested element")
+		}
+
+		path, err := v.ValidatePath(badElement)
+		if err == nil || path.String() != "[1][0]" {
+			t.Errorf("expected an error at [1][0], got %v at %s", err, path)
+		}
+		if path, err := v.ValidatePath(badKey); err == nil || path.String() != `["bad\x01"]` {
+			t.Errorf("expected an error at the bad key, got %v at %s", err, path)
+		}
+	})
 }
@@ -0,0 +1,304 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/base64"
+	stdxml "encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	e "github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// element is a parsed XML element, kept whole so that repeated children
+// can be grouped into arrays.
+type element struct {
+	name     string // As written, with any namespace prefix
+	attrs    []stdxml.Attr
+	children []*element
+	text     []string // Character data chunks
+	line     int
+	column   int
+}
+
+// Parse reads an XML document under a mapping. The document element holds
+// the root value and its name is not part of it. An element with
+// attributes or children is an object: attributes become members named
+// with the attribute prefix, children become members named after them,
+// and character data becomes the text member. Repeated children, and
+// children listed in Arrays, become arrays. Any other element is a string.
+// The json:type and json:array annotations written by a typed Marshal
+// restore the other JSON types. Comments, processing instructions and the
+// document type declaration are ignored.
+func Parse(input string, m Mapping) (parser.Value, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	root, err := readTree(input)
+	if err != nil {
+		return nil, err
+	}
+	r := &reader{m: m}
+	return r.value(root)
+}
+
+// readTree reads the document element and everything inside it.
+func readTree(input string) (*element, error) {
+	d := stdxml.NewDecoder(strings.NewReader(input))
+	var root *element
+	var stack []*element
+	for {
+		line, column := d.InputPos()
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntax *stdxml.SyntaxError
+			if errors.As(err, &syntax) {
+				line, column = d.InputPos()
+				return nil, e.NewParseError(line, column, syntax.Msg)
+			}
+			return nil, e.NewParseError(line, column, err.Error())
+		}
+
+		switch t := tok.(type) {
+		case stdxml.StartElement:
+			el := &element{name: rawName(t.Name), attrs: slices.Clone(t.Attr), line: line, column: column}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			case root != nil:
+				return nil, e.NewParseError(line, column, "a document has one root element, found another <"+el.name+">")
+			default:
+				root = el
+			}
+			stack = append(stack, el)
+		case stdxml.EndElement:
+			// RawToken leaves matching the tags to us
+			if len(stack) == 0 || stack[len(stack)-1].name != rawName(t.Name) {
+				return nil, e.NewParseError(line, column, "unexpected end tag </"+rawName(t.Name)+">")
+			}
+			stack = stack[:len(stack)-1]
+		case stdxml.CharData:
+			if len(stack) > 0 {
+				el := stack[len(stack)-1]
+				el.text = append(el.text, string(t))
+			} else if len(bytes.Trim(t, " \t\r\n")) > 0 {
+				return nil, e.NewParseError(line, column, "text outside the root element")
+			}
+		}
+	}
+
+	line, column := d.InputPos()
+	switch {
+	case len(stack) > 0:
+		return nil, e.NewParseError(line, column, "unexpected end of input: <"+stack[len(stack)-1].name+"> is not closed")
+	case root == nil:
+		return nil, e.NewParseError(line, column, "the document has no root element")
+	}
+	return root, nil
+}
+
+// rawName formats a name as written in the document.
+func rawName(name stdxml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+type reader struct {
+	m Mapping
+}
+
+// annotations are the markup attributes of an element, which do not
+// become members.
+type annotations struct {
+	typ      string // json:type
+	array    bool   // json:array="true"
+	preserve bool   // xml:space="preserve"
+}
+
+// split separates an element's annotations from its data attributes.
+func (r *reader) split(el *element) (annotations, []stdxml.Attr, error) {
+	var a annotations
+	var attrs []stdxml.Attr
+	for _, attr := range el.attrs {
+		switch {
+		case attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			// Namespace declarations
+		case attr.Name.Space == "json" && attr.Name.Local == "type":
+			a.typ = attr.Value
+		case attr.Name.Space == "json" && attr.Name.Local == "array":
+			a.array = attr.Value == "true"
+		case attr.Name.Space == "json":
+			return a, nil, r.errorf(el, "unknown annotation json:%s", attr.Name.Local)
+		case attr.Name.Space == "xml" && attr.Name.Local == "space":
+			a.preserve = attr.Value == "preserve"
+		default:
+			attrs = append(attrs, attr)
+		}
+	}
+	return a, attrs, nil
+}
+
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// value converts an element to the value it holds.
+func (r *reader) value(el *element) (parser.Value, error) {
+	a, attrs, err := r.split(el)
+	if err != nil {
+		return nil, err
+	}
+	if a.typ != "" && a.typ != "object" && a.typ != "array" && (len(attrs) > 0 || len(el.children) > 0) {
+		return nil, r.errorf(el, "an element of type %s cannot have attributes or children", a.typ)
+	}
+
+	text := strings.Join(el.text, "")
+	switch a.typ {
+	case "":
+		if len(attrs) == 0 && len(el.children) == 0 {
+			return parser.String(text), nil
+		}
+		return r.object(el, a, attrs)
+	case "string":
+		return parser.String(text), nil
+	case "base64":
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			return nil, r.errorf(el, "invalid base64 text: %v", err)
+		}
+		return parser.String(string(b)), nil
+	case "number":
+		return r.number(el, strings.TrimSpace(text))
+	case "boolean":
+		switch strings.TrimSpace(text) {
+		case "true":
+			return parser.Bool(true), nil
+		case "false":
+			return parser.Bool(false), nil
+		}
+		return nil, r.errorf(el, "invalid boolean %q", text)
+	case "null":
+		if strings.TrimSpace(text) != "" {
+			return nil, r.errorf(el, "an element of type null must be empty")
+		}
+		return parser.Null(), nil
+	case "object":
+		return r.object(el, a, attrs)
+	case "array":
+		if len(attrs) > 0 || strings.TrimSpace(text) != "" {
+			return nil, r.errorf(el, "an element of type array holds only item elements")
+		}
+		arr := parser.Array()
+		for _, child := range el.children {
+			v, err := r.value(child)
+			if err != nil {
+				return nil, err
+			}
+			arr.Append(v)
+		}
+		return arr, nil
+	default:
+		return nil, r.errorf(el, "unknown type %q", a.typ)
+	}
+}
+
+// number parses the text of a number element: a JSON number, NaN or an
+// infinity.
+func (r *reader) number(el *element, text string) (parser.Value, error) {
+	switch text {
+	case "NaN":
+		return parser.Number(math.NaN()), nil
+	case "Infinity":
+		return parser.Number(math.Inf(1)), nil
+	case "-Infinity":
+		return parser.Number(math.Inf(-1)), nil
+	}
+	if !jsonNumber.MatchString(text) {
+		return nil, r.errorf(el, "invalid number %q", text)
+	}
+	f, _ := strconv.ParseFloat(text, 64) // Out of range values round to ±Inf
+	return parser.Number(f), nil
+}
+
+// object converts an element to an object. Whitespace between child
+// elements is indentation and is dropped, unless xml:space="preserve"
+// says otherwise.
+func (r *reader) object(el *element, a annotations, attrs []stdxml.Attr) (parser.Value, error) {
+	o := parser.Object()
+	set := func(key string, v parser.Value) error {
+		if _, ok := o.Pairs[key]; ok {
+			return r.errorf(el, "duplicate member %q", key)
+		}
+		o.Set(key, v)
+		return nil
+	}
+
+	for _, attr := range attrs {
+		if err := set(r.m.AttributePrefix+DecodeName(rawName(attr.Name)), parser.String(attr.Value)); err != nil {
+			return nil, err
+		}
+	}
+
+	var text strings.Builder
+	for _, chunk := range el.text {
+		if a.preserve || strings.Trim(chunk, " \t\r\n") != "" {
+			text.WriteString(chunk)
+		}
+	}
+	if text.Len() > 0 {
+		if err := set(r.m.TextKey, parser.String(text.String())); err != nil {
+			return nil, err
+		}
+	}
+
+	// Group the children by name, in the order each name first appears
+	var names []string
+	groups := make(map[string][]*element)
+	for _, child := range el.children {
+		key := DecodeName(child.name)
+		if _, ok := groups[key]; !ok {
+			names = append(names, key)
+		}
+		groups[key] = append(groups[key], child)
+	}
+	for _, key := range names {
+		group := groups[key]
+		var values []parser.Value
+		array := len(group) > 1 || slices.Contains(r.m.Arrays, key)
+		for _, child := range group {
+			ca, _, err := r.split(child)
+			if err != nil {
+				return nil, err
+			}
+			array = array || ca.array
+			v, err := r.value(child)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		var v parser.Value = values[0]
+		if array {
+			v = parser.Array(values...)
+		}
+		if err := set(key, v); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+func (r *reader) errorf(el *element, format string, args ...any) error {
+	return e.NewParseError(el.line, el.column, fmt.Sprintf("<%s>: ", el.name)+fmt.Sprintf(format, args...))
+}
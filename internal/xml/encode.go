@@ -0,0 +1,295 @@
+// Package xml converts between the parser's AST and XML under a
+// configurable Mapping. Objects become elements whose members are child
+// elements, string members with the attribute prefix become attributes, a
+// text member becomes character data, and arrays become repeated elements.
+// With a typed mapping, json:type and json:array annotations record what
+// XML cannot express, so Parse(Marshal(v)) is equal to v.
+package xml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// namespace identifies the json:type and json:array annotations.
+const namespace = "urn:jsonparser:xml"
+
+// Mapping describes how JSON values and XML elements correspond.
+type Mapping struct {
+	// Root names the document element, which holds the JSON root value.
+	Root string
+	// ItemName names the elements of an array that is not an object member:
+	// the root array, and arrays nested directly in arrays.
+	ItemName string
+	// AttributePrefix marks the object members that are attributes: with
+	// "@", the member "@id": "7" is written and read as id="7". Only string
+	// members are written as attributes; others are child elements.
+	AttributePrefix string
+	// TextKey is the member holding an element's character data when the
+	// element also has attributes or children.
+	TextKey string
+	// Arrays lists element names that Parse always reads as arrays, even
+	// when they appear once. It is meant for XML without annotations, where
+	// only repeated elements are otherwise recognised as arrays.
+	Arrays []string
+	// Typed makes Marshal annotate elements whose JSON type the XML alone
+	// does not show: numbers, booleans, null, empty objects, empty and
+	// single-element arrays, and strings XML cannot hold. Without it the
+	// output is plainer, but reads back as strings.
+	Typed bool
+}
+
+// DefaultMapping returns the mapping the CLI uses: a root element named
+// "root", array items named "item", "@" for attributes, "#text" for
+// character data, and type annotations, so documents round-trip.
+func DefaultMapping() Mapping {
+	return Mapping{
+		Root:            "root",
+		ItemName:        "item",
+		AttributePrefix: "@",
+		TextKey:         "#text",
+		Typed:           true,
+	}
+}
+
+// check reports a mapping that cannot be used.
+func (m Mapping) check() error {
+	if !isName(m.Root) {
+		return fmt.Errorf("invalid XML mapping: root name %q is not an XML name", m.Root)
+	}
+	if !isName(m.ItemName) {
+		return fmt.Errorf("invalid XML mapping: item name %q is not an XML name", m.ItemName)
+	}
+	if m.AttributePrefix == "" {
+		return fmt.Errorf("invalid XML mapping: the attribute prefix is empty")
+	}
+	if m.TextKey == "" {
+		return fmt.Errorf("invalid XML mapping: the text key is empty")
+	}
+	return nil
+}
+
+// Marshal encodes a value as an XML document under the mapping's root
+// element, indented by two spaces. Object members become child elements
+// named after their keys, with keys that are not XML names escaped as
+// described at EncodeName. An array member becomes one element per
+// element of the array; other arrays hold ItemName elements. Elements with
+// both character data and children are written without indentation, since
+// it would change their text. Without Typed, a string XML cannot represent,
+// such as one holding U+0000, is an error.
+func Marshal(v parser.Value, m Mapping) (string, error) {
+	if err := m.check(); err != nil {
+		return "", err
+	}
+	e := &encoder{m: m}
+	e.sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	var attrs []string
+	if m.Typed {
+		attrs = append(attrs, attr("xmlns:json", namespace))
+	}
+	if err := e.writeElement(nil, m.Root, v, attrs, 0, false); err != nil {
+		return "", err
+	}
+	e.sb.WriteByte('\n')
+	return e.sb.String(), nil
+}
+
+type encoder struct {
+	m  Mapping
+	sb strings.Builder
+}
+
+// writeElement writes a value as an element with the given attributes,
+// already formatted by attr. In compact mode nothing is indented.
+func (e *encoder) writeElement(path parser.Path, name string, v parser.Value, attrs []string, depth int, compact bool) error {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		return e.writeObject(path, name, n, attrs, depth, compact)
+	case *parser.ArrayValue:
+		if e.m.Typed {
+			attrs = append(attrs, attr("json:type", "array"))
+		}
+		e.open(name, attrs)
+		if len(n.Elements) == 0 {
+			e.sb.WriteString("/>")
+			return nil
+		}
+		e.sb.WriteByte('>')
+		for i, elem := range n.Elements {
+			e.newline(depth+1, compact)
+			if err := e.writeElement(append(path, parser.IndexSegment(i)), e.m.ItemName, elem, nil, depth+1, compact); err != nil {
+				return err
+			}
+		}
+		e.newline(depth, compact)
+		e.sb.WriteString("</" + name + ">")
+		return nil
+	case *parser.StringValue:
+		text := n.Value
+		if !isText(text) {
+			if !e.m.Typed {
+				return fmt.Errorf("cannot encode the string at %s: it holds characters XML cannot represent", path.Describe())
+			}
+			attrs = append(attrs, attr("json:type", "base64"))
+			text = base64.StdEncoding.EncodeToString([]byte(text))
+		}
+		e.writeLeaf(name, attrs, escapeText(text))
+	case *parser.NumberValue:
+		if e.m.Typed {
+			attrs = append(attrs, attr("json:type", "number"))
+		}
+		e.writeLeaf(name, attrs, printer.FormatNumber(n.Value))
+	case *parser.BooleanValue:
+		if e.m.Typed {
+			attrs = append(attrs, attr("json:type", "boolean"))
+		}
+		e.writeLeaf(name, attrs, strconv.FormatBool(n.Value))
+	case *parser.NullValue:
+		if e.m.Typed {
+			attrs = append(attrs, attr("json:type", "null"))
+		}
+		e.writeLeaf(name, attrs, "")
+	default:
+		return fmt.Errorf("cannot encode %T at %s", v, path.Describe())
+	}
+	return nil
+}
+
+// writeObject writes an object's attribute members as attributes, its
+// text member as character data and the rest as child elements.
+func (e *encoder) writeObject(path parser.Path, name string, o *parser.ObjectValue, attrs []string, depth int, compact bool) error {
+	own := 0 // Attributes from members
+	var text string
+	var children []string
+	for _, key := range o.OrderedKeys() {
+		s, isString := o.Pairs[key].(*parser.StringValue)
+		switch {
+		case key == e.m.TextKey && isString && s.Value != "" && isText(s.Value):
+			text = s.Value
+		case strings.HasPrefix(key, e.m.AttributePrefix) && isString && isText(s.Value):
+			attrs = append(attrs, attr(EncodeName(key[len(e.m.AttributePrefix):]), s.Value))
+			own++
+		default:
+			children = append(children, key)
+		}
+	}
+
+	if e.m.Typed && own == 0 && len(children) == 0 {
+		attrs = append(attrs, attr("json:type", "object"))
+	}
+	// Readers drop whitespace between elements unless told to keep it
+	if text != "" && (len(children) > 0 || strings.Trim(text, " \t\r\n") == "") {
+		attrs = append(attrs, attr("xml:space", "preserve"))
+	}
+
+	e.open(name, attrs)
+	if text == "" && len(children) == 0 {
+		e.sb.WriteString("/>")
+		return nil
+	}
+	e.sb.WriteString(">" + escapeText(text))
+	compact = compact || text != ""
+	start := e.sb.Len()
+	for _, key := range children {
+		if err := e.writeMember(append(path.Clone(), parser.KeySegment(key)), key, o.Pairs[key], depth+1, compact); err != nil {
+			return err
+		}
+	}
+	if e.sb.Len() > start {
+		e.newline(depth, compact)
+	}
+	e.sb.WriteString("</" + name + ">")
+	return nil
+}
+
+// writeMember writes an object member as elements named after its key:
+// one per element for an array, and one otherwise. Typed output marks an
+// array with a single element, and writes an empty array as one element
+// with no items.
+func (e *encoder) writeMember(path parser.Path, key string, v parser.Value, depth int, compact bool) error {
+	name := EncodeName(key)
+	a, ok := v.(*parser.ArrayValue)
+	if !ok || (len(a.Elements) == 0 && e.m.Typed) {
+		e.newline(depth, compact)
+		return e.writeElement(path, name, v, nil, depth, compact)
+	}
+
+	var attrs []string
+	if e.m.Typed && len(a.Elements) == 1 {
+		attrs = append(attrs, attr("json:array", "true"))
+	}
+	for i, elem := range a.Elements {
+		e.newline(depth, compact)
+		if err := e.writeElement(append(path, parser.IndexSegment(i)), name, elem, attrs, depth, compact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) open(name string, attrs []string) {
+	e.sb.WriteString("<" + name)
+	for _, a := range attrs {
+		e.sb.WriteString(" " + a)
+	}
+}
+
+// writeLeaf writes an element holding only text.
+func (e *encoder) writeLeaf(name string, attrs []string, text string) {
+	e.open(name, attrs)
+	if text == "" {
+		e.sb.WriteString("/>")
+		return
+	}
+	e.sb.WriteString(">" + text + "</" + name + ">")
+}
+
+func (e *encoder) newline(depth int, compact bool) {
+	if !compact {
+		e.sb.WriteString("\n" + strings.Repeat("  ", depth))
+	}
+}
+
+// attr formats an attribute, escaping the value so that a reader gets it
+// back unchanged: whitespace characters are written as references, since
+// readers normalize literal ones to spaces.
+func attr(name, value string) string {
+	return name + `="` + attrEscaper.Replace(value) + `"`
+}
+
+var (
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+)
+
+// escapeText escapes character data. A carriage return is written as a
+// reference, since readers turn a literal one into a line feed.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// isText reports whether every character of s may appear in an XML 1.0
+// document.
+func isText(s string) bool {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || !isChar(r) {
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// isChar implements the Char production of XML 1.0.
+func isChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF || r >= 0xE000 && r <= 0xFFFD || r >= 0x10000 && r <= 0x10FFFF
+}
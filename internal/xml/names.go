@@ -0,0 +1,89 @@
+package xml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodeName turns a JSON key into an XML element or attribute name. Each
+// character a name cannot hold at its position, including ':', is written
+// as _xHHHH_ with its code point in hexadecimal, so "first name" becomes
+// "first_x0020_name" and "1st" becomes "_x0031_st". An underscore followed
+// by 'x' is escaped as well, and "xmlns" is escaped because it declares
+// namespaces. The empty key is written as "_x_". DecodeName reverses it.
+func EncodeName(key string) string {
+	if key == "" {
+		return "_x_"
+	}
+	var sb strings.Builder
+	for i, r := range key {
+		escape := false
+		switch {
+		case r == '_':
+			escape = strings.HasPrefix(key[i+1:], "x")
+		case i == 0:
+			escape = !isNameStart(r) || key == "xmlns"
+		default:
+			escape = !isNameChar(r)
+		}
+		if escape {
+			fmt.Fprintf(&sb, "_x%04X_", r)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+var escapedChar = regexp.MustCompile(`_x([0-9A-Fa-f]{4,6})_`)
+
+// DecodeName turns an element or attribute name back into a JSON key,
+// replacing each _xHHHH_ escape by its character. Names without escapes
+// are returned unchanged.
+func DecodeName(name string) string {
+	if name == "_x_" {
+		return ""
+	}
+	if !strings.Contains(name, "_x") {
+		return name
+	}
+	return escapedChar.ReplaceAllStringFunc(name, func(escape string) string {
+		code, _ := strconv.ParseUint(escape[2:len(escape)-1], 16, 32)
+		if !utf8.ValidRune(rune(code)) {
+			return escape
+		}
+		return string(rune(code))
+	})
+}
+
+// isName reports whether s is an XML name without a namespace prefix.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if i == 0 && !isNameStart(r) || i > 0 && !isNameChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNameStart implements the NameStartChar production of XML 1.0, without
+// the ':' reserved for namespace prefixes.
+func isNameStart(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r == '_' || r >= 'a' && r <= 'z' ||
+		r >= 0xC0 && r <= 0xD6 || r >= 0xD8 && r <= 0xF6 || r >= 0xF8 && r <= 0x2FF ||
+		r >= 0x370 && r <= 0x37D || r >= 0x37F && r <= 0x1FFF || r >= 0x200C && r <= 0x200D ||
+		r >= 0x2070 && r <= 0x218F || r >= 0x2C00 && r <= 0x2FEF || r >= 0x3001 && r <= 0xD7FF ||
+		r >= 0xF900 && r <= 0xFDCF || r >= 0xFDF0 && r <= 0xFFFD || r >= 0x10000 && r <= 0xEFFFF
+}
+
+// isNameChar implements the NameChar production of XML 1.0, without ':'.
+func isNameChar(r rune) bool {
+	return isNameStart(r) || r == '-' || r == '.' || r >= '0' && r <= '9' || r == 0xB7 ||
+		r >= 0x300 && r <= 0x36F || r >= 0x203F && r <= 0x2040
+}
@@ -0,0 +1,253 @@
+package xml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+	e "github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"typed object", `{"id": 7, "name": "Ada", "admin": true, "manager": null}`,
+			`<root xmlns:json="urn:jsonparser:xml">
+  <id json:type="number">7</id>
+  <name>Ada</name>
+  <admin json:type="boolean">true</admin>
+  <manager json:type="null"/>
+</root>`},
+		{"attributes and text", `{"book": {"@isbn": "123", "@year": 1999, "#text": "Dune"}}`,
+			`<root xmlns:json="urn:jsonparser:xml">
+  <book isbn="123" xml:space="preserve">Dune<_x0040_year json:type="number">1999</_x0040_year></book>
+</root>`},
+		{"arrays", `{"tags": ["a", "b"], "one": [1], "none": [], "grid": [[1, 2], []]}`,
+			`<root xmlns:json="urn:jsonparser:xml">
+  <tags>a</tags>
+  <tags>b</tags>
+  <one json:array="true" json:type="number">1</one>
+  <none json:type="array"/>
+  <grid json:type="array">
+    <item json:type="number">1</item>
+    <item json:type="number">2</item>
+  </grid>
+  <grid json:type="array"/>
+</root>`},
+		{"root array", `[{}, "x"]`,
+			`<root xmlns:json="urn:jsonparser:xml" json:type="array">
+  <item json:type="object"/>
+  <item>x</item>
+</root>`},
+		{"escaping", `{"first name": "a < b & \"c\"", "1st": "\r", "@a b": "x\ny", "_x": "", "": "empty"}`,
+			`<root xmlns:json="urn:jsonparser:xml" a_x0020_b="x&#xA;y">
+  <first_x0020_name>a &lt; b &amp; "c"</first_x0020_name>
+  <_x0031_st>&#xD;</_x0031_st>
+  <_x005F_x/>
+  <_x_>empty</_x_>
+</root>`},
+		{"base64", `{"s": "\u0000"}`,
+			`<root xmlns:json="urn:jsonparser:xml">
+  <s json:type="base64">AA==</s>
+</root>`},
+		{"whitespace text", `{"p": {"#text": "  "}}`,
+			`<root xmlns:json="urn:jsonparser:xml">
+  <p json:type="object" xml:space="preserve">  </p>
+</root>`},
+		{"scalar root", `1.5e300`, `<root xmlns:json="urn:jsonparser:xml" json:type="number">1.5e+300</root>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(testutil.JSON(t, tt.input), DefaultMapping())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := header + tt.expected + "\n"; got != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+			}
+		})
+	}
+}
+
+func TestMarshal_Untyped(t *testing.T) {
+	m := Mapping{Root: "order", ItemName: "entry", AttributePrefix: "-", TextKey: "$", Typed: false}
+	input := testutil.JSON(t, `{"-id": "42", "lines": [{"sku": "A1", "qty": 2}], "notes": [], "total": 9.5, "gift": false, "coupon": null, "matrix": [[1]]}`)
+	expected := header + `<order id="42">
+  <lines>
+    <sku>A1</sku>
+    <qty>2</qty>
+  </lines>
+  <total>9.5</total>
+  <gift>false</gift>
+  <coupon/>
+  <matrix>
+    <entry>1</entry>
+  </matrix>
+</order>
+`
+	got, err := Marshal(input, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping Mapping
+		message string
+	}{
+		{"invalid character untyped", `{"a": ["\u0001"]}`, Mapping{Root: "r", ItemName: "i", AttributePrefix: "@", TextKey: "#text"},
+			"cannot encode the string at a[0]"},
+		{"bad root name", `{}`, Mapping{Root: "1x", ItemName: "i", AttributePrefix: "@", TextKey: "#text"}, `root name "1x"`},
+		{"bad item name", `{}`, Mapping{Root: "r", ItemName: "a:b", AttributePrefix: "@", TextKey: "#text"}, `item name "a:b"`},
+		{"empty prefix", `{}`, Mapping{Root: "r", ItemName: "i", TextKey: "#text"}, "attribute prefix is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(testutil.JSON(t, tt.input), tt.mapping)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		arrays   []string
+		expected string // JSON5
+	}{
+		{"plain elements", `<order><id>42</id><note>  spaced  </note><empty/></order>`, nil,
+			`{"id": "42", "note": "  spaced  ", "empty": ""}`},
+		{"attributes and text", `<book lang="en" xmlns:x="urn:x"><title x:kind="main">Dune</title></book>`, nil,
+			`{"@lang": "en", "title": {"@x:kind": "main", "#text": "Dune"}}`},
+		{"repeated elements", "<r>\n  <a>1</a>\n  <b>x</b>\n  <a>2</a>\n</r>", nil,
+			`{"a": ["1", "2"], "b": "x"}`},
+		{"forced arrays", `<r><a>1</a><b><c>2</c></b></r>`, []string{"a", "c"},
+			`{"a": ["1"], "b": {"c": ["2"]}}`},
+		{"mixed content", `<p>Hello <b>world</b>!</p>`, nil, `{"#text": "Hello !", "b": "world"}`},
+		{"escaped names", `<r><first_x0020_name>Ada</first_x0020_name><_x0031_st>y</_x0031_st></r>`, nil,
+			`{"first name": "Ada", "1st": "y"}`},
+		{"entities and CDATA", `<r><a>&lt;&amp;&#x41;</a><b><![CDATA[<x>]]></b></r>`, nil, `{"a": "<&A", "b": "<x>"}`},
+		{"markup ignored", `<?xml version="1.0"?><!DOCTYPE r><!-- c --><r><?pi x?><a>1<!-- c --></a></r>`, nil, `{"a": "1"}`},
+		{"typed values", `<r xmlns:json="urn:jsonparser:xml"><n json:type="number"> -1.5e3 </n><t json:type="boolean">true</t><z json:type="null"/><s json:type="string">1</s></r>`, nil,
+			`{"n": -1500, "t": true, "z": null, "s": "1"}`},
+		{"non-finite numbers", `<r xmlns:json="urn:jsonparser:xml" json:type="array"><i json:type="number">NaN</i><i json:type="number">-Infinity</i></r>`, nil,
+			`[NaN, -Infinity]`},
+		{"typed arrays", `<r xmlns:json="urn:jsonparser:xml"><a json:array="true">x</a><e json:type="array"/><m json:type="array"><v>1</v><w>2</w></m></r>`, nil,
+			`{"a": ["x"], "e": [], "m": ["1", "2"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := DefaultMapping()
+			m.Arrays = tt.arrays
+			got, err := Parse(tt.input, m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		line    int
+		message string
+	}{
+		{"mismatched tags", "<r>\n<a></b></r>", 2, "unexpected end tag </b>"},
+		{"unclosed", "<r><a>", 1, "<a> is not closed"},
+		{"no root", "<!-- only -->", 1, "no root element"},
+		{"two roots", "<a/>\n<b/>", 2, "found another <b>"},
+		{"text outside", "<a/>text", 1, "text outside the root element"},
+		{"syntax", "<r>\n<a x=1/></r>", 2, "unquoted or missing attribute value"},
+		{"bad number", `<r xmlns:json="urn:jsonparser:xml"><n json:type="number">1.</n></r>`, 1, `<n>: invalid number "1."`},
+		{"bad boolean", `<r xmlns:json="urn:jsonparser:xml"><b json:type="boolean">yes</b></r>`, 1, `invalid boolean "yes"`},
+		{"bad type", `<r xmlns:json="urn:jsonparser:xml"><b json:type="date"/></r>`, 1, `unknown type "date"`},
+		{"unknown annotation", `<r xmlns:json="urn:jsonparser:xml"><b json:kind="x"/></r>`, 1, "unknown annotation json:kind"},
+		{"typed with children", `<r xmlns:json="urn:jsonparser:xml"><n json:type="number"><x/></n></r>`, 1, "cannot have attributes or children"},
+		{"duplicate member", `<r a="1"><_x0040_a>2</_x0040_a></r>`, 1, `duplicate member "@a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, DefaultMapping())
+			pe, ok := err.(*e.ParseError)
+			if !ok {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if pe.Line != tt.line || !strings.Contains(pe.Message, tt.message) {
+				t.Errorf("expected %q at line %d, got %q at line %d", tt.message, tt.line, pe.Message, pe.Line)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		key  string
+		name string
+	}{
+		{"name", "name"},
+		{"first name", "first_x0020_name"},
+		{"1st", "_x0031_st"},
+		{"a:b", "a_x003A_b"},
+		{"_x0041_", "_x005F_x0041_"},
+		{"_y", "_y"},
+		{"xmlns", "_x0078_mlns"},
+		{"xmlnsfoo", "xmlnsfoo"},
+		{"", "_x_"},
+		{"-", "_x002D_"},
+		{"a-b.c", "a-b.c"},
+		{"日本", "日本"},
+		{"😀", "😀"},
+		{"×", "_x00D7_"},
+		{"\U000F0000", "_xF0000_"},
+	}
+	for _, tt := range tests {
+		if got := EncodeName(tt.key); got != tt.name {
+			t.Errorf("EncodeName(%q): expected %q, got %q", tt.key, tt.name, got)
+		}
+		if got := DecodeName(tt.name); got != tt.key {
+			t.Errorf("DecodeName(%q): expected %q, got %q", tt.name, tt.key, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{}`, `[]`, `null`, `"text"`, `""`, `0`, `-0.5`, `true`,
+		`{"a": {}, "b": [], "c": [[]], "d": [[], [[]]], "e": [{}], "f": [null, [1], {"x": []}]}`,
+		`{"@id": "7", "@n": 7, "@obj": {"x": 1}, "@": "", "#text": "body", "child": {"#text": "t", "@k": "v"}}`,
+		`{"#text": 5, "x": {"#text": ""}, "y": {"#text": " \n "}, "z": {"#text": " pad ", "k": [1, 2]}}`,
+		`{"s": "line\r\nbreak\ttab", "@a": "line\r\nbreak\ttab", "c": "\u0000\u0008￿", "@c": "\u0001"}`,
+		`{"weird key": {"1": 1, ":": 2, "_x": 3, "xmlns": 4, "": 5, "a.b-c": 6}}`,
+		`{"big": 1e300, "small": 5e-324, "nan": NaN, "inf": -Infinity}`,
+		`[[1, [2, [3]]], {"items": [{"id": 1}, {"id": 2}]}]`,
+		`{"mixed": [1, "1", true, null, {}, []]}`,
+	}
+	mappings := []Mapping{DefaultMapping(), {Root: "doc", ItemName: "li", AttributePrefix: "_", TextKey: "value", Typed: true}}
+	for _, m := range mappings {
+		marshal := func(v parser.Value) (string, error) { return Marshal(v, m) }
+		parse := func(text string) (parser.Value, error) { return Parse(text, m) }
+		for _, input := range inputs {
+			testutil.RoundTrip(t, input, marshal, parse)
+		}
+	}
+}
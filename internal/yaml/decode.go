@@ -0,0 +1,1098 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/pkg/errors"
+)
+
+// maxAliasNodes bounds the number of nodes that alias expansion may create
+// in one document, so a small "billion laughs" input cannot exhaust memory.
+const maxAliasNodes = 1 << 20
+
+// Parse reads a YAML stream and returns one value per document. Scalars are
+// resolved with the YAML 1.2 core schema, aliases are replaced by copies of
+// their anchored values, and mapping keys become strings. Explicit keys
+// ("? "), complex keys and tags other than the core ones are not supported.
+func Parse(input string) ([]parser.Value, error) {
+	input = strings.TrimPrefix(input, "\uFEFF")
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	r := &reader{src: input, line: 1}
+
+	var docs []parser.Value
+	for {
+		if err := r.skipToContent(); err != nil {
+			return nil, err
+		}
+		for r.column() == 0 && r.ch() == '%' {
+			r.skipLine() // %YAML and %TAG directives
+			if err := r.skipToContent(); err != nil {
+				return nil, err
+			}
+		}
+		if r.eof() {
+			return docs, nil
+		}
+		if r.atMarker("...") {
+			r.advance(3)
+			continue
+		}
+		if r.atMarker("---") {
+			r.advance(3)
+		}
+
+		r.anchors, r.anchorSizes, r.expanded = map[string]parser.Value{}, map[string]int{}, 0
+		doc, err := r.parseBlockNode(-1, false)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+
+		if err := r.skipToContent(); err != nil {
+			return nil, err
+		}
+		switch {
+		case r.atMarker("..."):
+			r.advance(3)
+			if err := r.finishLine(); err != nil {
+				return nil, err
+			}
+		case !r.eof() && !r.atMarker("---"):
+			return nil, r.errorf("unexpected content after the document")
+		}
+	}
+}
+
+// ParseOne reads a stream that must hold exactly one document.
+func ParseOne(input string) (parser.Value, error) {
+	docs, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	switch len(docs) {
+	case 0:
+		return &parser.NullValue{}, nil
+	case 1:
+		return docs[0], nil
+	default:
+		return nil, fmt.Errorf("expected one YAML document, found %d", len(docs))
+	}
+}
+
+type reader struct {
+	src       string
+	pos       int
+	line      int // 1-based line of pos
+	lineStart int // Offset of the start of the current line
+
+	anchors     map[string]parser.Value
+	anchorSizes map[string]int
+	expanded    int // Nodes created by alias expansion so far
+}
+
+// state is a saved reader position for lookahead.
+type state struct {
+	pos, line, lineStart int
+}
+
+func (r *reader) save() state {
+	return state{r.pos, r.line, r.lineStart}
+}
+
+func (r *reader) restore(s state) {
+	r.pos, r.line, r.lineStart = s.pos, s.line, s.lineStart
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.src)
+}
+
+func (r *reader) ch() byte {
+	return r.at(r.pos)
+}
+
+func (r *reader) at(i int) byte {
+	if i < 0 || i >= len(r.src) {
+		return 0
+	}
+	return r.src[i]
+}
+
+// column returns the 0-based column of the reader.
+func (r *reader) column() int {
+	return r.pos - r.lineStart
+}
+
+func (r *reader) advance(n int) {
+	for ; n > 0 && r.pos < len(r.src); n-- {
+		if r.src[r.pos] == '\n' {
+			r.line++
+			r.lineStart = r.pos + 1
+		}
+		r.pos++
+	}
+}
+
+func (r *reader) errorf(format string, args ...any) error {
+	return errors.NewParseError(r.line, r.column()+1, fmt.Sprintf(format, args...))
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// isBlankOrEnd reports whether c ends a token: a blank, a line break or EOF.
+func isBlankOrEnd(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == 0
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
+
+// atMarker reports whether the reader is at a "---" or "..." document marker.
+func (r *reader) atMarker(marker string) bool {
+	return r.column() == 0 && strings.HasPrefix(r.src[r.pos:], marker) && isBlankOrEnd(r.at(r.pos+3))
+}
+
+func (r *reader) atAnyMarker() bool {
+	return r.atMarker("---") || r.atMarker("...")
+}
+
+func (r *reader) skipBlanks() {
+	for isBlank(r.ch()) {
+		r.advance(1)
+	}
+}
+
+func (r *reader) skipLine() {
+	for !r.eof() && r.ch() != '\n' {
+		r.advance(1)
+	}
+}
+
+// atLineEnd skips blanks and reports whether only a comment or the end of
+// the line follows.
+func (r *reader) atLineEnd() bool {
+	r.skipBlanks()
+	return r.eof() || r.ch() == '\n' || r.ch() == '#'
+}
+
+// finishLine skips the rest of a line after a value, which may only hold a
+// comment.
+func (r *reader) finishLine() error {
+	if !r.atLineEnd() {
+		return r.errorf("unexpected %q after value", r.ch())
+	}
+	r.skipLine()
+	return nil
+}
+
+// skipToContent moves to the next character that is not whitespace or part
+// of a comment. Indentation must be made of spaces.
+func (r *reader) skipToContent() error {
+	for !r.eof() {
+		switch c := r.ch(); {
+		case c == ' ':
+			r.advance(1)
+		case c == '\t':
+			if r.onlySpacesBefore() {
+				// A tab in indentation is only allowed on lines without content
+				s := r.save()
+				r.skipBlanks()
+				if !r.eof() && r.ch() != '\n' && r.ch() != '#' {
+					r.restore(s)
+					return r.errorf("tabs must not be used for indentation")
+				}
+			} else {
+				r.advance(1)
+			}
+		case c == '\n':
+			r.advance(1)
+		case c == '#':
+			r.skipLine()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// onlySpacesBefore reports whether the current line holds only spaces before
+// the reader.
+func (r *reader) onlySpacesBefore() bool {
+	return strings.Trim(r.src[r.lineStart:r.pos], " ") == ""
+}
+
+// skipFlowSpace skips whitespace, line breaks and comments inside a flow
+// collection.
+func (r *reader) skipFlowSpace() error {
+	for !r.eof() {
+		switch c := r.ch(); {
+		case isBlank(c) || c == '\n':
+			r.advance(1)
+		case c == '#':
+			r.skipLine()
+		default:
+			if r.atAnyMarker() {
+				return r.errorf("document marker inside a flow collection")
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseBlockNode parses a node in block context whose content must be
+// indented more than indent. sameLine is set for mapping values written on
+// the line of their key, where nested block collections cannot start.
+// Empty nodes are null.
+func (r *reader) parseBlockNode(indent int, sameLine bool) (parser.Value, error) {
+	line := r.line
+	if err := r.skipToContent(); err != nil {
+		return nil, err
+	}
+	if r.line != line {
+		sameLine = false
+	}
+	if r.eof() || r.atAnyMarker() || (!sameLine && r.column() <= indent) {
+		return &parser.NullValue{}, nil
+	}
+
+	anchor, tag, err := r.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+	var value parser.Value
+	if (anchor != "" || tag != "") && r.atLineEnd() {
+		// The properties apply to a node on the following lines
+		value, err = r.parseBlockNode(indent, false)
+	} else {
+		value, err = r.parseBlockContent(indent, sameLine, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCollectionTag(tag, value); err != nil {
+		return nil, r.errorf("%v", err)
+	}
+	r.setAnchor(anchor, value)
+	return value, nil
+}
+
+// parseBlockContent parses the node at the reader, after its properties.
+func (r *reader) parseBlockContent(indent int, sameLine bool, tag string) (parser.Value, error) {
+	col := r.column()
+	switch c := r.ch(); {
+	case c == '*':
+		value, err := r.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		return value, r.finishLine()
+	case c == '-' && isBlankOrEnd(r.at(r.pos+1)):
+		if sameLine {
+			return nil, r.errorf("a block sequence cannot start on the line of its key")
+		}
+		return r.parseBlockSequence(col)
+	case c == '?' && isBlankOrEnd(r.at(r.pos+1)):
+		return nil, r.errorf("explicit mapping keys are not supported")
+	case c == '[' || c == '{':
+		value, err := r.parseFlowCollection()
+		if err != nil {
+			return nil, err
+		}
+		r.skipBlanks()
+		if r.ch() == ':' {
+			return nil, r.errorf("collections cannot be mapping keys")
+		}
+		return value, r.finishLine()
+	case c == '|' || c == '>':
+		text, err := r.parseBlockScalar(indent)
+		if err != nil {
+			return nil, err
+		}
+		return r.resolve(text, false, tag)
+	}
+
+	if r.isImplicitKey() {
+		if sameLine {
+			return nil, r.errorf("a mapping cannot start on the line of its key")
+		}
+		return r.parseBlockMapping(col)
+	}
+
+	text, plain, err := r.parseScalar(indent, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.finishLine(); err != nil {
+		return nil, err
+	}
+	return r.resolve(text, plain, tag)
+}
+
+// parseBlockMapping parses a mapping whose keys start at column col. The
+// reader is at the first key.
+func (r *reader) parseBlockMapping(col int) (parser.Value, error) {
+	object := parser.Object()
+	for {
+		if r.ch() == '?' && isBlankOrEnd(r.at(r.pos+1)) {
+			return nil, r.errorf("explicit mapping keys are not supported")
+		}
+		if !r.isImplicitKey() {
+			return nil, r.errorf("expected a mapping key")
+		}
+		keyLine, keyCol := r.line, r.column()+1
+		key, err := r.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		r.skipBlanks()
+		r.advance(1) // ':'
+		if _, ok := object.Pairs[key]; ok {
+			return nil, errors.NewParseError(keyLine, keyCol, fmt.Sprintf("duplicate key %q", key))
+		}
+
+		value, err := r.parseMappingValue(col)
+		if err != nil {
+			return nil, err
+		}
+		object.Set(key, value)
+
+		if err := r.skipToContent(); err != nil {
+			return nil, err
+		}
+		if r.eof() || r.atAnyMarker() || r.column() < col {
+			return object, nil
+		}
+		if r.column() > col {
+			return nil, r.errorf("unexpected indentation")
+		}
+	}
+}
+
+// parseMappingValue parses the value after a key's ':' in a mapping at
+// column col. A sequence may sit at the mapping's own indentation.
+func (r *reader) parseMappingValue(col int) (parser.Value, error) {
+	if !r.atLineEnd() {
+		return r.parseBlockNode(col, true)
+	}
+	if err := r.skipToContent(); err != nil {
+		return nil, err
+	}
+	if !r.eof() && !r.atAnyMarker() && r.column() == col && r.ch() == '-' && isBlankOrEnd(r.at(r.pos+1)) {
+		return r.parseBlockSequence(col)
+	}
+	return r.parseBlockNode(col, false)
+}
+
+// parseBlockSequence parses a sequence whose "-" indicators are at column
+// col. The reader is at the first indicator.
+func (r *reader) parseBlockSequence(col int) (parser.Value, error) {
+	array := parser.Array()
+	for {
+		r.advance(1) // '-'
+		value, err := r.parseBlockNode(col, false)
+		if err != nil {
+			return nil, err
+		}
+		array.Append(value)
+
+		if err := r.skipToContent(); err != nil {
+			return nil, err
+		}
+		if r.eof() || r.atAnyMarker() || r.column() < col {
+			return array, nil
+		}
+		if r.column() > col {
+			return nil, r.errorf("unexpected indentation")
+		}
+		if r.ch() != '-' || !isBlankOrEnd(r.at(r.pos+1)) {
+			// A mapping key after a sequence at the mapping's indentation
+			return array, nil
+		}
+	}
+}
+
+// isImplicitKey reports whether the reader is at a single-line scalar
+// followed by ": ".
+func (r *reader) isImplicitKey() bool {
+	s := r.save()
+	defer r.restore(s)
+
+	line := r.line
+	if _, err := r.parseKey(); err != nil || r.line != line {
+		return false
+	}
+	r.skipBlanks()
+	return r.ch() == ':' && isBlankOrEnd(r.at(r.pos+1))
+}
+
+// parseKey parses a mapping key in block context: a quoted scalar or a
+// plain scalar on a single line.
+func (r *reader) parseKey() (string, error) {
+	switch r.ch() {
+	case '"':
+		return r.parseDoubleQuoted()
+	case '\'':
+		return r.parseSingleQuoted()
+	case '*', '&', '!':
+		return "", r.errorf("anchors, aliases and tags on keys are not supported")
+	}
+	start := r.pos
+	for !r.eof() && r.ch() != '\n' {
+		if r.ch() == ':' && isBlankOrEnd(r.at(r.pos+1)) {
+			break
+		}
+		if r.ch() == '#' && r.pos > start && isBlank(r.src[r.pos-1]) {
+			break
+		}
+		r.advance(1)
+	}
+	key := strings.TrimRight(r.src[start:r.pos], " \t")
+	if key == "" {
+		return "", r.errorf("expected a mapping key")
+	}
+	return key, nil
+}
+
+// parseProperties parses an optional anchor and tag, in either order.
+func (r *reader) parseProperties() (anchor, tag string, err error) {
+	for {
+		switch r.ch() {
+		case '&':
+			if anchor != "" {
+				return "", "", r.errorf("a node can have only one anchor")
+			}
+			r.advance(1)
+			if anchor = r.readName(); anchor == "" {
+				return "", "", r.errorf("expected an anchor name")
+			}
+		case '!':
+			if tag != "" {
+				return "", "", r.errorf("a node can have only one tag")
+			}
+			start := r.pos
+			if strings.HasPrefix(r.src[r.pos:], "!<") {
+				// A verbatim tag may contain flow indicators
+				for !isBlankOrEnd(r.ch()) && r.ch() != '>' {
+					r.advance(1)
+				}
+				r.advance(1)
+			} else {
+				for !isBlankOrEnd(r.ch()) && !isFlowIndicator(r.ch()) {
+					r.advance(1)
+				}
+			}
+			tag = r.src[start:r.pos]
+		default:
+			return anchor, tag, nil
+		}
+		r.skipBlanks()
+	}
+}
+
+// readName reads an anchor or alias name.
+func (r *reader) readName() string {
+	start := r.pos
+	for !isBlankOrEnd(r.ch()) && !isFlowIndicator(r.ch()) {
+		r.advance(1)
+	}
+	return r.src[start:r.pos]
+}
+
+func (r *reader) setAnchor(name string, value parser.Value) {
+	if name == "" {
+		return
+	}
+	r.anchors[name] = value
+	r.anchorSizes[name] = countNodes(value)
+}
+
+// parseAlias parses "*name" and returns a copy of the anchored value.
+func (r *reader) parseAlias() (parser.Value, error) {
+	r.advance(1)
+	name := r.readName()
+	value, ok := r.anchors[name]
+	if !ok {
+		return nil, r.errorf("unknown anchor %q", name)
+	}
+	r.expanded += r.anchorSizes[name]
+	if r.expanded > maxAliasNodes {
+		return nil, r.errorf("aliases expand to more than %d nodes", maxAliasNodes)
+	}
+	return parser.Clone(value), nil
+}
+
+func countNodes(v parser.Value) int {
+	n := 0
+	parser.Walk(v, parser.Funcs{OnEnter: func(parser.Path, parser.Value) parser.Action {
+		n++
+		return parser.Continue
+	}})
+	return n
+}
+
+// parseScalar parses a quoted or plain scalar. Continuation lines of a
+// plain scalar in block context must be indented more than indent.
+func (r *reader) parseScalar(indent int, flow bool) (text string, plain bool, err error) {
+	switch r.ch() {
+	case '"':
+		text, err = r.parseDoubleQuoted()
+		return text, false, err
+	case '\'':
+		text, err = r.parseSingleQuoted()
+		return text, false, err
+	case '%', '@', '`':
+		return "", false, r.errorf("%q is reserved and cannot start a plain scalar", r.ch())
+	}
+	return r.parsePlain(indent, flow), true, nil
+}
+
+// plainStop reports whether a plain scalar ends at the reader.
+func (r *reader) plainStop(flow bool) bool {
+	c := r.ch()
+	switch {
+	case c == 0 || c == '\n':
+		return true
+	case c == ':':
+		next := r.at(r.pos + 1)
+		return isBlankOrEnd(next) || (flow && isFlowIndicator(next))
+	case c == '#':
+		return r.pos > 0 && (isBlank(r.src[r.pos-1]) || r.src[r.pos-1] == '\n')
+	case flow && isFlowIndicator(c):
+		return true
+	}
+	return false
+}
+
+// parsePlain parses a plain scalar, folding continuation lines.
+func (r *reader) parsePlain(indent int, flow bool) string {
+	var sb strings.Builder
+	for {
+		// One line of the scalar, without trailing blanks
+		start, end := r.pos, r.pos
+		for !r.plainStop(flow) {
+			r.advance(1)
+			if !isBlank(r.src[r.pos-1]) {
+				end = r.pos
+			}
+		}
+		sb.WriteString(r.src[start:end])
+		if r.ch() != '\n' && !r.eof() {
+			r.restore(state{end, r.line, r.lineStart})
+			return sb.String()
+		}
+
+		// Look for a continuation line
+		s := state{end, r.line, r.lineStart}
+		breaks := 0
+		for r.ch() == '\n' {
+			r.advance(1)
+			breaks++
+			r.skipBlanks()
+		}
+		if r.eof() || r.atAnyMarker() || r.ch() == '#' || (!flow && r.column() <= indent) || r.plainStop(flow) {
+			r.restore(s)
+			return sb.String()
+		}
+		if breaks == 1 {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteString(strings.Repeat("\n", breaks-1))
+		}
+	}
+}
+
+// foldBreak folds the line break at the reader inside a quoted scalar,
+// including the indentation of the following lines. Blanks before the break
+// are dropped, so buf is cut back to keep.
+func (r *reader) foldBreak(buf []byte, keep int) []byte {
+	buf = buf[:keep]
+	breaks := 0
+	for r.ch() == '\n' {
+		r.advance(1)
+		breaks++
+		r.skipBlanks()
+	}
+	if breaks == 1 {
+		return append(buf, ' ')
+	}
+	return append(buf, strings.Repeat("\n", breaks-1)...)
+}
+
+func (r *reader) parseSingleQuoted() (string, error) {
+	r.advance(1)
+	var buf []byte
+	keep := 0 // Length of buf without trailing blanks
+	for {
+		switch c := r.ch(); {
+		case r.eof() || r.atAnyMarker():
+			return "", r.errorf("unterminated single-quoted string")
+		case c == '\'' && r.at(r.pos+1) == '\'':
+			buf = append(buf, '\'')
+			r.advance(2)
+		case c == '\'':
+			r.advance(1)
+			return string(buf), nil
+		case c == '\n':
+			buf = r.foldBreak(buf, keep)
+		default:
+			buf = append(buf, c)
+			r.advance(1)
+			if isBlank(c) {
+				continue
+			}
+		}
+		keep = len(buf)
+	}
+}
+
+// escapes maps the single-character escapes of double-quoted scalars.
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	'N': "\u0085", '_': "\u00A0", 'L': "\u2028", 'P': "\u2029",
+}
+
+func (r *reader) parseDoubleQuoted() (string, error) {
+	r.advance(1)
+	var buf []byte
+	keep := 0 // Length of buf without trailing blanks
+	for {
+		switch c := r.ch(); {
+		case r.eof() || r.atAnyMarker():
+			return "", r.errorf("unterminated double-quoted string")
+		case c == '"':
+			r.advance(1)
+			return string(buf), nil
+		case c == '\n':
+			buf = r.foldBreak(buf, keep)
+		case c == '\\':
+			var err error
+			if buf, err = r.readEscape(buf); err != nil {
+				return "", err
+			}
+		default:
+			buf = append(buf, c)
+			r.advance(1)
+			if isBlank(c) {
+				continue
+			}
+		}
+		keep = len(buf)
+	}
+}
+
+// readEscape decodes the escape sequence at the reader.
+func (r *reader) readEscape(buf []byte) ([]byte, error) {
+	c := r.at(r.pos + 1)
+	if c == '\n' {
+		// An escaped line break joins the lines without a space
+		r.advance(2)
+		for r.ch() == '\n' || isBlank(r.ch()) {
+			r.advance(1)
+		}
+		return buf, nil
+	}
+	if s, ok := escapes[c]; ok {
+		buf = append(buf, s...)
+		r.advance(2)
+		return buf, nil
+	}
+
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if digits == 0 {
+		return nil, r.errorf("invalid escape sequence \\%c", c)
+	}
+	hex := r.src[min(r.pos+2, len(r.src)):min(r.pos+2+digits, len(r.src))]
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != digits {
+		return nil, r.errorf("invalid escape sequence \\%c%s", c, hex)
+	}
+	r.advance(2 + digits)
+
+	// A UTF-16 surrogate pair written as two \u escapes
+	if 0xD800 <= code && code < 0xDC00 && strings.HasPrefix(r.src[r.pos:], `\u`) {
+		if low, err := strconv.ParseUint(r.src[r.pos+2:min(r.pos+6, len(r.src))], 16, 32); err == nil && 0xDC00 <= low && low < 0xE000 {
+			code = 0x10000 + (code-0xD800)<<10 + (low - 0xDC00)
+			r.advance(6)
+		}
+	}
+	if !utf8.ValidRune(rune(code)) {
+		code = utf8.RuneError
+	}
+	buf = utf8.AppendRune(buf, rune(code))
+	return buf, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar whose
+// content is indented more than indent.
+func (r *reader) parseBlockScalar(indent int) (string, error) {
+	literal := r.ch() == '|'
+	r.advance(1)
+
+	chomp, explicit := byte(0), 0
+	for i := 0; i < 2; i++ {
+		switch c := r.ch(); {
+		case (c == '+' || c == '-') && chomp == 0:
+			chomp = c
+			r.advance(1)
+		case '1' <= c && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+			r.advance(1)
+		}
+	}
+	if !isBlankOrEnd(r.ch()) {
+		return "", r.errorf("invalid block scalar header")
+	}
+	if err := r.finishLine(); err != nil {
+		return "", err
+	}
+
+	contentIndent := -1
+	if explicit > 0 {
+		contentIndent = indent + explicit
+	}
+
+	// Collect the lines; empty lines are kept as "" for folding and chomping
+	var lines []string
+	end := r.save()
+	for r.ch() == '\n' {
+		lineEnd := r.save()
+		r.advance(1)
+		spaces := 0
+		for r.ch() == ' ' && (contentIndent < 0 || spaces < contentIndent) {
+			r.advance(1)
+			spaces++
+		}
+		if r.eof() || r.ch() == '\n' {
+			lines = append(lines, "")
+			end = r.save()
+			continue
+		}
+		if contentIndent < 0 {
+			if spaces <= indent {
+				r.restore(lineEnd)
+				break
+			}
+			contentIndent = spaces
+		}
+		if spaces < contentIndent || r.atAnyMarker() {
+			r.restore(lineEnd)
+			break
+		}
+		start := r.pos
+		r.skipLine()
+		lines = append(lines, r.src[start:r.pos])
+		end = r.save()
+	}
+	r.restore(end)
+
+	// Separate the trailing empty lines, which only chomping keeps
+	n := len(lines)
+	for n > 0 && lines[n-1] == "" {
+		n--
+	}
+	content, trailing := lines[:n], len(lines)-n
+
+	var sb strings.Builder
+	if literal {
+		sb.WriteString(strings.Join(content, "\n"))
+	} else {
+		foldLines(&sb, content)
+	}
+	switch chomp {
+	case '-':
+	case '+':
+		if n > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(strings.Repeat("\n", trailing))
+	default:
+		if n > 0 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+// foldLines joins the lines of a folded block scalar: a single line break
+// between two lines of text becomes a space, and each empty line a line
+// break. Lines that start with a blank are "more indented" and keep their
+// line breaks.
+func foldLines(sb *strings.Builder, lines []string) {
+	moreIndented := func(s string) bool {
+		return s != "" && isBlank(s[0])
+	}
+	prev := -1 // Index of the previous line with text
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if prev >= 0 {
+			breaks := i - prev
+			if moreIndented(line) || moreIndented(lines[prev]) {
+				sb.WriteString(strings.Repeat("\n", breaks))
+			} else if breaks == 1 {
+				sb.WriteByte(' ')
+			} else {
+				sb.WriteString(strings.Repeat("\n", breaks-1))
+			}
+		} else {
+			sb.WriteString(strings.Repeat("\n", i)) // Leading empty lines
+		}
+		sb.WriteString(line)
+		prev = i
+	}
+}
+
+// parseFlowNode parses a node inside a flow collection.
+func (r *reader) parseFlowNode() (parser.Value, error) {
+	if err := r.skipFlowSpace(); err != nil {
+		return nil, err
+	}
+	anchor, tag, err := r.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.skipFlowSpace(); err != nil {
+		return nil, err
+	}
+
+	var value parser.Value
+	switch c := r.ch(); {
+	case c == '[' || c == '{':
+		value, err = r.parseFlowCollection()
+	case c == '*':
+		value, err = r.parseAlias()
+	case c == ',' || c == ']' || c == '}' || (c == ':' && isBlankOrEnd(r.at(r.pos+1))):
+		value, err = r.resolve("", true, tag) // Empty node
+	case r.eof():
+		err = r.errorf("unterminated flow collection")
+	default:
+		var text string
+		var plain bool
+		if text, plain, err = r.parseScalar(-1, true); err == nil {
+			value, err = r.resolve(text, plain, tag)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCollectionTag(tag, value); err != nil {
+		return nil, r.errorf("%v", err)
+	}
+	r.setAnchor(anchor, value)
+	return value, nil
+}
+
+// parseFlowCollection parses a [sequence] or {mapping}.
+func (r *reader) parseFlowCollection() (parser.Value, error) {
+	open := r.ch()
+	r.advance(1)
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+
+	array, object := parser.Array(), parser.Object()
+	for {
+		if err := r.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+		if r.eof() {
+			return nil, r.errorf("unterminated flow collection")
+		}
+		if r.ch() == closing {
+			r.advance(1)
+			break
+		}
+
+		keyLine, keyCol := r.line, r.column()+1
+		entry, err := r.parseFlowNode()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+
+		if r.ch() == ':' || open == '{' {
+			// A key/value pair; in a sequence it is a single-pair mapping
+			key, ok := entry.(*parser.StringValue)
+			if !ok {
+				if !isScalar(entry) {
+					return nil, errors.NewParseError(keyLine, keyCol, "collections cannot be mapping keys")
+				}
+				key = &parser.StringValue{Value: scalarText(entry)}
+			}
+			var value parser.Value = &parser.NullValue{}
+			if r.ch() == ':' {
+				r.advance(1)
+				if value, err = r.parseFlowNode(); err != nil {
+					return nil, err
+				}
+				if err := r.skipFlowSpace(); err != nil {
+					return nil, err
+				}
+			}
+			if open == '{' {
+				if _, ok := object.Pairs[key.Value]; ok {
+					return nil, errors.NewParseError(keyLine, keyCol, fmt.Sprintf("duplicate key %q", key.Value))
+				}
+				object.Set(key.Value, value)
+			} else {
+				array.Append(parser.Object().Set(key.Value, value))
+			}
+		} else {
+			array.Append(entry)
+		}
+
+		switch r.ch() {
+		case ',':
+			r.advance(1)
+		case closing:
+		default:
+			if r.eof() {
+				return nil, r.errorf("unterminated flow collection")
+			}
+			return nil, r.errorf("expected ',' or '%c'", closing)
+		}
+	}
+
+	if open == '{' {
+		return object, nil
+	}
+	return array, nil
+}
+
+func isScalar(v parser.Value) bool {
+	switch v.(type) {
+	case *parser.ObjectValue, *parser.ArrayValue:
+		return false
+	}
+	return true
+}
+
+// scalarText formats a non-string scalar used as a mapping key.
+func scalarText(v parser.Value) string {
+	switch n := v.(type) {
+	case *parser.NumberValue:
+		return formatNumber(n.Value)
+	case *parser.BooleanValue:
+		return strconv.FormatBool(n.Value)
+	default:
+		return "null"
+	}
+}
+
+// Core schema patterns for plain scalars.
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	octalPattern = regexp.MustCompile(`^0o[0-7]+$`)
+	hexPattern   = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	infPattern   = regexp.MustCompile(`^[-+]?\.(inf|Inf|INF)$`)
+	nanPattern   = regexp.MustCompile(`^\.(nan|NaN|NAN)$`)
+)
+
+// resolvePlain resolves an untagged plain scalar with the core schema.
+func resolvePlain(s string) parser.Value {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return &parser.NullValue{}
+	case "true", "True", "TRUE":
+		return &parser.BooleanValue{Value: true}
+	case "false", "False", "FALSE":
+		return &parser.BooleanValue{Value: false}
+	}
+	if f, ok := parseNumber(s); ok {
+		return &parser.NumberValue{Value: f}
+	}
+	return &parser.StringValue{Value: s}
+}
+
+// parseNumber parses the core schema's integer and float forms.
+func parseNumber(s string) (float64, bool) {
+	switch {
+	case intPattern.MatchString(s) || numberPattern.MatchString(s):
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil || math.IsInf(f, 0)
+	case octalPattern.MatchString(s):
+		n, err := strconv.ParseUint(s[2:], 8, 64)
+		return float64(n), err == nil
+	case hexPattern.MatchString(s):
+		n, err := strconv.ParseUint(s[2:], 16, 64)
+		return float64(n), err == nil
+	case infPattern.MatchString(s):
+		if s[0] == '-' {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case nanPattern.MatchString(s):
+		return math.NaN(), true
+	}
+	return 0, false
+}
+
+// normalizeTag expands the verbatim form of core tags to the "!!" shorthand.
+func normalizeTag(tag string) string {
+	if strings.HasPrefix(tag, "!<tag:yaml.org,2002:") && strings.HasSuffix(tag, ">") {
+		return "!!" + strings.TrimSuffix(strings.TrimPrefix(tag, "!<tag:yaml.org,2002:"), ">")
+	}
+	return tag
+}
+
+// resolve turns scalar text into a value, honouring a core schema tag.
+func (r *reader) resolve(text string, plain bool, tag string) (parser.Value, error) {
+	switch normalizeTag(tag) {
+	case "":
+		if plain {
+			return resolvePlain(text), nil
+		}
+		return &parser.StringValue{Value: text}, nil
+	case "!", "!!str":
+		return &parser.StringValue{Value: text}, nil
+	case "!!null":
+		if _, ok := resolvePlain(text).(*parser.NullValue); !ok {
+			return nil, r.errorf("%q is not a null", text)
+		}
+		return &parser.NullValue{}, nil
+	case "!!bool":
+		if b, ok := resolvePlain(text).(*parser.BooleanValue); ok {
+			return b, nil
+		}
+		return nil, r.errorf("%q is not a boolean", text)
+	case "!!int", "!!float":
+		if f, ok := parseNumber(text); ok {
+			return &parser.NumberValue{Value: f}, nil
+		}
+		return nil, r.errorf("%q is not a number", text)
+	case "!!map", "!!seq":
+		return nil, r.errorf("tag %s cannot be used on a scalar", tag)
+	default:
+		return nil, r.errorf("unsupported tag %s", tag)
+	}
+}
+
+// checkCollectionTag checks the tag of a collection.
+func checkCollectionTag(tag string, v parser.Value) error {
+	switch v.(type) {
+	case *parser.ObjectValue:
+		if tag = normalizeTag(tag); tag != "" && tag != "!" && tag != "!!map" {
+			return fmt.Errorf("tag %s cannot be used on a mapping", tag)
+		}
+	case *parser.ArrayValue:
+		if tag = normalizeTag(tag); tag != "" && tag != "!" && tag != "!!seq" {
+			return fmt.Errorf("tag %s cannot be used on a sequence", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package yaml converts between the parser's AST and YAML 1.2. Marshal
+// emits block-style YAML; Parse reads the JSON-compatible subset of YAML
+// (block and flow collections, all scalar styles, anchors and aliases, and
+// multi-document streams) into the same AST the JSON parser produces.
+package yaml
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/printer"
+)
+
+// Marshal emits a value as a YAML 1.2 document in block style, ending with a
+// newline. Object members keep their source order. Strings are written plain
+// when the core schema reads them back as the same string, and double-quoted
+// otherwise. Comments are not emitted.
+func Marshal(v parser.Value) string {
+	var sb strings.Builder
+	writeNode(&sb, v, 0)
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// MarshalAll emits values as a multi-document stream, each document
+// introduced by "---".
+func MarshalAll(values []parser.Value) string {
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteString("---")
+		if isBlockCollection(v) {
+			sb.WriteByte('\n')
+		} else {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(Marshal(v))
+	}
+	return sb.String()
+}
+
+// writeNode writes v with its first line at the current position and later
+// lines indented by indent spaces.
+func writeNode(sb *strings.Builder, v parser.Value, indent int) {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		if len(n.Pairs) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		for i, key := range n.OrderedKeys() {
+			if i > 0 {
+				newline(sb, indent)
+			}
+			writeString(sb, key)
+			sb.WriteByte(':')
+			value := n.Pairs[key]
+			if isBlockCollection(value) {
+				newline(sb, indent+2)
+				writeNode(sb, value, indent+2)
+			} else {
+				sb.WriteByte(' ')
+				writeNode(sb, value, indent+2)
+			}
+		}
+	case *parser.ArrayValue:
+		if len(n.Elements) == 0 {
+			sb.WriteString("[]")
+			return
+		}
+		for i, elem := range n.Elements {
+			if i > 0 {
+				newline(sb, indent)
+			}
+			sb.WriteString("- ")
+			writeNode(sb, elem, indent+2) // Collections start on the same line
+		}
+	case *parser.StringValue:
+		writeString(sb, n.Value)
+	case *parser.NumberValue:
+		sb.WriteString(formatNumber(n.Value))
+	case *parser.BooleanValue:
+		sb.WriteString(strconv.FormatBool(n.Value))
+	default:
+		sb.WriteString("null")
+	}
+}
+
+// isBlockCollection reports whether v is written as an indented block, that
+// is, a non-empty object or array.
+func isBlockCollection(v parser.Value) bool {
+	switch n := v.(type) {
+	case *parser.ObjectValue:
+		return len(n.Pairs) > 0
+	case *parser.ArrayValue:
+		return len(n.Elements) > 0
+	}
+	return false
+}
+
+func newline(sb *strings.Builder, indent int) {
+	sb.WriteByte('\n')
+	sb.WriteString(strings.Repeat(" ", indent))
+}
+
+// formatNumber writes numbers as the JSON printer does, but NaN and the
+// infinities in YAML spelling.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	default:
+		return printer.FormatNumber(f)
+	}
+}
+
+// yaml11Words are plain scalars that YAML 1.1 reads as booleans. They are
+// strings in YAML 1.2, but are quoted so older readers agree.
+var yaml11Words = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"n": true, "N": true, "no": true, "No": true, "NO": true,
+	"on": true, "On": true, "ON": true, "off": true, "Off": true, "OFF": true,
+}
+
+// writeString writes s plain if that is safe, and double-quoted otherwise.
+func writeString(sb *strings.Builder, s string) {
+	if isPlainSafe(s) {
+		sb.WriteString(s)
+		return
+	}
+
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7F || (0x80 <= r && r <= 0x9F) || r == 0xFEFF:
+			sb.WriteString(`\u`)
+			sb.WriteString(strconv.FormatInt(int64(r)+0x10000, 16)[1:])
+		case r == utf8.RuneError && size == 1:
+			sb.WriteRune(utf8.RuneError)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('"')
+}
+
+// isPlainSafe reports whether s can be written as a plain scalar that reads
+// back as the same string in both block and flow context.
+func isPlainSafe(s string) bool {
+	if s == "" || yaml11Words[s] || resolvesToNonString(s) {
+		return false
+	}
+	if s[0] == ' ' || s[len(s)-1] == ' ' || s[len(s)-1] == ':' {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7F || (0x80 <= r && r <= 0x9F) || r == 0xFEFF || r == utf8.RuneError ||
+			strings.ContainsRune(",[]{}", r) {
+			return false
+		}
+	}
+	return true
+}
+
+var numberPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+
+// resolvesToNonString reports whether a plain scalar would be read as null,
+// a boolean or a number. Anything that looks numeric is included, so
+// strings such as "1_000" and "0b101" are quoted too.
+func resolvesToNonString(s string) bool {
+	if _, ok := resolvePlain(s).(*parser.StringValue); !ok {
+		return true
+	}
+	return numberPattern.MatchString(strings.ReplaceAll(s, "_", "")) ||
+		strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") || strings.HasPrefix(s, "0b")
+}
@@ -0,0 +1,191 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/letsmakecakes/jsonparser/internal/parser"
+	"github.com/letsmakecakes/jsonparser/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // JSON5
+	}{
+		{"plain scalars", "a: hello world\nb: 42\nc: -1.5e3\nd: true\ne: ~\nf:\n", `{"a": "hello world", "b": 42, "c": -1500, "d": true, "e": null, "f": null}`},
+		{"core schema", "- 0o17\n- 0x1F\n- .inf\n- -.Inf\n- .NaN\n- False\n- NULL\n- yes\n- 1_000\n- +12\n- .5", `[15, 31, Infinity, -Infinity, NaN, false, null, "yes", "1_000", 12, 0.5]`},
+		{"quoted scalars", `a: "1"` + "\nb: 'it''s'\nc: \"tab\\tnew\\nline \\u00e9 \\U0001F600\"\n", `{"a": "1", "b": "it's", "c": "tab\tnew\nline é 😀"}`},
+		{"surrogate pair", `"\ud83d\ude00"`, `"😀"`},
+		{"nested mapping", "server:\n  host: localhost\n  ports:\n    http: 80\n", `{"server": {"host": "localhost", "ports": {"http": 80}}}`},
+		{"sequence at key indentation", "items:\n- a\n- b\nnext: 1\n", `{"items": ["a", "b"], "next": 1}`},
+		{"compact nested", "- name: a\n  tags: [x, y]\n- - 1\n  - 2\n-\n  deep: true\n", `[{"name": "a", "tags": ["x", "y"]}, [1, 2], {"deep": true}]`},
+		{"empty entries", "- \n-\n- ~\n", `[null, null, null]`},
+		{"flow collections", "{a: [1, 2, {b: c}], 'd': \"e\", f, g: }", `{"a": [1, 2, {"b": "c"}], "d": "e", "f": null, "g": null}`},
+		{"flow over lines", "list: [\n  one,  # first\n  two,\n]\n", `{"list": ["one", "two"]}`},
+		{"flow pair in sequence", "[a: 1, b]", `[{"a": 1}, "b"]`},
+		{"json", `{"a": [1, 2.5, "x", null, true], "b": {}, "c": []}`, `{"a": [1, 2.5, "x", null, true], "b": {}, "c": []}`},
+		{"json without spaces", `{"a":1,"b":[true,false]}`, `{"a": 1, "b": [true, false]}`},
+		{"comments", "# header\na: 1 # trailing\n# between\nb: x#y\n", `{"a": 1, "b": "x#y"}`},
+		{"urls and colons", "url: http://example.com:8080/\ntime: 12:30\n", `{"url": "http://example.com:8080/", "time": "12:30"}`},
+		{"multi-line plain", "text: first\n  second\n\n  third\n", `{"text": "first second\nthird"}`},
+		{"multi-line quoted", "a: \"one\n  two \\\n  three\"\nb: 'x\n\n  y'\n", `{"a": "one two three", "b": "x\ny"}`},
+		{"literal block", "a: |\n  line 1\n    indented\n  line 3\n\nb: 1\n", `{"a": "line 1\n  indented\nline 3\n", "b": 1}`},
+		{"folded block", "a: >\n  one\n  two\n\n  three\n    more\n  four\n", `{"a": "one two\nthree\n  more\nfour\n"}`},
+		{"chomping", "strip: |-\n  x\n\nclip: |\n  x\n\nkeep: |+\n  x\n\nend: 1\n", `{"strip": "x", "clip": "x\n", "keep": "x\n\n", "end": 1}`},
+		{"indentation indicator", "- |1\n  leading space\n", `[" leading space\n"]`},
+		{"top-level block scalar", "--- >\nfolded\ntext\n", `"folded text\n"`},
+		{"anchors and aliases", "base: &base\n  x: 1\n  y: [a, b]\ncopy: *base\nlist: [&n 5, *n]\n", `{"base": {"x": 1, "y": ["a", "b"]}, "copy": {"x": 1, "y": ["a", "b"]}, "list": [5, 5]}`},
+		{"tags", "a: !!str 123\nb: !!float '1.5'\nc: !!int \"7\"\nd: !!bool true\ne: !!map {x: 1}\nf: !<tag:yaml.org,2002:str> true\n", `{"a": "123", "b": 1.5, "c": 7, "d": true, "e": {"x": 1}, "f": "true"}`},
+		{"quoted keys", "\"a b\": 1\n'c:d': 2\n3: three\n", `{"a b": 1, "c:d": 2, "3": "three"}`},
+		{"directives and markers", "%YAML 1.2\n---\na: 1\n...\n", `{"a": 1}`},
+		{"scalar root", "hello", `"hello"`},
+		{"crlf", "a: 1\r\nb:\r\n  - x\r\n", `{"a": 1, "b": ["x"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOne(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Expect(t, got, tt.expected)
+		})
+	}
+}
+
+func TestParse_Streams(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"comments only", "# nothing\n", nil},
+		{"single implicit", "a: 1", []string{`{"a": 1}`}},
+		{"several", "---\na: 1\n---\n- 2\n--- 3\n", []string{`{"a": 1}`, `[2]`, `3`}},
+		{"empty documents", "---\n---\n", []string{`null`, `null`}},
+		{"end markers", "a: 1\n...\n---\nb: 2\n...\n", []string{`{"a": 1}`, `{"b": 2}`}},
+		{"anchor redefined", "- &a 1\n- *a\n- &a 2\n- *a\n", []string{`[1, 1, 2, 2]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(docs) != len(tt.expected) {
+				t.Fatalf("expected %d documents, got %d", len(tt.expected), len(docs))
+			}
+			for i, doc := range docs {
+				testutil.Expect(t, doc, tt.expected[i])
+			}
+		})
+	}
+}
+
+func TestParse_AliasesAreCopies(t *testing.T) {
+	got, err := ParseOne("a: &x [1]\nb: *x\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, _ := got.Get("a")
+	b, _ := got.Get("b")
+	a.(*parser.ArrayValue).Append(parser.Number(2))
+	if n, _ := b.Len(); n != 1 {
+		t.Errorf("expected the alias to be an independent copy")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"duplicate key", "a: 1\na: 2\n", `duplicate key "a"`},
+		{"bad indentation", "a:\n    b: 1\n  c: 2\n", "unexpected indentation"},
+		{"nested mapping on key line", "a: b: c\n", "mapping cannot start"},
+		{"sequence on key line", "a: - b\n", "sequence cannot start"},
+		{"unknown alias", "a: *missing\n", `unknown anchor "missing"`},
+		{"alias from another document", "--- &a 1\n--- *a\n", `unknown anchor "a"`},
+		{"unterminated flow", "[1, 2\n", "unterminated flow collection"},
+		{"missing comma", `["a" "b"]`, "expected ',' or ']'"},
+		{"unterminated string", `"abc`, "unterminated double-quoted string"},
+		{"invalid escape", `"\q"`, `invalid escape sequence \q`},
+		{"tab indentation", "a:\n\tb: 1\n", "tabs must not be used"},
+		{"explicit key", "? a\n: b\n", "explicit mapping keys are not supported"},
+		{"unsupported tag", "!custom x", "unsupported tag !custom"},
+		{"bad typed scalar", "!!int abc", `"abc" is not a number`},
+		{"collection key", "[a]: b\n", "collections cannot be mapping keys"},
+		{"trailing content", "\"a\" b\n", "after value"},
+		{"reserved indicator", "@foo", "reserved"},
+		{"alias bomb", "a: &a [x, x, x, x, x, x, x, x, x, x]\nb: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]\n" +
+			"c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]\nd: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]\n" +
+			"e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]\nf: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e, *e]\n", "aliases expand to more than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.message)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // JSON5
+		expected string
+	}{
+		{"scalars", `[1, 2.5, -0.001, 1e300, true, null, Infinity, -Infinity]`, "- 1\n- 2.5\n- -0.001\n- 1e+300\n- true\n- null\n- .inf\n- -.inf\n"},
+		{"nested", `{"server": {"host": "localhost", "ports": [80, 443]}, "empty": {}, "none": []}`,
+			"server:\n  host: localhost\n  ports:\n    - 80\n    - 443\nempty: {}\nnone: []\n"},
+		{"sequence of mappings", `[{"a": 1, "b": [true]}, [1, [2]]]`, "- a: 1\n  b:\n    - true\n- - 1\n  - - 2\n"},
+		{"quoting", `["true", "1.5", "", " x", "a: b", "- x", "#x", "yes", "null", "1_000", "ok", "x #y", "a\nb", "é", "[x]", "key:"]`,
+			"- \"true\"\n- \"1.5\"\n- \"\"\n- \" x\"\n- \"a: b\"\n- \"- x\"\n- \"#x\"\n- \"yes\"\n- \"null\"\n- \"1_000\"\n- ok\n- \"x #y\"\n- \"a\\nb\"\n- é\n- \"[x]\"\n- \"key:\"\n"},
+		{"keys", `{"a b": 1, "1": 2, "x:y": 3, "": 4}`, "a b: 1\n\"1\": 2\nx:y: 3\n\"\": 4\n"},
+		{"scalar root", `"text"`, "text\n"},
+		{"control characters", `"\u0001\t\""`, "\"\\u0001\\t\\\"\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Marshal(testutil.JSON(t, tt.input)); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarshalAll(t *testing.T) {
+	values := []parser.Value{testutil.JSON(t, `{"a": 1}`), testutil.JSON(t, `"x"`)}
+	expected := "---\na: 1\n--- x\n"
+	if got := MarshalAll(values); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		`{"name": "parser", "version": 1.2, "tags": ["json", "yaml"], "meta": {"nested": {"deep": [1, [2, [3]]]}}}`,
+		`[{"a": null}, {}, [], "", " ", "true", "-", "? x", "x: y", "'q'", "\"dq\"", "a\\b", "tab\there"]`,
+		`{"keys with spaces": 1, "-dash": 2, "[bracket]": 3, "#hash": 4, "&anchor": 5, "*alias": 6, "!tag": 7, "|pipe": 8}`,
+		`{"unicode": "日本語 ☃ 😀", "control": "\u0000\u001f\u007f", "nel": "\u0085", "bom": "\ufeff"}`,
+		`[0, -0.5, 1e21, 123456789012, 3.14159, 1e-7]`,
+		`"multi\nline\n\nstring\n"`,
+	}
+
+	marshal := func(v parser.Value) (string, error) { return Marshal(v), nil }
+	for _, input := range inputs {
+		testutil.RoundTrip(t, input, marshal, ParseOne)
+	}
+}
@@ -39,3 +39,25 @@ func NewValidationError(message string) error {
 		Message: message,
 	}
 }
+
+// AccessError represents a failed lookup or type conversion on a parsed value.
+type AccessError struct {
+	Path    string
+	Message string
+}
+
+// Error formats the AccessError into a readable string.
+func (e *AccessError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("access error: %s", e.Message)
+	}
+	return fmt.Sprintf("access error at %s: %s", e.Path, e.Message)
+}
+
+// NewAccessError creates a new instance of AccessError.
+func NewAccessError(path, message string) error {
+	return &AccessError{
+		Path:    path,
+		Message: message,
+	}
+}